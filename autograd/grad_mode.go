@@ -0,0 +1,20 @@
+package autograd
+
+// gradDisabled counts the active NoGrad calls; operations are only recorded on the tape while it is zero.
+var gradDisabled int
+
+// NoGrad runs fn with gradient recording disabled, like torch.no_grad.
+//
+// Operations performed inside fn return tensors that do not require gradients and do not record their inputs, which
+// saves memory and time during evaluation and when updating parameters. Calls can be nested. The setting is global,
+// so NoGrad should not be used concurrently with operations that are meant to be recorded.
+func NoGrad(fn func()) {
+	gradDisabled++
+	defer func() { gradDisabled-- }()
+	fn()
+}
+
+// IsGradEnabled reports whether operations are currently recorded for automatic differentiation.
+func IsGradEnabled() bool {
+	return gradDisabled == 0
+}
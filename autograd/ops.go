@@ -0,0 +1,364 @@
+package autograd
+
+import (
+	"fmt"
+
+	"github.com/timotewb/gonn/numpy"
+)
+
+// record creates the result tensor of an operation, adding it to the tape when gradients are enabled and one of the
+// inputs requires them.
+func record(op string, value *numpy.NDArray, parents []*Tensor, backward func(grad *numpy.NDArray) ([]*numpy.NDArray, error)) *Tensor {
+	out := &Tensor{value: value}
+	if !IsGradEnabled() {
+		return out
+	}
+	for _, p := range parents {
+		if p.requiresGrad {
+			out.requiresGrad = true
+			out.op = op
+			out.parents = parents
+			out.backward = backward
+			break
+		}
+	}
+	return out
+}
+
+// sumToShape reduces a broadcast gradient back to the shape of the input it was broadcast from, summing over the
+// leading axes that were added and the axes of length one that were stretched.
+func sumToShape(g *numpy.NDArray, shape []int) (*numpy.NDArray, error) {
+	gShape := g.Shape()
+	lead := len(gShape) - len(shape)
+	axes := []int{}
+	for i := range gShape {
+		if i < lead || (shape[i-lead] == 1 && gShape[i] != 1) {
+			axes = append(axes, i)
+		}
+	}
+	if len(axes) == 0 {
+		return g, nil
+	}
+	s, err := numpy.Sum(g, axes...)
+	if err != nil {
+		return nil, err
+	}
+	return s.Reshape(shape...)
+}
+
+// expandReduced broadcasts the gradient of a reduction over axes back to the input shape.
+func expandReduced(g *numpy.NDArray, shape []int, axes []int) (*numpy.NDArray, error) {
+	keep := append([]int{}, shape...)
+	if len(axes) == 0 {
+		for i := range keep {
+			keep[i] = 1
+		}
+	}
+	for _, ax := range axes {
+		if ax < 0 {
+			ax += len(shape)
+		}
+		keep[ax] = 1
+	}
+	r, err := g.Reshape(keep...)
+	if err != nil {
+		return nil, err
+	}
+	b, err := r.BroadcastTo(shape...)
+	if err != nil {
+		return nil, err
+	}
+	return b.Copy(), nil
+}
+
+// Add returns a + b with numpy broadcasting.
+//
+// Errors:
+//
+//	Returns an error if the shapes cannot be broadcast together.
+func Add(a, b *Tensor) (*Tensor, error) {
+	v, err := a.value.Add(b.value)
+	if err != nil {
+		return nil, err
+	}
+	return record("Add", v, []*Tensor{a, b}, func(g *numpy.NDArray) ([]*numpy.NDArray, error) {
+		ga, err := sumToShape(g, a.value.Shape())
+		if err != nil {
+			return nil, err
+		}
+		gb, err := sumToShape(g, b.value.Shape())
+		if err != nil {
+			return nil, err
+		}
+		return []*numpy.NDArray{ga, gb}, nil
+	}), nil
+}
+
+// Sub returns a - b with numpy broadcasting.
+//
+// Errors:
+//
+//	Returns an error if the shapes cannot be broadcast together.
+func Sub(a, b *Tensor) (*Tensor, error) {
+	v, err := a.value.Sub(b.value)
+	if err != nil {
+		return nil, err
+	}
+	return record("Sub", v, []*Tensor{a, b}, func(g *numpy.NDArray) ([]*numpy.NDArray, error) {
+		ga, err := sumToShape(g, a.value.Shape())
+		if err != nil {
+			return nil, err
+		}
+		gb, err := sumToShape(g.Scale(-1), b.value.Shape())
+		if err != nil {
+			return nil, err
+		}
+		return []*numpy.NDArray{ga, gb}, nil
+	}), nil
+}
+
+// Multiply returns the element-wise product a * b with numpy broadcasting.
+//
+// Errors:
+//
+//	Returns an error if the shapes cannot be broadcast together.
+func Multiply(a, b *Tensor) (*Tensor, error) {
+	v, err := a.value.Mul(b.value)
+	if err != nil {
+		return nil, err
+	}
+	return record("Multiply", v, []*Tensor{a, b}, func(g *numpy.NDArray) ([]*numpy.NDArray, error) {
+		ga, err := g.Mul(b.value)
+		if err != nil {
+			return nil, err
+		}
+		gb, err := g.Mul(a.value)
+		if err != nil {
+			return nil, err
+		}
+		if ga, err = sumToShape(ga, a.value.Shape()); err != nil {
+			return nil, err
+		}
+		if gb, err = sumToShape(gb, b.value.Shape()); err != nil {
+			return nil, err
+		}
+		return []*numpy.NDArray{ga, gb}, nil
+	}), nil
+}
+
+// Div returns the element-wise quotient a / b with numpy broadcasting.
+//
+// Errors:
+//
+//	Returns an error if the shapes cannot be broadcast together.
+func Div(a, b *Tensor) (*Tensor, error) {
+	v, err := a.value.Div(b.value)
+	if err != nil {
+		return nil, err
+	}
+	return record("Div", v, []*Tensor{a, b}, func(g *numpy.NDArray) ([]*numpy.NDArray, error) {
+		// d(a/b)/da = 1/b and d(a/b)/db = -a/b^2 = -out/b
+		ga, err := g.Div(b.value)
+		if err != nil {
+			return nil, err
+		}
+		gb, err := ga.Mul(v)
+		if err != nil {
+			return nil, err
+		}
+		if ga, err = sumToShape(ga, a.value.Shape()); err != nil {
+			return nil, err
+		}
+		if gb, err = sumToShape(gb.Scale(-1), b.value.Shape()); err != nil {
+			return nil, err
+		}
+		return []*numpy.NDArray{ga, gb}, nil
+	}), nil
+}
+
+// Neg returns -a.
+func Neg(a *Tensor) *Tensor {
+	return record("Neg", a.value.Scale(-1), []*Tensor{a}, func(g *numpy.NDArray) ([]*numpy.NDArray, error) {
+		return []*numpy.NDArray{g.Scale(-1)}, nil
+	})
+}
+
+// Exp returns the element-wise exponential of a.
+func Exp(a *Tensor) *Tensor {
+	v := numpy.Exp(a.value)
+	return record("Exp", v, []*Tensor{a}, func(g *numpy.NDArray) ([]*numpy.NDArray, error) {
+		ga, err := g.Mul(v)
+		return []*numpy.NDArray{ga}, err
+	})
+}
+
+// Log returns the element-wise natural logarithm of a.
+func Log(a *Tensor) *Tensor {
+	return record("Log", numpy.Log(a.value), []*Tensor{a}, func(g *numpy.NDArray) ([]*numpy.NDArray, error) {
+		ga, err := g.Div(a.value)
+		return []*numpy.NDArray{ga}, err
+	})
+}
+
+// Pow returns every element of a raised to the power p.
+func Pow(a *Tensor, p float64) *Tensor {
+	return record("Pow", numpy.Power(a.value, p), []*Tensor{a}, func(g *numpy.NDArray) ([]*numpy.NDArray, error) {
+		ga, err := g.Mul(numpy.Power(a.value, p-1).Scale(p))
+		return []*numpy.NDArray{ga}, err
+	})
+}
+
+// Matmul returns the matrix product of a and b with the semantics of numpy.Matmul, including batched (stacked)
+// matrices with broadcast batch dimensions and 1-D vectors.
+//
+// Errors:
+//
+//	Returns an error if the shapes are not compatible for matrix multiplication.
+func Matmul(a, b *Tensor) (*Tensor, error) {
+	v, err := numpy.Matmul(a.value, b.value)
+	if err != nil {
+		return nil, err
+	}
+	return record("Matmul", v, []*Tensor{a, b}, func(g *numpy.NDArray) ([]*numpy.NDArray, error) {
+		// Work with matrices, treating vectors as a single row (for a) or column (for b)
+		x, y := a.value, b.value
+		if x.Ndim() == 1 {
+			x, _ = x.Reshape(1, -1)
+		}
+		if y.Ndim() == 1 {
+			y, _ = y.Reshape(-1, 1)
+		}
+		out, err := numpy.Matmul(x, y)
+		if err != nil {
+			return nil, err
+		}
+		g, err = g.Reshape(out.Shape()...)
+		if err != nil {
+			return nil, err
+		}
+
+		// dA = G @ B^T and dB = A^T @ G, summed over any broadcast batch dimensions
+		yT, _ := y.SwapAxes(-1, -2)
+		xT, _ := x.SwapAxes(-1, -2)
+		ga, err := numpy.Matmul(g, yT)
+		if err != nil {
+			return nil, err
+		}
+		gb, err := numpy.Matmul(xT, g)
+		if err != nil {
+			return nil, err
+		}
+		if ga, err = sumToShape(ga, x.Shape()); err != nil {
+			return nil, err
+		}
+		if gb, err = sumToShape(gb, y.Shape()); err != nil {
+			return nil, err
+		}
+		if ga, err = ga.Reshape(a.value.Shape()...); err != nil {
+			return nil, err
+		}
+		if gb, err = gb.Reshape(b.value.Shape()...); err != nil {
+			return nil, err
+		}
+		return []*numpy.NDArray{ga, gb}, nil
+	}), nil
+}
+
+// Dot returns the dot product of two 1-D or 2-D tensors: the inner product of vectors, the matrix-vector product or
+// the matrix product, matching numpy.dot for these inputs.
+//
+// Errors:
+//
+//	Returns an error if either input has more than two dimensions or the shapes are not aligned.
+func Dot(a, b *Tensor) (*Tensor, error) {
+	if a.value.Ndim() > 2 || b.value.Ndim() > 2 {
+		return nil, fmt.Errorf("dot supports 1-D and 2-D tensors, use Matmul for stacks of matrices")
+	}
+	return Matmul(a, b)
+}
+
+// Sum returns the sum of the elements of a over the given axes, or over every element if no axes are given.
+//
+// Errors:
+//
+//	Returns an error if an axis is out of bounds or repeated.
+func Sum(a *Tensor, axis ...int) (*Tensor, error) {
+	v, err := numpy.Sum(a.value, axis...)
+	if err != nil {
+		return nil, err
+	}
+	return record("Sum", v, []*Tensor{a}, func(g *numpy.NDArray) ([]*numpy.NDArray, error) {
+		ga, err := expandReduced(g, a.value.Shape(), axis)
+		return []*numpy.NDArray{ga}, err
+	}), nil
+}
+
+// Mean returns the mean of the elements of a over the given axes, or over every element if no axes are given.
+//
+// Errors:
+//
+//	Returns an error if an axis is out of bounds or repeated.
+func Mean(a *Tensor, axis ...int) (*Tensor, error) {
+	v, err := numpy.Mean(a.value, axis...)
+	if err != nil {
+		return nil, err
+	}
+	count := float64(a.value.Size()) / float64(v.Size())
+	return record("Mean", v, []*Tensor{a}, func(g *numpy.NDArray) ([]*numpy.NDArray, error) {
+		ga, err := expandReduced(g, a.value.Shape(), axis)
+		if err != nil {
+			return nil, err
+		}
+		return []*numpy.NDArray{ga.Scale(1 / count)}, nil
+	}), nil
+}
+
+// Reshape returns a tensor with the data of a in a new shape. One dimension may be -1 to be inferred.
+//
+// Errors:
+//
+//	Returns an error if the new shape does not have the same number of elements.
+func Reshape(a *Tensor, shape ...int) (*Tensor, error) {
+	v, err := a.value.Reshape(shape...)
+	if err != nil {
+		return nil, err
+	}
+	return record("Reshape", v, []*Tensor{a}, func(g *numpy.NDArray) ([]*numpy.NDArray, error) {
+		ga, err := g.Reshape(a.value.Shape()...)
+		return []*numpy.NDArray{ga}, err
+	}), nil
+}
+
+// Transpose returns a tensor with the axes of a permuted. With no axes the order of the axes is reversed.
+//
+// Errors:
+//
+//	Returns an error if axes is not a permutation of the dimensions of a.
+func Transpose(a *Tensor, axes ...int) (*Tensor, error) {
+	v, err := a.value.Transpose(axes...)
+	if err != nil {
+		return nil, err
+	}
+	n := a.value.Ndim()
+	perm := append([]int{}, axes...)
+	if len(perm) == 0 {
+		perm = make([]int, n)
+		for i := range perm {
+			perm[i] = n - 1 - i
+		}
+	}
+	inverse := make([]int, n)
+	for i, ax := range perm {
+		if ax < 0 {
+			ax += n
+		}
+		inverse[ax] = i
+	}
+	return record("Transpose", v, []*Tensor{a}, func(g *numpy.NDArray) ([]*numpy.NDArray, error) {
+		ga, err := g.Transpose(inverse...)
+		if err != nil {
+			return nil, err
+		}
+		return []*numpy.NDArray{ga.Copy()}, nil
+	}), nil
+}
@@ -0,0 +1,187 @@
+package autograd
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/timotewb/gonn/numpy"
+)
+
+// Tensor wraps a numpy.NDArray and records the operations applied to it so that gradients can be computed with
+// reverse-mode automatic differentiation.
+//
+// Every operation in this package that receives a tensor requiring gradients returns a new tensor that remembers its
+// inputs and how to propagate a gradient back to them. Together these records form a tape that is built dynamically
+// as the computation runs; calling Backward on the final (scalar) tensor replays the tape in reverse.
+//
+// Example usage:
+//
+//	w := autograd.NewTensor(numpy.OnesArray(3, 2), true)
+//	x := autograd.NewTensor(xArray, false)
+//	y, _ := autograd.Matmul(x, w)
+//	loss, _ := autograd.Mean(y)
+//	loss.Backward()
+//	fmt.Println(w.Grad())
+type Tensor struct {
+	value        *numpy.NDArray
+	grad         *numpy.NDArray
+	requiresGrad bool
+
+	// The operation that produced the tensor; parents and backward are nil for leaf tensors
+	op       string
+	parents  []*Tensor
+	backward func(grad *numpy.NDArray) ([]*numpy.NDArray, error)
+}
+
+// NewTensor creates a leaf tensor holding value.
+//
+// Parameters:
+//
+//	value (*numpy.NDArray): The data of the tensor. It is not copied.
+//	requiresGrad (bool): Whether gradients should be computed for the tensor when Backward is called.
+func NewTensor(value *numpy.NDArray, requiresGrad bool) *Tensor {
+	return &Tensor{value: value, requiresGrad: requiresGrad}
+}
+
+// Value returns the data held by the tensor.
+func (t *Tensor) Value() *numpy.NDArray {
+	return t.value
+}
+
+// Grad returns the gradient accumulated by Backward, or nil if no gradient has been computed.
+func (t *Tensor) Grad() *numpy.NDArray {
+	return t.grad
+}
+
+// Shape returns the shape of the tensor's data.
+func (t *Tensor) Shape() []int {
+	return t.value.Shape()
+}
+
+// RequiresGrad reports whether gradients are computed for the tensor.
+func (t *Tensor) RequiresGrad() bool {
+	return t.requiresGrad
+}
+
+// Op returns the name of the operation that produced the tensor, or an empty string for a leaf tensor.
+func (t *Tensor) Op() string {
+	return t.op
+}
+
+// IsLeaf reports whether the tensor was created directly rather than as the result of a recorded operation.
+func (t *Tensor) IsLeaf() bool {
+	return t.backward == nil
+}
+
+// ZeroGrad clears the accumulated gradient. Gradients are summed across calls to Backward, so this is usually called
+// before each training step.
+func (t *Tensor) ZeroGrad() {
+	t.grad = nil
+}
+
+// Detach returns a new leaf tensor sharing the data of t but not recording any history.
+func (t *Tensor) Detach() *Tensor {
+	return NewTensor(t.value, false)
+}
+
+// String formats the tensor's data in numpy's layout.
+func (t *Tensor) String() string {
+	return fmt.Sprintf("tensor(%v)", t.value)
+}
+
+// Backward computes the gradient of t with respect to every tensor it depends on that requires gradients.
+//
+// t must hold a single element, such as a loss. The gradients are added to the Grad of every tensor on the tape that
+// requires gradients, so they accumulate across calls until ZeroGrad is called.
+//
+// Errors:
+//
+//	Returns an error if t has more than one element, does not require gradients, or an operation fails to propagate
+//	its gradient.
+func (t *Tensor) Backward() error {
+	if t.value.Size() != 1 {
+		return fmt.Errorf("grad can be implicitly created only for scalar outputs, got shape %v", t.value.Shape())
+	}
+	return t.BackwardWithGrad(numpy.OnesArray(t.value.Shape()...))
+}
+
+// BackwardWithGrad computes gradients like Backward, starting from the given gradient of some scalar with respect to
+// t instead of from one. This is used to backpropagate through tensors with more than one element.
+//
+// Errors:
+//
+//	Returns an error if grad does not have the shape of t, t does not require gradients, or an operation fails to
+//	propagate its gradient.
+func (t *Tensor) BackwardWithGrad(grad *numpy.NDArray) error {
+	if !t.requiresGrad {
+		return fmt.Errorf("tensor does not require grad and does not have a grad function")
+	}
+	if !slices.Equal(grad.Shape(), t.value.Shape()) {
+		return fmt.Errorf("grad has shape %v but the tensor has shape %v", grad.Shape(), t.value.Shape())
+	}
+
+	// Order the tape so that every tensor is processed after all the tensors that use it
+	order := []*Tensor{}
+	visited := map[*Tensor]bool{}
+	var visit func(n *Tensor)
+	visit = func(n *Tensor) {
+		if visited[n] || !n.requiresGrad {
+			return
+		}
+		visited[n] = true
+		for _, p := range n.parents {
+			visit(p)
+		}
+		order = append(order, n)
+	}
+	visit(t)
+
+	// Walk the tape in reverse, summing the gradient flowing into each tensor before propagating it further
+	grads := map[*Tensor]*numpy.NDArray{t: grad}
+	for i := len(order) - 1; i >= 0; i-- {
+		n := order[i]
+		g, ok := grads[n]
+		if !ok {
+			continue
+		}
+		if err := n.accumulate(g); err != nil {
+			return err
+		}
+		if n.backward == nil {
+			continue
+		}
+		parentGrads, err := n.backward(g)
+		if err != nil {
+			return fmt.Errorf("backward of %v failed: %v", n.op, err)
+		}
+		for j, p := range n.parents {
+			if !p.requiresGrad || parentGrads[j] == nil {
+				continue
+			}
+			if prev, ok := grads[p]; ok {
+				sum, err := prev.Add(parentGrads[j])
+				if err != nil {
+					return err
+				}
+				grads[p] = sum
+			} else {
+				grads[p] = parentGrads[j]
+			}
+		}
+	}
+	return nil
+}
+
+// accumulate adds g to the stored gradient of the tensor.
+func (t *Tensor) accumulate(g *numpy.NDArray) error {
+	if t.grad == nil {
+		t.grad = g.Copy()
+		return nil
+	}
+	sum, err := t.grad.Add(g)
+	if err != nil {
+		return err
+	}
+	t.grad = sum
+	return nil
+}
@@ -0,0 +1,337 @@
+package checkpoint
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"github.com/timotewb/gonn/nn"
+	"github.com/timotewb/gonn/numpy"
+	"github.com/timotewb/gonn/optim"
+	"github.com/timotewb/gonn/schedule"
+)
+
+// Version is the format version written by Save. Load reads files of this and every earlier version.
+const Version = 1
+
+// metaFile is the name of the JSON entry holding everything except the arrays.
+const metaFile = "checkpoint.json"
+
+// Checkpoint is the state of a training run: the network and, optionally, its optimizer, learning rate scheduler,
+// epoch counter and free-form metadata.
+//
+// A checkpoint file is a zip archive in the NumPy .npz layout, so its arrays can also be inspected with numpy.load.
+// It holds a JSON entry with the format version, the architecture of the network (see nn.GetConfig), the epoch, the
+// scalar optimizer and scheduler state and the metadata, and one .npy entry per array: "parameters/<i>" in the order
+// of Parameters, "buffers/<name>" for buffers such as running statistics, and "optimizer/<buffer>/<i>" for optimizer
+// buffers such as Adam's moment estimates.
+//
+// Example usage:
+//
+//	checkpoint.Save("run.ckpt", &checkpoint.Checkpoint{Network: net, Optimizer: opt, Epoch: epoch})
+//
+//	// Later, rebuild the network and resume training
+//	c := &checkpoint.Checkpoint{}
+//	checkpoint.Load("run.ckpt", c)
+//	opt, _ := optim.NewAdam(c.Network.Parameters(), 1e-3, 0.9, 0.999, 1e-8, 0)
+//	c.Optimizer = opt
+//	checkpoint.Load("run.ckpt", c)
+//
+// Fields:
+//
+//	Network (nn.Layer): The network. Its layer types must be registered with nn.RegisterLayer to be saved.
+//	Optimizer (optim.Optimizer): The optimizer of the network, or nil.
+//	Scheduler (*schedule.Scheduler): The learning rate scheduler, or nil.
+//	Epoch (int): The number of completed epochs.
+//	Metadata (map[string]string): Any other information about the run, such as the dataset or a git revision.
+type Checkpoint struct {
+	Network   nn.Layer
+	Optimizer optim.Optimizer
+	Scheduler *schedule.Scheduler
+	Epoch     int
+	Metadata  map[string]string
+}
+
+// meta is the content of the JSON entry of a checkpoint file.
+type meta struct {
+	Version      int               `json:"version"`
+	Architecture *nn.LayerConfig   `json:"architecture"`
+	Parameters   []string          `json:"parameters"`
+	Epoch        int               `json:"epoch"`
+	Optimizer    *optimizerMeta    `json:"optimizer,omitempty"`
+	Scheduler    *schedule.State   `json:"scheduler,omitempty"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+}
+
+// optimizerMeta is the scalar part of an optim.State. The buffers are stored as arrays, with Buffers recording
+// which parameters have one.
+type optimizerMeta struct {
+	Type         string            `json:"type"`
+	Steps        []int             `json:"steps"`
+	LRs          []float64         `json:"lrs"`
+	WeightDecays []float64         `json:"weight_decays"`
+	Buffers      map[string][]bool `json:"buffers"`
+}
+
+// migrations upgrade the metadata of a file of the version they are keyed by to the next version. When the format
+// changes, Version is incremented and a migration is added here so older checkpoints keep loading.
+var migrations = map[int]func(m *meta, arrays map[string]*numpy.NDArray) error{}
+
+// Save writes a checkpoint to path. The file is written to a temporary file first and renamed, so an interrupted
+// save does not corrupt an existing checkpoint.
+//
+// Errors:
+//
+//	Returns an error if the network is nil or contains a layer type that is not registered, or the file cannot be
+//	written.
+func Save(path string, c *Checkpoint) error {
+	if c.Network == nil {
+		return fmt.Errorf("checkpoint has no network")
+	}
+	arch, err := nn.GetConfig(c.Network)
+	if err != nil {
+		return err
+	}
+	m := meta{Version: Version, Architecture: arch, Epoch: c.Epoch, Metadata: c.Metadata}
+	arrays := map[string]*numpy.NDArray{}
+	for i, p := range c.Network.Parameters() {
+		m.Parameters = append(m.Parameters, p.Name)
+		arrays[fmt.Sprintf("parameters/%v", i)] = p.Value
+	}
+	if b, ok := c.Network.(nn.Buffered); ok {
+		for name, a := range b.Buffers() {
+			arrays["buffers/"+name] = a
+		}
+	}
+	if c.Optimizer != nil {
+		state := c.Optimizer.StateDict()
+		om := &optimizerMeta{
+			Type:         fmt.Sprintf("%T", c.Optimizer),
+			Steps:        state.Steps,
+			LRs:          state.LRs,
+			WeightDecays: state.WeightDecays,
+			Buffers:      map[string][]bool{},
+		}
+		for name, bufs := range state.Buffers {
+			present := make([]bool, len(bufs))
+			for i, buf := range bufs {
+				if buf != nil {
+					present[i] = true
+					arrays[fmt.Sprintf("optimizer/%v/%v", name, i)] = buf
+				}
+			}
+			om.Buffers[name] = present
+		}
+		m.Optimizer = om
+	}
+	if c.Scheduler != nil {
+		m.Scheduler = c.Scheduler.StateDict()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := write(tmp, &m, arrays); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	// CreateTemp makes the file private, so give it the mode of the file it replaces, or the usual 0644
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// write writes the metadata and arrays as a zip archive.
+func write(w io.Writer, m *meta, arrays map[string]*numpy.NDArray) error {
+	z := zip.NewWriter(w)
+	mw, err := z.Create(metaFile)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(mw)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(m); err != nil {
+		return err
+	}
+	names := make([]string, 0, len(arrays))
+	for name := range arrays {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := numpy.WriteNpzEntry(z, name, arrays[name], true); err != nil {
+			return err
+		}
+	}
+	return z.Close()
+}
+
+// read returns the metadata, upgraded to the current version, and the arrays of a checkpoint file.
+func read(path string) (*meta, map[string]*numpy.NDArray, error) {
+	z, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer z.Close()
+	m := &meta{}
+	found := false
+	for _, f := range z.File {
+		if f.Name != metaFile {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, nil, err
+		}
+		err = json.NewDecoder(rc).Decode(m)
+		rc.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading %v: %v", metaFile, err)
+		}
+		found = true
+	}
+	if !found {
+		return nil, nil, fmt.Errorf("%v is not a checkpoint file: %v is missing", path, metaFile)
+	}
+	if m.Version > Version || m.Version < 1 {
+		return nil, nil, fmt.Errorf("checkpoint format version %v is not supported by this version of the library, which supports up to %v", m.Version, Version)
+	}
+	arrays, err := numpy.ReadNpzEntries(&z.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	for v := m.Version; v < Version; v++ {
+		if err := migrations[v](m, arrays); err != nil {
+			return nil, nil, fmt.Errorf("upgrading checkpoint from version %v: %v", v, err)
+		}
+		m.Version = v + 1
+	}
+	return m, arrays, nil
+}
+
+// Load reads a checkpoint from path into c.
+//
+// If c.Network is nil a new network is built from the saved architecture; otherwise it must have the saved
+// architecture. The saved parameters and buffers are copied into the network. The optimizer and scheduler state is
+// loaded into c.Optimizer and c.Scheduler if they are set and the file holds their state; they are not created, as
+// their hyperparameters are not saved. c.Epoch and c.Metadata are replaced by the saved values.
+//
+// Errors:
+//
+//	Returns an error if the file cannot be read, was written by a newer version of the format, or does not match the
+//	network, optimizer or scheduler. The network is left unchanged if an error is returned, and the optimizer is
+//	left unchanged unless only the scheduler state fails to load.
+func Load(path string, c *Checkpoint) error {
+	m, arrays, err := read(path)
+	if err != nil {
+		return err
+	}
+	network := c.Network
+	if network == nil {
+		if m.Architecture == nil {
+			return fmt.Errorf("checkpoint has no architecture, so a network must be provided")
+		}
+		if network, err = nn.FromConfig(m.Architecture); err != nil {
+			return err
+		}
+	}
+
+	params := network.Parameters()
+	if len(params) != len(m.Parameters) {
+		return fmt.Errorf("checkpoint has %v parameters but the network has %v", len(m.Parameters), len(params))
+	}
+	for i, p := range params {
+		a, ok := arrays[fmt.Sprintf("parameters/%v", i)]
+		if !ok {
+			return fmt.Errorf("checkpoint is missing parameter %v", i)
+		}
+		if !slices.Equal(a.Shape(), p.Value.Shape()) {
+			return fmt.Errorf("parameter %v (%v) has shape %v in the checkpoint but %v in the network", i, p.Name, a.Shape(), p.Value.Shape())
+		}
+	}
+	buffers := map[string]*numpy.NDArray{}
+	if b, ok := network.(nn.Buffered); ok {
+		buffers = b.Buffers()
+	}
+	for name, buf := range buffers {
+		a, ok := arrays["buffers/"+name]
+		if !ok {
+			return fmt.Errorf("checkpoint is missing buffer %q", name)
+		}
+		if !slices.Equal(a.Shape(), buf.Shape()) {
+			return fmt.Errorf("buffer %q has shape %v in the checkpoint but %v in the network", name, a.Shape(), buf.Shape())
+		}
+	}
+
+	// Load the optimizer and scheduler state before the network, as LoadStateDict checks a state before applying it,
+	// so a mismatch returns before any parameter is overwritten
+	if c.Optimizer != nil && m.Optimizer != nil {
+		if t := fmt.Sprintf("%T", c.Optimizer); t != m.Optimizer.Type {
+			return fmt.Errorf("checkpoint holds the state of a %v optimizer, not %v", m.Optimizer.Type, t)
+		}
+		state := &optim.State{
+			Steps:        m.Optimizer.Steps,
+			LRs:          m.Optimizer.LRs,
+			WeightDecays: m.Optimizer.WeightDecays,
+			Buffers:      map[string][]*numpy.NDArray{},
+		}
+		for name, present := range m.Optimizer.Buffers {
+			bufs := make([]*numpy.NDArray, len(present))
+			for i, ok := range present {
+				if ok {
+					if bufs[i] = arrays[fmt.Sprintf("optimizer/%v/%v", name, i)]; bufs[i] == nil {
+						return fmt.Errorf("checkpoint is missing optimizer buffer %q for parameter %v", name, i)
+					}
+				}
+			}
+			state.Buffers[name] = bufs
+		}
+		if err := c.Optimizer.LoadStateDict(state); err != nil {
+			return err
+		}
+	}
+	if c.Scheduler != nil && m.Scheduler != nil {
+		if err := c.Scheduler.LoadStateDict(m.Scheduler); err != nil {
+			return err
+		}
+	}
+
+	for i, p := range params {
+		copy(p.Value.Data(), arrays[fmt.Sprintf("parameters/%v", i)].Data())
+	}
+	for name, buf := range buffers {
+		copy(buf.Data(), arrays["buffers/"+name].Data())
+	}
+	c.Network = network
+	c.Epoch, c.Metadata = m.Epoch, m.Metadata
+	return nil
+}
+
+// LoadNetwork rebuilds the network saved in a checkpoint with its trained parameters and buffers, for inference.
+//
+// Errors:
+//
+//	Returns an error if the file cannot be read or its architecture cannot be rebuilt.
+func LoadNetwork(path string) (nn.Layer, error) {
+	c := &Checkpoint{}
+	if err := Load(path, c); err != nil {
+		return nil, err
+	}
+	return c.Network, nil
+}
@@ -0,0 +1,152 @@
+package data
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/timotewb/gonn/numpy"
+	"github.com/timotewb/gonn/numpy/random"
+)
+
+// BatchTransform modifies a batch of inputs and targets before a training step, such as Mixup and CutMix. It has
+// the type of model.Model.BatchTransform and can also be applied to the batches of a DataLoader.
+type BatchTransform func(x, y *numpy.NDArray) (*numpy.NDArray, *numpy.NDArray, error)
+
+// rows returns the contiguous data of a batch with the samples along the first axis and the size of a sample.
+func rows(a *numpy.NDArray) ([]float64, int, error) {
+	if a.Ndim() == 0 || a.Shape()[0] == 0 {
+		return nil, 0, fmt.Errorf("expected a batch with the samples along the first axis, got shape %v", a.Shape())
+	}
+	return a.Data(), a.Size() / a.Shape()[0], nil
+}
+
+// mixRows returns lam * a[i] + (1 - lam) * a[perm[i]] for every sample i.
+func mixRows(a *numpy.NDArray, perm []int, lam float64) (*numpy.NDArray, error) {
+	data, size, err := rows(a)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]float64, len(data))
+	for i, j := range perm {
+		for k := 0; k < size; k++ {
+			out[i*size+k] = lam*data[i*size+k] + (1-lam)*data[j*size+k]
+		}
+	}
+	return numpy.NewArray(out, a.Shape()...)
+}
+
+// checkBatch checks that x and y hold the same number of samples.
+func checkBatch(x, y *numpy.NDArray) (int, error) {
+	if x.Ndim() == 0 || y.Ndim() == 0 || x.Shape()[0] != y.Shape()[0] {
+		return 0, fmt.Errorf("inputs of shape %v and targets of shape %v must have the same number of samples", x.Shape(), y.Shape())
+	}
+	return x.Shape()[0], nil
+}
+
+// Mixup returns the mixup transform of Zhang et al. (2018), which trains on convex combinations of pairs of samples
+// and of their targets. Each batch draws lam from Beta(alpha, alpha) and a random pairing, and returns
+// lam * x + (1 - lam) * x[perm] with targets mixed the same way.
+//
+// The targets must be one-hot or probabilities, as used by CategoricalCrossEntropy, since mixing integer labels is
+// meaningless.
+//
+// Parameters:
+//
+//	alpha (float64): The parameter of the Beta distribution. Values around 0.2 are common; larger values mix more.
+//	g (*random.Generator): The source of randomness, or nil for the global one.
+//
+// Errors:
+//
+//	Returns an error if alpha is not positive. The transform returns an error if x and y differ in number of
+//	samples.
+//
+// Example usage:
+//
+//	m.BatchTransform, _ = data.Mixup(0.2, random.NewGenerator(0))
+func Mixup(alpha float64, g *random.Generator) (BatchTransform, error) {
+	if alpha <= 0 {
+		return nil, fmt.Errorf("alpha must be positive, got %v", alpha)
+	}
+	return func(x, y *numpy.NDArray) (*numpy.NDArray, *numpy.NDArray, error) {
+		n, err := checkBatch(x, y)
+		if err != nil {
+			return nil, nil, err
+		}
+		lam := g.Beta(alpha, alpha)
+		perm := g.Permutation(n)
+		mx, err := mixRows(x, perm, lam)
+		if err != nil {
+			return nil, nil, err
+		}
+		my, err := mixRows(y, perm, lam)
+		if err != nil {
+			return nil, nil, err
+		}
+		return mx, my, nil
+	}, nil
+}
+
+// CutMix returns the CutMix transform of Yun et al. (2019), which pastes a random rectangle of another sample into
+// each image and mixes the targets in proportion to the pasted area. Each batch draws lam from Beta(alpha, alpha)
+// and a random pairing, and cuts a box of height H * sqrt(1 - lam) and width W * sqrt(1 - lam) centred at a random
+// position, clipped to the image. The targets are mixed with the area actually kept.
+//
+// Inputs are images with the spatial axes last, such as (batch, channels, height, width); the box is applied to
+// every channel. The targets must be one-hot or probabilities, as for Mixup.
+//
+// Errors:
+//
+//	Returns an error if alpha is not positive. The transform returns an error if x has fewer than 3 dimensions,
+//	its height or width is 0, or x and y differ in number of samples.
+func CutMix(alpha float64, g *random.Generator) (BatchTransform, error) {
+	if alpha <= 0 {
+		return nil, fmt.Errorf("alpha must be positive, got %v", alpha)
+	}
+	return func(x, y *numpy.NDArray) (*numpy.NDArray, *numpy.NDArray, error) {
+		n, err := checkBatch(x, y)
+		if err != nil {
+			return nil, nil, err
+		}
+		shape := x.Shape()
+		if len(shape) < 3 {
+			return nil, nil, fmt.Errorf("expected images of shape (batch, ..., height, width), got %v", shape)
+		}
+		h, w := shape[len(shape)-2], shape[len(shape)-1]
+		if h == 0 || w == 0 {
+			return nil, nil, fmt.Errorf("cannot cut a box from empty images of shape %v", shape)
+		}
+		lam := g.Beta(alpha, alpha)
+		perm := g.Permutation(n)
+
+		r := math.Sqrt(1 - lam)
+		cutH, cutW := int(float64(h)*r), int(float64(w)*r)
+		cy, cx := g.Intn(h), g.Intn(w)
+		y0, y1 := max(cy-cutH/2, 0), min(cy+cutH/2, h)
+		x0, x1 := max(cx-cutW/2, 0), min(cx+cutW/2, w)
+
+		data, size, err := rows(x)
+		if err != nil {
+			return nil, nil, err
+		}
+		out := append([]float64{}, data...)
+		planes := size / (h * w)
+		for i, j := range perm {
+			for p := 0; p < planes; p++ {
+				dst, src := (i*planes+p)*h*w, (j*planes+p)*h*w
+				for row := y0; row < y1; row++ {
+					copy(out[dst+row*w+x0:dst+row*w+x1], data[src+row*w+x0:src+row*w+x1])
+				}
+			}
+		}
+		mx, err := numpy.NewArray(out, shape...)
+		if err != nil {
+			return nil, nil, err
+		}
+		lam = 1 - float64((y1-y0)*(x1-x0))/float64(h*w)
+		my, err := mixRows(y, perm, lam)
+		if err != nil {
+			return nil, nil, err
+		}
+		return mx, my, nil
+	}, nil
+}
@@ -0,0 +1,178 @@
+package data
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/timotewb/gonn/numpy"
+	"github.com/timotewb/gonn/numpy/random"
+)
+
+// Dataset is an indexed collection of samples, like torch.utils.data.Dataset.
+//
+// Get returns sample i, for 0 <= i < Len(), as a list of arrays such as an input and its target. Every sample of a
+// dataset has the same number of arrays. Get may be called from several goroutines at once by a DataLoader with
+// workers, so it must not modify shared state without synchronization.
+type Dataset interface {
+	Len() int
+	Get(i int) ([]*numpy.NDArray, error)
+}
+
+// checkIndex returns an error if i is not a valid index of a dataset of length n.
+func checkIndex(i, n int) error {
+	if i < 0 || i >= n {
+		return fmt.Errorf("index %v is out of range for a dataset of length %v", i, n)
+	}
+	return nil
+}
+
+// TensorDataset is a dataset whose samples are the slices along the first axis of arrays with the same number of
+// rows, like torch.utils.data.TensorDataset.
+//
+// Example usage:
+//
+//	ds, _ := data.NewTensorDataset(x, y) // x of shape (1000, 28, 28), y of shape (1000,)
+//	sample, _ := ds.Get(3)               // sample[0] has shape (28, 28), sample[1] has shape ()
+type TensorDataset struct {
+	Arrays []*numpy.NDArray
+}
+
+// NewTensorDataset creates a dataset over arrays.
+//
+// Errors:
+//
+//	Returns an error if no array is given, an array is 0-d, or the arrays have different lengths.
+func NewTensorDataset(arrays ...*numpy.NDArray) (*TensorDataset, error) {
+	if len(arrays) == 0 {
+		return nil, fmt.Errorf("at least one array is required")
+	}
+	for _, a := range arrays {
+		if a.Ndim() == 0 || a.Shape()[0] != arrays[0].Shape()[0] {
+			return nil, fmt.Errorf("arrays must have the same length along the first axis")
+		}
+	}
+	return &TensorDataset{Arrays: arrays}, nil
+}
+
+// Len returns the number of rows of the arrays.
+func (d *TensorDataset) Len() int {
+	return d.Arrays[0].Shape()[0]
+}
+
+// Get returns row i of every array as views, without copying.
+func (d *TensorDataset) Get(i int) ([]*numpy.NDArray, error) {
+	if err := checkIndex(i, d.Len()); err != nil {
+		return nil, err
+	}
+	sample := make([]*numpy.NDArray, len(d.Arrays))
+	for j, a := range d.Arrays {
+		v, err := a.View(i)
+		if err != nil {
+			return nil, err
+		}
+		sample[j] = v
+	}
+	return sample, nil
+}
+
+// Subset is the samples of a dataset at the given indices, like torch.utils.data.Subset.
+type Subset struct {
+	Dataset Dataset
+	Indices []int
+}
+
+// NewSubset creates a subset of a dataset.
+//
+// Errors:
+//
+//	Returns an error if an index is out of range.
+func NewSubset(dataset Dataset, indices []int) (*Subset, error) {
+	for _, i := range indices {
+		if err := checkIndex(i, dataset.Len()); err != nil {
+			return nil, err
+		}
+	}
+	return &Subset{Dataset: dataset, Indices: indices}, nil
+}
+
+// Len returns the number of indices.
+func (s *Subset) Len() int {
+	return len(s.Indices)
+}
+
+// Get returns the sample of the underlying dataset at Indices[i].
+func (s *Subset) Get(i int) ([]*numpy.NDArray, error) {
+	if err := checkIndex(i, len(s.Indices)); err != nil {
+		return nil, err
+	}
+	return s.Dataset.Get(s.Indices[i])
+}
+
+// ConcatDataset is the concatenation of several datasets, like torch.utils.data.ConcatDataset.
+type ConcatDataset struct {
+	Datasets []Dataset
+
+	offsets []int
+}
+
+// NewConcatDataset creates the concatenation of datasets.
+//
+// Errors:
+//
+//	Returns an error if no dataset is given.
+func NewConcatDataset(datasets ...Dataset) (*ConcatDataset, error) {
+	if len(datasets) == 0 {
+		return nil, fmt.Errorf("at least one dataset is required")
+	}
+	c := &ConcatDataset{Datasets: datasets}
+	total := 0
+	for _, d := range datasets {
+		total += d.Len()
+		c.offsets = append(c.offsets, total)
+	}
+	return c, nil
+}
+
+// Len returns the total length of the datasets.
+func (c *ConcatDataset) Len() int {
+	return c.offsets[len(c.offsets)-1]
+}
+
+// Get returns sample i, counting through the datasets in order.
+func (c *ConcatDataset) Get(i int) ([]*numpy.NDArray, error) {
+	if err := checkIndex(i, c.Len()); err != nil {
+		return nil, err
+	}
+	d := sort.SearchInts(c.offsets, i+1)
+	if d > 0 {
+		i -= c.offsets[d-1]
+	}
+	return c.Datasets[d].Get(i)
+}
+
+// RandomSplit splits a dataset into non-overlapping random subsets of the given lengths, like
+// torch.utils.data.random_split, for example into training and validation sets.
+//
+// Errors:
+//
+//	Returns an error if a length is negative or the lengths do not add up to the length of the dataset.
+func RandomSplit(dataset Dataset, lengths []int, g *random.Generator) ([]*Subset, error) {
+	total := 0
+	for _, n := range lengths {
+		if n < 0 {
+			return nil, fmt.Errorf("lengths must be non-negative, got %v", lengths)
+		}
+		total += n
+	}
+	if total != dataset.Len() {
+		return nil, fmt.Errorf("sum of lengths %v does not equal the length of the dataset (%v)", lengths, dataset.Len())
+	}
+	perm := g.Permutation(total)
+	subsets := make([]*Subset, len(lengths))
+	start := 0
+	for i, n := range lengths {
+		subsets[i] = &Subset{Dataset: dataset, Indices: perm[start : start+n]}
+		start += n
+	}
+	return subsets, nil
+}
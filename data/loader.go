@@ -0,0 +1,259 @@
+package data
+
+import (
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/timotewb/gonn/numpy"
+	"github.com/timotewb/gonn/numpy/random"
+)
+
+// CollateFunc merges the samples of a batch into the arrays given to the model.
+type CollateFunc func(samples [][]*numpy.NDArray) ([]*numpy.NDArray, error)
+
+// DefaultCollate stacks each array of the samples along a new first axis, so samples made of an input of shape
+// (28, 28) and a scalar label give a batch of an input of shape (batch, 28, 28) and labels of shape (batch,).
+//
+// Errors:
+//
+//	Returns an error if the samples have different numbers of arrays or the arrays at the same position have
+//	different shapes.
+func DefaultCollate(samples [][]*numpy.NDArray) ([]*numpy.NDArray, error) {
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("cannot collate an empty batch")
+	}
+	batch := make([]*numpy.NDArray, len(samples[0]))
+	for j := range batch {
+		shape := samples[0][j].Shape()
+		size := samples[0][j].Size()
+		out := make([]float64, len(samples)*size)
+		for i, s := range samples {
+			if len(s) != len(batch) {
+				return nil, fmt.Errorf("sample %v has %v arrays, expected %v", i, len(s), len(batch))
+			}
+			if !slices.Equal(s[j].Shape(), shape) {
+				return nil, fmt.Errorf("array %v of sample %v has shape %v, expected %v", j, i, s[j].Shape(), shape)
+			}
+			copy(out[i*size:(i+1)*size], s[j].Data())
+		}
+		a, err := numpy.NewArray(out, append([]int{len(samples)}, shape...)...)
+		if err != nil {
+			return nil, err
+		}
+		batch[j] = a
+	}
+	return batch, nil
+}
+
+// DataLoader iterates over a dataset in batches, like torch.utils.data.DataLoader.
+//
+// With NumWorkers greater than zero, batches are loaded and collated by a pool of goroutines while the previous
+// batches are being used, keeping up to Prefetch batches ready. Batches are always returned in order.
+//
+// Example usage:
+//
+//	loader, _ := data.NewDataLoader(ds, 64, true, false, 4)
+//	for epoch := 0; epoch < 10; epoch++ {
+//	    it := loader.Iter()
+//	    for {
+//	        batch, err := it.Next()
+//	        if err == io.EOF {
+//	            break
+//	        }
+//	        ... train on batch[0] and batch[1] ...
+//	    }
+//	}
+//
+// Fields:
+//
+//	Dataset (Dataset): The samples to iterate over.
+//	BatchSize (int): The number of samples per batch.
+//	Shuffle (bool): Whether the samples are visited in a new random order by each iterator.
+//	DropLast (bool): Whether a last batch smaller than BatchSize is dropped.
+//	Collate (CollateFunc): Merges the samples of a batch. Defaults to DefaultCollate.
+//	NumWorkers (int): The number of goroutines loading batches, or 0 to load them in Next.
+//	Prefetch (int): The number of batches loaded ahead when NumWorkers is positive. Defaults to 2 * NumWorkers.
+//	Rand (*random.Generator): The source of randomness for shuffling, or nil for the package-level source.
+type DataLoader struct {
+	Dataset    Dataset
+	BatchSize  int
+	Shuffle    bool
+	DropLast   bool
+	Collate    CollateFunc
+	NumWorkers int
+	Prefetch   int
+	Rand       *random.Generator
+}
+
+// NewDataLoader creates a data loader with the default collate function and prefetch depth.
+//
+// Errors:
+//
+//	Returns an error if batchSize is not positive or numWorkers is negative.
+func NewDataLoader(dataset Dataset, batchSize int, shuffle, dropLast bool, numWorkers int) (*DataLoader, error) {
+	if batchSize <= 0 {
+		return nil, fmt.Errorf("batchSize must be positive, got %v", batchSize)
+	}
+	if numWorkers < 0 {
+		return nil, fmt.Errorf("numWorkers must be non-negative, got %v", numWorkers)
+	}
+	return &DataLoader{
+		Dataset:    dataset,
+		BatchSize:  batchSize,
+		Shuffle:    shuffle,
+		DropLast:   dropLast,
+		Collate:    DefaultCollate,
+		NumWorkers: numWorkers,
+		Prefetch:   2 * numWorkers,
+	}, nil
+}
+
+// Len returns the number of batches of an iteration.
+func (l *DataLoader) Len() int {
+	n := l.Dataset.Len()
+	if l.DropLast {
+		return n / l.BatchSize
+	}
+	return (n + l.BatchSize - 1) / l.BatchSize
+}
+
+// batch is a batch loaded by a worker.
+type batch struct {
+	index  int
+	arrays []*numpy.NDArray
+	err    error
+}
+
+// Iterator returns the batches of one pass over a dataset. It is created by DataLoader.Iter.
+type Iterator struct {
+	loader  *DataLoader
+	batches [][]int
+	next    int
+
+	results chan batch
+	pending map[int]batch
+	tokens  chan struct{}
+	done    chan struct{}
+	closed  bool
+}
+
+// Iter starts a pass over the dataset, shuffling the samples if Shuffle is set and starting the workers if
+// NumWorkers is positive. Each call returns an independent iterator.
+func (l *DataLoader) Iter() *Iterator {
+	order := make([]int, l.Dataset.Len())
+	for i := range order {
+		order[i] = i
+	}
+	if l.Shuffle {
+		l.Rand.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+	}
+	it := &Iterator{loader: l}
+	for b := 0; b < l.Len(); b++ {
+		it.batches = append(it.batches, order[b*l.BatchSize:min((b+1)*l.BatchSize, len(order))])
+	}
+	if l.NumWorkers > 0 {
+		it.start()
+	}
+	return it
+}
+
+// load reads and collates the samples at the given indices.
+func (l *DataLoader) load(indices []int) ([]*numpy.NDArray, error) {
+	samples := make([][]*numpy.NDArray, len(indices))
+	for i, idx := range indices {
+		s, err := l.Dataset.Get(idx)
+		if err != nil {
+			return nil, err
+		}
+		samples[i] = s
+	}
+	collate := l.Collate
+	if collate == nil {
+		collate = DefaultCollate
+	}
+	return collate(samples)
+}
+
+// start launches a producer handing out batch indices and the workers loading them. The producer takes a token for
+// every batch it hands out and Next returns one for every batch it delivers, which bounds the batches in flight.
+func (it *Iterator) start() {
+	l := it.loader
+	prefetch := l.Prefetch
+	if prefetch < 1 {
+		prefetch = 2 * l.NumWorkers
+	}
+	it.results = make(chan batch, prefetch)
+	it.pending = map[int]batch{}
+	it.tokens = make(chan struct{}, prefetch)
+	it.done = make(chan struct{})
+
+	jobs := make(chan int)
+	go func() {
+		defer close(jobs)
+		for b := range it.batches {
+			select {
+			case it.tokens <- struct{}{}:
+			case <-it.done:
+				return
+			}
+			select {
+			case jobs <- b:
+			case <-it.done:
+				return
+			}
+		}
+	}()
+	for w := 0; w < l.NumWorkers; w++ {
+		go func() {
+			for b := range jobs {
+				arrays, err := l.load(it.batches[b])
+				select {
+				case it.results <- batch{index: b, arrays: arrays, err: err}:
+				case <-it.done:
+					return
+				}
+			}
+		}()
+	}
+}
+
+// Next returns the next batch, or nil and io.EOF after the last batch.
+//
+// Errors:
+//
+//	Returns io.EOF at the end of the pass, or the error of the dataset or collate function for the batch.
+func (it *Iterator) Next() ([]*numpy.NDArray, error) {
+	if it.next >= len(it.batches) || it.closed {
+		it.Close()
+		return nil, io.EOF
+	}
+	b := it.next
+	it.next++
+	if it.done == nil {
+		return it.loader.load(it.batches[b])
+	}
+	for {
+		if r, ok := it.pending[b]; ok {
+			delete(it.pending, b)
+			<-it.tokens
+			if r.err != nil {
+				return nil, fmt.Errorf("batch %v: %v", b, r.err)
+			}
+			return r.arrays, nil
+		}
+		r := <-it.results
+		it.pending[r.index] = r
+	}
+}
+
+// Close stops the workers of an iterator that is not read to the end. It is safe to call more than once.
+func (it *Iterator) Close() {
+	if it.closed {
+		return
+	}
+	it.closed = true
+	if it.done != nil {
+		close(it.done)
+	}
+}
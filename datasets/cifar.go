@@ -0,0 +1,187 @@
+package datasets
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/timotewb/gonn/numpy"
+)
+
+// CIFAR images are 32x32 pixels with 3 channels, stored channel by channel.
+const (
+	cifarSide   = 32
+	cifarPixels = 3 * cifarSide * cifarSide
+)
+
+// ReadCIFAR reads a CIFAR-10 or CIFAR-100 binary batch. Each record of a CIFAR-10 batch is a label byte followed by
+// 3072 pixel bytes; a CIFAR-100 record has a coarse and a fine label byte before the pixels.
+//
+// Parameters:
+//
+//	r (io.Reader): The batch data.
+//	cifar100 (bool): Whether the records hold the two labels of CIFAR-100.
+//	normalize (bool): Whether to scale the pixels from [0, 255] to [0, 1].
+//
+// Returns:
+//
+//	(*numpy.NDArray, *numpy.NDArray, error): The images, of shape (n, 3, 32, 32) as expected by Conv2D, and the
+//	labels, of shape (n,) for CIFAR-10 and (n, 2) holding the coarse and fine labels for CIFAR-100.
+//
+// Errors:
+//
+//	Returns an error if reading fails or the data ends inside a record.
+func ReadCIFAR(r io.Reader, cifar100, normalize bool) (*numpy.NDArray, *numpy.NDArray, error) {
+	labelBytes := 1
+	if cifar100 {
+		labelBytes = 2
+	}
+	br := bufio.NewReader(r)
+	record := make([]byte, labelBytes+cifarPixels)
+	var pixels, labels []uint8
+	for {
+		n, err := io.ReadFull(br, record)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading CIFAR record %v: %v (%v of %v bytes)", len(labels)/labelBytes, err, n, len(record))
+		}
+		labels = append(labels, record[:labelBytes]...)
+		pixels = append(pixels, record[labelBytes:]...)
+	}
+	n := len(labels) / labelBytes
+	if n == 0 {
+		return nil, nil, fmt.Errorf("CIFAR batch holds no records")
+	}
+	images, err := toFloat(pixels, []int{n, 3, cifarSide, cifarSide}, normalize)
+	if err != nil {
+		return nil, nil, err
+	}
+	labelShape := []int{n}
+	if cifar100 {
+		labelShape = []int{n, 2}
+	}
+	l, err := toFloat(labels, labelShape, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	return images, l, nil
+}
+
+// LoadCIFAR10 reads the CIFAR-10 binary batches of a split from dir, as extracted from cifar-10-binary.tar.gz.
+// Nothing is downloaded.
+//
+// Parameters:
+//
+//	dir (string): The directory holding data_batch_1.bin to data_batch_5.bin and test_batch.bin, each optionally
+//	              gzipped with a .gz suffix.
+//	train (bool): Whether to read the five training batches rather than the test batch.
+//	normalize (bool): Whether to scale the pixels from [0, 255] to [0, 1].
+//
+// Returns:
+//
+//	(*numpy.NDArray, *numpy.NDArray, error): The images, of shape (n, 3, 32, 32), and the labels, of shape (n,).
+//
+// Errors:
+//
+//	Returns an error if a batch is missing or malformed.
+func LoadCIFAR10(dir string, train, normalize bool) (*numpy.NDArray, *numpy.NDArray, error) {
+	files := []string{"test_batch.bin"}
+	if train {
+		files = []string{"data_batch_1.bin", "data_batch_2.bin", "data_batch_3.bin", "data_batch_4.bin", "data_batch_5.bin"}
+	}
+	return loadCIFAR(dir, files, false, normalize)
+}
+
+// LoadCIFAR100 reads the CIFAR-100 binary batch of a split from dir, as extracted from cifar-100-binary.tar.gz.
+// Nothing is downloaded.
+//
+// Parameters:
+//
+//	dir (string): The directory holding train.bin and test.bin, each optionally gzipped with a .gz suffix.
+//	train (bool): Whether to read the training batch rather than the test batch.
+//	normalize (bool): Whether to scale the pixels from [0, 255] to [0, 1].
+//
+// Returns:
+//
+//	(*numpy.NDArray, *numpy.NDArray, error): The images, of shape (n, 3, 32, 32), and the labels, of shape (n, 2)
+//	holding the coarse (superclass) and fine labels.
+//
+// Errors:
+//
+//	Returns an error if the batch is missing or malformed.
+func LoadCIFAR100(dir string, train, normalize bool) (*numpy.NDArray, *numpy.NDArray, error) {
+	file := "test.bin"
+	if train {
+		file = "train.bin"
+	}
+	return loadCIFAR(dir, []string{file}, true, normalize)
+}
+
+// loadCIFAR reads and concatenates the given batches of dir, which may be gzipped.
+func loadCIFAR(dir string, files []string, cifar100, normalize bool) (*numpy.NDArray, *numpy.NDArray, error) {
+	var images, labels []*numpy.NDArray
+	for _, name := range files {
+		path, err := findFile(dir, name)
+		if err != nil {
+			return nil, nil, err
+		}
+		r, closeFn, err := open(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		x, y, err := ReadCIFAR(r, cifar100, normalize)
+		closeFn()
+		if err != nil {
+			return nil, nil, fmt.Errorf("%v: %v", path, err)
+		}
+		images, labels = append(images, x), append(labels, y)
+	}
+	if len(files) == 1 {
+		return images[0], labels[0], nil
+	}
+	x, err := numpy.Concatenate(images, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	y, err := numpy.Concatenate(labels, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	return x, y, nil
+}
+
+// WriteCIFAR writes images and labels as a CIFAR binary batch, rounding and clipping the values to [0, 255]. It is
+// the inverse of ReadCIFAR with normalize unset and is used to build small fixtures. An empty batch writes nothing.
+//
+// Parameters:
+//
+//	w (io.Writer): The destination.
+//	images (*numpy.NDArray): The images, of shape (n, 3, 32, 32).
+//	labels (*numpy.NDArray): The labels, of shape (n,) for CIFAR-10 or (n, 2) for CIFAR-100.
+//
+// Errors:
+//
+//	Returns an error if the shapes are invalid or writing fails.
+func WriteCIFAR(w io.Writer, images, labels *numpy.NDArray) error {
+	is, ls := images.Shape(), labels.Shape()
+	if len(is) != 4 || is[1] != 3 || is[2] != cifarSide || is[3] != cifarSide {
+		return fmt.Errorf("expected images of shape (n, 3, 32, 32), got %v", is)
+	}
+	if len(ls) < 1 || len(ls) > 2 || ls[0] != is[0] || (len(ls) == 2 && ls[1] != 2) {
+		return fmt.Errorf("expected %v labels of shape (n,) or (n, 2), got %v", is[0], ls)
+	}
+	labelBytes := len(ls)
+	pd, ld := images.Data(), labels.Data()
+	bw := bufio.NewWriter(w)
+	for i := 0; i < is[0]; i++ {
+		for _, v := range ld[i*labelBytes : (i+1)*labelBytes] {
+			bw.WriteByte(toByte(v))
+		}
+		for _, v := range pd[i*cifarPixels : (i+1)*cifarPixels] {
+			bw.WriteByte(toByte(v))
+		}
+	}
+	return bw.Flush()
+}
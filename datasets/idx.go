@@ -0,0 +1,177 @@
+package datasets
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/timotewb/gonn/numpy"
+)
+
+// IDX element types, the third byte of the magic number.
+const (
+	idxUint8   = 0x08
+	idxInt8    = 0x09
+	idxInt16   = 0x0B
+	idxInt32   = 0x0C
+	idxFloat32 = 0x0D
+	idxFloat64 = 0x0E
+)
+
+// readIDXHeader reads the magic number and dimensions of an IDX file.
+func readIDXHeader(r io.Reader) (byte, []int, error) {
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return 0, nil, err
+	}
+	if magic[0] != 0 || magic[1] != 0 {
+		return 0, nil, fmt.Errorf("not an IDX file")
+	}
+	shape := make([]int, magic[3])
+	for i := range shape {
+		var n uint32
+		if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+			return 0, nil, err
+		}
+		shape[i] = int(n)
+	}
+	return magic[2], shape, nil
+}
+
+// ReadIDXUint8 reads an IDX file of unsigned bytes, such as the images and labels of MNIST, without converting the
+// values, which keeps a dataset in an eighth of the memory of a float64 array.
+//
+// Returns:
+//
+//	([]uint8, []int, error): The values in row-major order and the shape.
+//
+// Errors:
+//
+//	Returns an error if the data is not an IDX file of unsigned bytes or is truncated.
+func ReadIDXUint8(r io.Reader) ([]uint8, []int, error) {
+	kind, shape, err := readIDXHeader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	if kind != idxUint8 {
+		return nil, nil, fmt.Errorf("expected an IDX file of unsigned bytes, got type 0x%02x", kind)
+	}
+	size := 1
+	for _, n := range shape {
+		size *= n
+	}
+	values := make([]uint8, size)
+	if _, err := io.ReadFull(r, values); err != nil {
+		return nil, nil, fmt.Errorf("reading IDX data: %v", err)
+	}
+	return values, shape, nil
+}
+
+// ReadIDX reads an IDX file of any element type, converting the values to float64.
+//
+// Errors:
+//
+//	Returns an error if the data is not an IDX file or is truncated.
+func ReadIDX(r io.Reader) (*numpy.NDArray, error) {
+	kind, shape, err := readIDXHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	size := 1
+	for _, n := range shape {
+		size *= n
+	}
+	var itemSize int
+	var decode func(b []byte) float64
+	switch kind {
+	case idxUint8:
+		itemSize, decode = 1, func(b []byte) float64 { return float64(b[0]) }
+	case idxInt8:
+		itemSize, decode = 1, func(b []byte) float64 { return float64(int8(b[0])) }
+	case idxInt16:
+		itemSize, decode = 2, func(b []byte) float64 { return float64(int16(binary.BigEndian.Uint16(b))) }
+	case idxInt32:
+		itemSize, decode = 4, func(b []byte) float64 { return float64(int32(binary.BigEndian.Uint32(b))) }
+	case idxFloat32:
+		itemSize, decode = 4, func(b []byte) float64 { return float64(math.Float32frombits(binary.BigEndian.Uint32(b))) }
+	case idxFloat64:
+		itemSize, decode = 8, func(b []byte) float64 { return math.Float64frombits(binary.BigEndian.Uint64(b)) }
+	default:
+		return nil, fmt.Errorf("unknown IDX type 0x%02x", kind)
+	}
+	raw := make([]byte, size*itemSize)
+	if _, err := io.ReadFull(r, raw); err != nil {
+		return nil, fmt.Errorf("reading IDX data: %v", err)
+	}
+	values := make([]float64, size)
+	for i := range values {
+		values[i] = decode(raw[i*itemSize : (i+1)*itemSize])
+	}
+	return numpy.NewArray(values, shape...)
+}
+
+// WriteIDX writes an array as an IDX file of unsigned bytes, rounding and clipping its values to [0, 255]. It is the
+// inverse of ReadIDXUint8 and is used to build small fixtures.
+//
+// Errors:
+//
+//	Returns an error if the array has more than 255 dimensions or writing fails.
+func WriteIDX(w io.Writer, a *numpy.NDArray) error {
+	if a.Ndim() > 255 {
+		return fmt.Errorf("IDX files have at most 255 dimensions, got %v", a.Ndim())
+	}
+	bw := bufio.NewWriter(w)
+	bw.Write([]byte{0, 0, idxUint8, byte(a.Ndim())})
+	for _, n := range a.Shape() {
+		binary.Write(bw, binary.BigEndian, uint32(n))
+	}
+	for _, v := range a.Data() {
+		bw.WriteByte(toByte(v))
+	}
+	return bw.Flush()
+}
+
+// toByte rounds and clips a value to a byte.
+func toByte(v float64) byte {
+	return byte(math.Max(0, math.Min(255, math.Round(v))))
+}
+
+// open opens a file for reading, transparently decompressing it if it is gzipped as the MNIST downloads are.
+func open(path string) (io.Reader, func() error, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	br := bufio.NewReader(f)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+		return gz, func() error { gz.Close(); return f.Close() }, nil
+	}
+	return br, f.Close, nil
+}
+
+// LoadIDX reads an IDX file, gzipped or not, converting the values to float64.
+//
+// Errors:
+//
+//	Returns an error if the file cannot be read or is not an IDX file.
+func LoadIDX(path string) (*numpy.NDArray, error) {
+	r, closeFn, err := open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer closeFn()
+	a, err := ReadIDX(r)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	return a, nil
+}
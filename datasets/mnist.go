@@ -0,0 +1,97 @@
+package datasets
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/timotewb/gonn/numpy"
+)
+
+// mnistFiles returns the names of the image and label files of a split, as distributed for MNIST and Fashion-MNIST.
+func mnistFiles(train bool) (string, string) {
+	prefix := "t10k"
+	if train {
+		prefix = "train"
+	}
+	return prefix + "-images-idx3-ubyte", prefix + "-labels-idx1-ubyte"
+}
+
+// findFile returns the path of name or name.gz in dir.
+func findFile(dir, name string) (string, error) {
+	for _, candidate := range []string{name, name + ".gz"} {
+		path := filepath.Join(dir, candidate)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("neither %v nor %v.gz found in %v", name, name, dir)
+}
+
+// LoadMNIST reads the MNIST or Fashion-MNIST images and labels of a split from the IDX files in dir, which may be
+// gzipped as downloaded. Nothing is downloaded.
+//
+// Parameters:
+//
+//	dir (string): The directory holding train-images-idx3-ubyte, train-labels-idx1-ubyte, t10k-images-idx3-ubyte
+//	              and t10k-labels-idx1-ubyte, each optionally with a .gz suffix.
+//	train (bool): Whether to read the training split rather than the test split.
+//	normalize (bool): Whether to scale the pixels from [0, 255] to [0, 1].
+//
+// Returns:
+//
+//	(*numpy.NDArray, *numpy.NDArray, error): The images, of shape (n, 28, 28), and the labels, of shape (n,).
+//
+// Errors:
+//
+//	Returns an error if a file is missing or malformed, or the images and labels differ in number.
+func LoadMNIST(dir string, train, normalize bool) (*numpy.NDArray, *numpy.NDArray, error) {
+	imageFile, labelFile := mnistFiles(train)
+	imagePath, err := findFile(dir, imageFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	labelPath, err := findFile(dir, labelFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	images, err := loadUint8(imagePath, normalize)
+	if err != nil {
+		return nil, nil, err
+	}
+	labels, err := loadUint8(labelPath, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	if images.Ndim() != 3 || labels.Ndim() != 1 || images.Shape()[0] != labels.Shape()[0] {
+		return nil, nil, fmt.Errorf("expected images of shape (n, rows, cols) and n labels, got %v and %v", images.Shape(), labels.Shape())
+	}
+	return images, labels, nil
+}
+
+// loadUint8 reads an IDX file of unsigned bytes as floats, scaled to [0, 1] if normalize is set.
+func loadUint8(path string, normalize bool) (*numpy.NDArray, error) {
+	r, closeFn, err := open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer closeFn()
+	values, shape, err := ReadIDXUint8(r)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	return toFloat(values, shape, normalize)
+}
+
+// toFloat converts bytes to an array, scaled to [0, 1] if normalize is set.
+func toFloat(values []uint8, shape []int, normalize bool) (*numpy.NDArray, error) {
+	scale := 1.
+	if normalize {
+		scale = 1. / 255
+	}
+	out := make([]float64, len(values))
+	for i, v := range values {
+		out[i] = float64(v) * scale
+	}
+	return numpy.NewArray(out, shape...)
+}
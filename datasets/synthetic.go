@@ -0,0 +1,157 @@
+package datasets
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/timotewb/gonn/numpy"
+	"github.com/timotewb/gonn/numpy/random"
+)
+
+// syntheticImages draws n images of the given channels and side with labels in [0, classes). Each class lights a
+// different band of rows on top of uniform noise, so a small model can learn to tell the classes apart.
+func syntheticImages(n, channels, side, classes int, g *random.Generator) (*numpy.NDArray, *numpy.NDArray) {
+	pixels := make([]float64, n*channels*side*side)
+	labels := make([]float64, n)
+	band := side / classes
+	if band == 0 {
+		band = 1
+	}
+	for i := 0; i < n; i++ {
+		c := g.Intn(classes)
+		labels[i] = float64(c)
+		lo := (c * side / classes) % side
+		for ch := 0; ch < channels; ch++ {
+			for y := 0; y < side; y++ {
+				for x := 0; x < side; x++ {
+					v := 64 * g.Float64()
+					if y >= lo && y < lo+band {
+						v += 160
+					}
+					pixels[((i*channels+ch)*side+y)*side+x] = float64(int(v))
+				}
+			}
+		}
+	}
+	images, _ := numpy.NewArray(pixels, n, channels, side, side)
+	l, _ := numpy.NewArray(labels, n)
+	return images, l
+}
+
+// writeFile writes the output of write to path, gzipping it if compress is set.
+func writeFile(path string, compress bool, write func(io.Writer) error) error {
+	var buf bytes.Buffer
+	var w io.Writer = &buf
+	var gz *gzip.Writer
+	if compress {
+		gz = gzip.NewWriter(&buf)
+		w = gz
+	}
+	if err := write(w); err != nil {
+		return err
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// WriteSyntheticMNIST writes a tiny MNIST-style dataset of 28x28 images in 10 classes to dir, with the file names
+// read by LoadMNIST, so code using MNIST can run offline and quickly. Each class lights a different band of rows.
+//
+// Parameters:
+//
+//	dir (string): The directory to write to. It must exist.
+//	nTrain, nTest (int): The number of training and test images.
+//	compress (bool): Whether to gzip the files and add the .gz suffix, as in the original downloads.
+//	g (*random.Generator): The source of randomness, or nil for the global one.
+//
+// Errors:
+//
+//	Returns an error if a count is not positive or writing fails.
+//
+// Example usage:
+//
+//	dir, _ := os.MkdirTemp("", "mnist")
+//	datasets.WriteSyntheticMNIST(dir, 64, 16, true, random.NewGenerator(0))
+//	x, y, err := datasets.LoadMNIST(dir, true, true)
+func WriteSyntheticMNIST(dir string, nTrain, nTest int, compress bool, g *random.Generator) error {
+	if nTrain <= 0 || nTest <= 0 {
+		return fmt.Errorf("nTrain and nTest must be positive, got %v and %v", nTrain, nTest)
+	}
+	suffix := ""
+	if compress {
+		suffix = ".gz"
+	}
+	for _, train := range []bool{true, false} {
+		n := nTest
+		if train {
+			n = nTrain
+		}
+		images, labels := syntheticImages(n, 1, 28, 10, g)
+		images, _ = images.Reshape(n, 28, 28)
+		imageFile, labelFile := mnistFiles(train)
+		for _, f := range []struct {
+			name string
+			a    *numpy.NDArray
+		}{{imageFile, images}, {labelFile, labels}} {
+			a := f.a
+			err := writeFile(filepath.Join(dir, f.name+suffix), compress, func(w io.Writer) error { return WriteIDX(w, a) })
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WriteSyntheticCIFAR writes a tiny CIFAR-style dataset of 32x32 colour images to dir, with the file names read by
+// LoadCIFAR10 or, if cifar100 is set, LoadCIFAR100. Each class lights a different band of rows; CIFAR-100 images
+// use 100 fine classes whose coarse label is the fine label divided by 5.
+//
+// Parameters:
+//
+//	dir (string): The directory to write to. It must exist.
+//	nPerBatch (int): The number of images in each batch file. CIFAR-10 has five training batches and CIFAR-100 one.
+//	cifar100 (bool): Whether to write CIFAR-100 batches instead of CIFAR-10.
+//	compress (bool): Whether to gzip the files and add the .gz suffix.
+//	g (*random.Generator): The source of randomness, or nil for the global one.
+//
+// Errors:
+//
+//	Returns an error if nPerBatch is not positive or writing fails.
+func WriteSyntheticCIFAR(dir string, nPerBatch int, cifar100, compress bool, g *random.Generator) error {
+	if nPerBatch <= 0 {
+		return fmt.Errorf("nPerBatch must be positive, got %v", nPerBatch)
+	}
+	files := []string{"data_batch_1.bin", "data_batch_2.bin", "data_batch_3.bin", "data_batch_4.bin", "data_batch_5.bin", "test_batch.bin"}
+	classes := 10
+	if cifar100 {
+		files, classes = []string{"train.bin", "test.bin"}, 100
+	}
+	for _, name := range files {
+		images, labels := syntheticImages(nPerBatch, 3, cifarSide, classes, g)
+		if cifar100 {
+			fine := labels.Data()
+			both := make([]float64, 2*nPerBatch)
+			for i, c := range fine {
+				both[2*i], both[2*i+1] = float64(int(c)/5), c
+			}
+			labels, _ = numpy.NewArray(both, nPerBatch, 2)
+		}
+		if compress {
+			name += ".gz"
+		}
+		err := writeFile(filepath.Join(dir, name), compress, func(w io.Writer) error { return WriteCIFAR(w, images, labels) })
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package datasets
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/timotewb/gonn/numpy"
+	"github.com/timotewb/gonn/numpy/random"
+)
+
+// checkSplit checks the shapes of a loaded split, that its labels are integers below classes and that the
+// normalized pixels are in [0, 1].
+func checkSplit(t *testing.T, x, y *numpy.NDArray, imageShape, labelShape []int, classes int) {
+	t.Helper()
+	if !slices.Equal(x.Shape(), imageShape) {
+		t.Errorf("images have shape %v, want %v", x.Shape(), imageShape)
+	}
+	if !slices.Equal(y.Shape(), labelShape) {
+		t.Errorf("labels have shape %v, want %v", y.Shape(), labelShape)
+	}
+	for _, v := range x.Data() {
+		if v < 0 || v > 1 {
+			t.Fatalf("pixel %v is not normalized", v)
+		}
+	}
+	for _, v := range y.Data() {
+		if v < 0 || v >= float64(classes) || v != float64(int(v)) {
+			t.Fatalf("label %v is not a class below %v", v, classes)
+		}
+	}
+}
+
+// checkSameFiles checks that the arrays loaded from the plain and gzipped files are identical.
+func checkSameFiles(t *testing.T, loaded map[bool][]*numpy.NDArray) {
+	t.Helper()
+	for i, a := range loaded[false] {
+		b := loaded[true][i]
+		if !slices.Equal(a.Shape(), b.Shape()) || !slices.Equal(a.Data(), b.Data()) {
+			t.Errorf("array %v differs between the plain and gzipped files", i)
+		}
+	}
+}
+
+func TestSyntheticMNIST(t *testing.T) {
+	loaded := map[bool][]*numpy.NDArray{}
+	for _, compress := range []bool{false, true} {
+		dir := t.TempDir()
+		if err := WriteSyntheticMNIST(dir, 12, 5, compress, random.NewGenerator(1)); err != nil {
+			t.Fatal(err)
+		}
+		xTrain, yTrain, err := LoadMNIST(dir, true, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkSplit(t, xTrain, yTrain, []int{12, 28, 28}, []int{12}, 10)
+		xTest, yTest, err := LoadMNIST(dir, false, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkSplit(t, xTest, yTest, []int{5, 28, 28}, []int{5}, 10)
+		loaded[compress] = []*numpy.NDArray{xTrain, yTrain, xTest, yTest}
+	}
+	checkSameFiles(t, loaded)
+}
+
+func TestSyntheticCIFAR10(t *testing.T) {
+	loaded := map[bool][]*numpy.NDArray{}
+	for _, compress := range []bool{false, true} {
+		dir := t.TempDir()
+		if err := WriteSyntheticCIFAR(dir, 4, false, compress, random.NewGenerator(2)); err != nil {
+			t.Fatal(err)
+		}
+		xTrain, yTrain, err := LoadCIFAR10(dir, true, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkSplit(t, xTrain, yTrain, []int{20, 3, 32, 32}, []int{20}, 10)
+		xTest, yTest, err := LoadCIFAR10(dir, false, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkSplit(t, xTest, yTest, []int{4, 3, 32, 32}, []int{4}, 10)
+		loaded[compress] = []*numpy.NDArray{xTrain, yTrain, xTest, yTest}
+	}
+	checkSameFiles(t, loaded)
+}
+
+func TestSyntheticCIFAR100(t *testing.T) {
+	loaded := map[bool][]*numpy.NDArray{}
+	for _, compress := range []bool{false, true} {
+		dir := t.TempDir()
+		if err := WriteSyntheticCIFAR(dir, 6, true, compress, random.NewGenerator(3)); err != nil {
+			t.Fatal(err)
+		}
+		xTrain, yTrain, err := LoadCIFAR100(dir, true, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkSplit(t, xTrain, yTrain, []int{6, 3, 32, 32}, []int{6, 2}, 100)
+		xTest, yTest, err := LoadCIFAR100(dir, false, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkSplit(t, xTest, yTest, []int{6, 3, 32, 32}, []int{6, 2}, 100)
+		labels := yTrain.Data()
+		for i := 0; i < len(labels); i += 2 {
+			if coarse, fine := labels[i], labels[i+1]; coarse != float64(int(fine)/5) {
+				t.Errorf("coarse label %v does not match fine label %v", coarse, fine)
+			}
+		}
+		loaded[compress] = []*numpy.NDArray{xTrain, yTrain, xTest, yTest}
+	}
+	checkSameFiles(t, loaded)
+}
@@ -16,6 +16,63 @@
 // numpy
 // numpy attempts to replicate the numpy functionality in go
 //
+// autograd
+// autograd provides a Tensor type wrapping numpy arrays and reverse-mode automatic differentiation
+//
+// nn
+// nn holds neural network layers (dense, convolutional, pooling, recurrent, attention and transformer blocks,
+// normalization, embeddings, activations) with hand-written forward and backward passes, and a Sequential container
+//
+// optim
+// optim holds optimizers (SGD, Adam, AdamW, RMSProp, Adagrad) that update nn parameters in place, and gradient clipping
+//
+// losses
+// losses holds loss functions returning both the loss and its gradient with respect to the predictions
+//
+// initializers
+// initializers holds weight initialization schemes (Xavier, Kaiming, LeCun, orthogonal, truncated normal) drawing
+// from a seedable random.Generator
+//
+// model
+// model holds a Model type wrapping an nn network with an optimizer, loss and metrics, with Fit, Evaluate and Predict,
+// and training callbacks (EarlyStopping, ModelCheckpoint, ReduceLROnPlateau, CSVLogger)
+//
+// schedule
+// schedule holds learning-rate schedules (step, exponential, cosine with warm restarts, 1cycle, warmup, polynomial,
+// reduce on plateau) that can be chained and checkpointed, and a Scheduler applying them to an optimizer
+//
+// checkpoint
+// checkpoint saves and loads versioned training checkpoints holding the network architecture and parameters,
+// optimizer and scheduler state and the epoch in a single .npz-compatible file
+//
+// data
+// data holds the Dataset interface with tensor, subset and concatenated datasets, and a DataLoader that batches and
+// shuffles samples and prefetches batches with a pool of goroutines
+//
+// datasets
+// datasets reads MNIST and Fashion-MNIST IDX files and CIFAR-10/100 binary batches from disk, and writes tiny synthetic
+// versions of them so code can run offline
+//
+// metrics
+// metrics holds streaming evaluation metrics: accuracy, top-k accuracy, precision, recall, F1, confusion matrices,
+// ROC AUC, average precision, R², MAE, RMSE and log-loss
+//
+// gradcheck
+// gradcheck compares analytical gradients of functions and layers to central finite differences and reports the
+// worst element
+//
+// regularizers
+// regularizers holds L1, L2 and elastic net penalties and max-norm, unit-norm and non-negativity constraints
+// attachable to the parameters of a layer
+//
+// sparse
+// sparse holds COO, CSR and CSC sparse matrices with conversion to and from dense arrays, sparse-dense and
+// sparse-sparse products, transposes, row slicing and element-wise arithmetic
+//
+// numpy/fft
+// fft replicates numpy.fft: complex and real transforms of any length in 1, 2 and N dimensions with the backward,
+// ortho and forward normalizations, and the FFTFreq and FFTShift helpers
+//
 // This package is intended to make it easier for data analysts and scientists
 // to adopt go for their work.
 package gonn
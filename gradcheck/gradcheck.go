@@ -0,0 +1,266 @@
+package gradcheck
+
+import (
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/timotewb/gonn/nn"
+	"github.com/timotewb/gonn/numpy"
+	"github.com/timotewb/gonn/numpy/random"
+)
+
+// Default tolerances, as in torch.autograd.gradcheck.
+const (
+	DefaultEps  = 1e-6
+	DefaultRtol = 1e-3
+	DefaultAtol = 1e-5
+)
+
+// Result reports the comparison of analytical and numerical gradients. An element passes if
+// |analytical - numerical| <= Atol + Rtol * |numerical|, and the worst element is the one exceeding its tolerance by
+// the largest factor.
+//
+// Fields:
+//
+//	Passed (bool): Whether every element is within tolerance.
+//	Checked (int): The number of elements compared.
+//	Failed (int): The number of elements outside the tolerance.
+//	Input (int): The position of the input holding the worst element.
+//	Name (string): The name of that input: "input <i>" for Check, and "input" or the parameter name for CheckLayer.
+//	Index ([]int): The index of the worst element within its input.
+//	Analytical, Numerical (float64): The two gradients of the worst element.
+//	AbsError (float64): |Analytical - Numerical| of the worst element.
+//	RelError (float64): AbsError / |Numerical| of the worst element, or +Inf if Numerical is zero.
+type Result struct {
+	Passed     bool
+	Checked    int
+	Failed     int
+	Input      int
+	Name       string
+	Index      []int
+	Analytical float64
+	Numerical  float64
+	AbsError   float64
+	RelError   float64
+}
+
+// String summarizes the result and the worst element.
+func (r *Result) String() string {
+	status := "passed"
+	if !r.Passed {
+		status = fmt.Sprintf("failed on %v of %v elements", r.Failed, r.Checked)
+	}
+	if r.Index == nil {
+		return fmt.Sprintf("gradcheck %v", status)
+	}
+	return fmt.Sprintf("gradcheck %v; worst element %v at %v: analytical %.6g, numerical %.6g, abs error %.3g, rel error %.3g",
+		status, r.Name, r.Index, r.Analytical, r.Numerical, r.AbsError, r.RelError)
+}
+
+// tolerances replaces zero tolerances by the defaults.
+func tolerances(eps, rtol, atol float64) (float64, float64, float64, error) {
+	if eps < 0 || rtol < 0 || atol < 0 {
+		return 0, 0, 0, fmt.Errorf("eps, rtol and atol must not be negative, got %v, %v and %v", eps, rtol, atol)
+	}
+	if eps == 0 {
+		eps = DefaultEps
+	}
+	if rtol == 0 && atol == 0 {
+		rtol, atol = DefaultRtol, DefaultAtol
+	}
+	return eps, rtol, atol, nil
+}
+
+// compare perturbs every element of values in place by +eps and -eps, evaluates f at both points and compares the
+// central difference (f(x + eps) - f(x - eps)) / (2 * eps) to the analytical gradient. Each element is restored
+// after it is checked.
+func compare(f func() (float64, error), values []*numpy.NDArray, names []string, analytical [][]float64, eps, rtol, atol float64) (*Result, error) {
+	res := &Result{Passed: true}
+	worst := -1.
+	for i, v := range values {
+		data := v.Data()
+		if len(analytical[i]) != len(data) {
+			return nil, fmt.Errorf("%v: gradient of %v elements for a value of shape %v", names[i], len(analytical[i]), v.Shape())
+		}
+		for j, orig := range data {
+			data[j] = orig + eps
+			plus, err := f()
+			if err != nil {
+				data[j] = orig
+				return nil, err
+			}
+			data[j] = orig - eps
+			minus, err := f()
+			data[j] = orig
+			if err != nil {
+				return nil, err
+			}
+			numerical := (plus - minus) / (2 * eps)
+			abs := math.Abs(analytical[i][j] - numerical)
+			excess := abs / (atol + rtol*math.Abs(numerical))
+			if math.IsNaN(abs) {
+				excess = math.Inf(1)
+			}
+			res.Checked++
+			if excess > 1 {
+				res.Passed = false
+				res.Failed++
+			}
+			if excess > worst {
+				worst = excess
+				res.Input, res.Name, res.Index = i, names[i], unravel(j, v.Shape())
+				res.Analytical, res.Numerical, res.AbsError = analytical[i][j], numerical, abs
+				res.RelError = abs / math.Abs(numerical)
+			}
+		}
+	}
+	return res, nil
+}
+
+// unravel converts a flat row-major position to an index of the given shape.
+func unravel(pos int, shape []int) []int {
+	index := make([]int, len(shape))
+	for d := len(shape) - 1; d >= 0; d-- {
+		index[d] = pos % shape[d]
+		pos /= shape[d]
+	}
+	return index
+}
+
+// Check compares the analytical gradients of a scalar function to central finite differences, like
+// torch.autograd.gradcheck. f is evaluated twice per input element, so inputs should be small.
+//
+// Parameters:
+//
+//	f (func([]*numpy.NDArray) (float64, error)): The function. It must be deterministic and must not modify its
+//	                                             inputs.
+//	inputs ([]*numpy.NDArray): The point at which to check. They are copied and left unchanged.
+//	grads ([]*numpy.NDArray): The analytical gradient of f with respect to each input, of the same shapes.
+//	eps (float64): The perturbation. 0 selects DefaultEps.
+//	rtol, atol (float64): The relative and absolute tolerances. If both are 0, DefaultRtol and DefaultAtol are used.
+//
+// Returns:
+//
+//	(*Result, error): The comparison, reporting the worst element, and an error only if the check could not run.
+//	                  A gradient mismatch is reported by Result.Passed, not as an error.
+//
+// Errors:
+//
+//	Returns an error if the numbers of inputs and gradients differ, a shape does not match, a tolerance is negative
+//	or f returns an error.
+//
+// Example usage:
+//
+//	// f(x) = sum(x^2) has gradient 2x
+//	f := func(in []*numpy.NDArray) (float64, error) {
+//		sum := 0.
+//		for _, v := range in[0].Data() {
+//			sum += v * v
+//		}
+//		return sum, nil
+//	}
+//	res, err := gradcheck.Check(f, []*numpy.NDArray{x}, []*numpy.NDArray{x.Scale(2)}, 0, 0, 0)
+//	fmt.Println(res)
+func Check(f func(inputs []*numpy.NDArray) (float64, error), inputs, grads []*numpy.NDArray, eps, rtol, atol float64) (*Result, error) {
+	eps, rtol, atol, err := tolerances(eps, rtol, atol)
+	if err != nil {
+		return nil, err
+	}
+	if len(inputs) != len(grads) {
+		return nil, fmt.Errorf("got %v inputs and %v gradients", len(inputs), len(grads))
+	}
+	values := make([]*numpy.NDArray, len(inputs))
+	names := make([]string, len(inputs))
+	analytical := make([][]float64, len(inputs))
+	for i, in := range inputs {
+		if !slices.Equal(in.Shape(), grads[i].Shape()) {
+			return nil, fmt.Errorf("input %v of shape %v has a gradient of shape %v", i, in.Shape(), grads[i].Shape())
+		}
+		values[i], names[i], analytical[i] = in.Copy(), fmt.Sprintf("input %v", i), grads[i].Data()
+	}
+	return compare(func() (float64, error) { return f(values) }, values, names, analytical, eps, rtol, atol)
+}
+
+// CheckLayer checks the Backward of a layer against finite differences of its Forward, for the input and every
+// parameter. The layer output y is reduced to the scalar sum(y * r) for a fixed random r, so Backward is called with
+// r and every element of the output contributes to the check.
+//
+// The layer must be deterministic, so layers with dropout should be put in evaluation mode with nn.Eval first.
+// Parameters are perturbed in place and restored, and their gradients are overwritten. Layers updating buffers in
+// training mode, such as BatchNorm, update them on every call.
+//
+// Parameters:
+//
+//	l (nn.Layer): The layer to check.
+//	x (*numpy.NDArray): The input.
+//	checkInput (bool): Whether to check the gradient with respect to x. Disable it for layers taking indices, such as
+//	                   Embedding.
+//	g (*random.Generator): The source of r, or nil for the global one.
+//	eps, rtol, atol (float64): As for Check.
+//
+// Returns:
+//
+//	(*Result, error): The comparison, where Input 0 is x if checkInput is set, followed by the parameters in the
+//	                  order of l.Parameters().
+//
+// Errors:
+//
+//	Returns an error if a tolerance is negative or Forward or Backward fails.
+//
+// Example usage:
+//
+//	dense, _ := nn.NewDense(3, 2, true)
+//	x, _ := numpy.Array(random.Randn(4, 3))
+//	res, err := gradcheck.CheckLayer(dense, x, true, nil, 0, 0, 0)
+func CheckLayer(l nn.Layer, x *numpy.NDArray, checkInput bool, g *random.Generator, eps, rtol, atol float64) (*Result, error) {
+	eps, rtol, atol, err := tolerances(eps, rtol, atol)
+	if err != nil {
+		return nil, err
+	}
+	x = x.Copy()
+	y, err := l.Forward(x)
+	if err != nil {
+		return nil, err
+	}
+	r, err := numpy.Array(g.Randn(y.Shape()...))
+	if err != nil {
+		return nil, err
+	}
+	if y.Ndim() == 0 {
+		r = numpy.Scalar(g.NormFloat64())
+	}
+	nn.ZeroGrad(l)
+	dx, err := l.Backward(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []*numpy.NDArray
+	var names []string
+	var analytical [][]float64
+	if checkInput {
+		values, names, analytical = append(values, x), append(names, "input"), append(analytical, dx.Data())
+	}
+	for _, p := range l.Parameters() {
+		// Perturbing in place needs the parameter to own contiguous data
+		if c := p.Value.AsContiguous(); c != p.Value {
+			p.Value = c
+		}
+		values, names = append(values, p.Value), append(names, p.Name)
+		analytical = append(analytical, append([]float64{}, p.Grad.Data()...))
+	}
+	rd := r.Data()
+	f := func() (float64, error) {
+		y, err := l.Forward(x)
+		if err != nil {
+			return 0, err
+		}
+		sum := 0.
+		for i, v := range y.Data() {
+			sum += v * rd[i]
+		}
+		return sum, nil
+	}
+	return compare(f, values, names, analytical, eps, rtol, atol)
+}
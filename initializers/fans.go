@@ -0,0 +1,93 @@
+package initializers
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/timotewb/gonn/nn"
+	"github.com/timotewb/gonn/numpy"
+)
+
+// ComputeFans returns the number of inputs and outputs of each unit for a weight of the given shape, following the
+// layouts used by the nn package.
+//
+// For 2-D weights the shape is (fanIn, fanOut), as in Dense and the recurrent layers where inputs are row vectors.
+// For weights with more dimensions, such as the (outChannels, inChannels, kernel...) kernels of Conv2D, the first two
+// axes are the output and input channels and the remaining axes form the receptive field, as in PyTorch.
+//
+// Errors:
+//
+//	Returns an error if the shape has fewer than one dimension.
+func ComputeFans(shape []int) (int, int, error) {
+	switch len(shape) {
+	case 0:
+		return 0, 0, fmt.Errorf("fan in and fan out can not be computed for a 0-d array")
+	case 1:
+		return shape[0], shape[0], nil
+	case 2:
+		return shape[0], shape[1], nil
+	}
+	receptive := 1
+	for _, n := range shape[2:] {
+		receptive *= n
+	}
+	return shape[1] * receptive, shape[0] * receptive, nil
+}
+
+// CalculateGain returns the recommended scaling of the standard deviation for a nonlinearity, like
+// torch.nn.init.calculate_gain.
+//
+// Parameters:
+//
+//	nonlinearity (string): One of "linear", "conv1d", "conv2d", "conv3d", "sigmoid", "tanh", "relu", "leaky_relu"
+//	                       or "selu".
+//	param (float64): The negative slope of "leaky_relu", ignored otherwise.
+//
+// Returns:
+//
+//	(float64, error): The gain.
+//
+// Errors:
+//
+//	Returns an error if the nonlinearity is not recognised.
+func CalculateGain(nonlinearity string, param float64) (float64, error) {
+	switch nonlinearity {
+	case "linear", "conv1d", "conv2d", "conv3d", "sigmoid":
+		return 1, nil
+	case "tanh":
+		return 5.0 / 3, nil
+	case "relu":
+		return math.Sqrt(2), nil
+	case "leaky_relu":
+		return math.Sqrt(2 / (1 + param*param)), nil
+	case "selu":
+		return 3.0 / 4, nil
+	}
+	return 0, fmt.Errorf("unsupported nonlinearity %v", nonlinearity)
+}
+
+// Fill copies values into the value of a parameter in place, so layers can be re-initialized with any of the
+// functions in this package.
+//
+// Example usage:
+//
+//	d, _ := nn.NewDense(256, 128, true)
+//	w, _ := initializers.KaimingNormal(d.Weight.Value.Shape(), 0, "fan_in", "relu", random.NewGenerator(0))
+//	initializers.Fill(d.Weight, w)
+//
+// Errors:
+//
+//	Returns an error if values does not have the shape of the parameter.
+func Fill(p *nn.Parameter, values *numpy.NDArray) error {
+	ps, vs := p.Value.Shape(), values.Shape()
+	if len(ps) != len(vs) {
+		return fmt.Errorf("cannot fill parameter of shape %v with values of shape %v", ps, vs)
+	}
+	for i := range ps {
+		if ps[i] != vs[i] {
+			return fmt.Errorf("cannot fill parameter of shape %v with values of shape %v", ps, vs)
+		}
+	}
+	copy(p.Value.Data(), values.Data())
+	return nil
+}
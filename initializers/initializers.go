@@ -0,0 +1,285 @@
+package initializers
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/timotewb/gonn/numpy"
+	"github.com/timotewb/gonn/numpy/random"
+)
+
+// normal returns samples from N(0, std^2) drawn with g.Randn.
+func normal(shape []int, std float64, g *random.Generator) (*numpy.NDArray, error) {
+	a, err := numpy.Array(g.Randn(shape...))
+	if err != nil {
+		return nil, err
+	}
+	return a.Scale(std), nil
+}
+
+// uniform returns samples from U(-bound, bound) drawn with g.Rand.
+func uniform(shape []int, bound float64, g *random.Generator) (*numpy.NDArray, error) {
+	a, err := numpy.Array(g.Rand(shape...))
+	if err != nil {
+		return nil, err
+	}
+	return a.Apply(func(u float64) float64 { return (2*u - 1) * bound }), nil
+}
+
+// checkShape returns an error if a dimension is negative.
+func checkShape(shape []int) error {
+	for _, n := range shape {
+		if n < 0 {
+			return fmt.Errorf("negative dimensions are not allowed, got shape %v", shape)
+		}
+	}
+	return nil
+}
+
+// XavierUniform returns values drawn from U(-a, a) with a = gain * sqrt(6 / (fanIn + fanOut)) (Glorot and Bengio,
+// 2010), like torch.nn.init.xavier_uniform_.
+//
+// Parameters:
+//
+//	shape ([]int): The shape of the weight, in the layouts described by ComputeFans.
+//	gain (float64): The scaling factor, 1 for linear layers; see CalculateGain.
+//	g (*random.Generator): The source of random numbers, or nil for the package-level source.
+//
+// Returns:
+//
+//	(*numpy.NDArray, error): The initial values.
+//
+// Errors:
+//
+//	Returns an error if the shape is 0-d or has a negative dimension.
+func XavierUniform(shape []int, gain float64, g *random.Generator) (*numpy.NDArray, error) {
+	fanIn, fanOut, err := ComputeFans(shape)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkShape(shape); err != nil {
+		return nil, err
+	}
+	return uniform(shape, gain*math.Sqrt(6/float64(fanIn+fanOut)), g)
+}
+
+// XavierNormal returns values drawn from N(0, std^2) with std = gain * sqrt(2 / (fanIn + fanOut)), like
+// torch.nn.init.xavier_normal_. The parameters are as for XavierUniform.
+func XavierNormal(shape []int, gain float64, g *random.Generator) (*numpy.NDArray, error) {
+	fanIn, fanOut, err := ComputeFans(shape)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkShape(shape); err != nil {
+		return nil, err
+	}
+	return normal(shape, gain*math.Sqrt(2/float64(fanIn+fanOut)), g)
+}
+
+// kaimingStd returns gain / sqrt(fan) for the He initializers.
+func kaimingStd(shape []int, a float64, mode, nonlinearity string) (float64, error) {
+	fanIn, fanOut, err := ComputeFans(shape)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkShape(shape); err != nil {
+		return 0, err
+	}
+	fan := fanIn
+	switch mode {
+	case "fan_in":
+	case "fan_out":
+		fan = fanOut
+	default:
+		return 0, fmt.Errorf("mode %v not supported, please use one of fan_in, fan_out", mode)
+	}
+	gain, err := CalculateGain(nonlinearity, a)
+	if err != nil {
+		return 0, err
+	}
+	return gain / math.Sqrt(float64(fan)), nil
+}
+
+// KaimingUniform returns values drawn from U(-bound, bound) with bound = gain * sqrt(3 / fan) (He et al., 2015),
+// like torch.nn.init.kaiming_uniform_.
+//
+// Parameters:
+//
+//	shape ([]int): The shape of the weight, in the layouts described by ComputeFans.
+//	a (float64): The negative slope of the rectifier, used with "leaky_relu".
+//	mode (string): "fan_in" to preserve the variance of the activations in the forward pass, or "fan_out" to
+//	               preserve the variance of the gradients in the backward pass.
+//	nonlinearity (string): The nonlinearity following the layer, usually "relu" or "leaky_relu"; see CalculateGain.
+//	g (*random.Generator): The source of random numbers, or nil for the package-level source.
+//
+// Returns:
+//
+//	(*numpy.NDArray, error): The initial values.
+//
+// Errors:
+//
+//	Returns an error if the shape is invalid or the mode or nonlinearity is not recognised.
+func KaimingUniform(shape []int, a float64, mode, nonlinearity string, g *random.Generator) (*numpy.NDArray, error) {
+	std, err := kaimingStd(shape, a, mode, nonlinearity)
+	if err != nil {
+		return nil, err
+	}
+	return uniform(shape, math.Sqrt(3)*std, g)
+}
+
+// KaimingNormal returns values drawn from N(0, std^2) with std = gain / sqrt(fan), like
+// torch.nn.init.kaiming_normal_. The parameters are as for KaimingUniform.
+func KaimingNormal(shape []int, a float64, mode, nonlinearity string, g *random.Generator) (*numpy.NDArray, error) {
+	std, err := kaimingStd(shape, a, mode, nonlinearity)
+	if err != nil {
+		return nil, err
+	}
+	return normal(shape, std, g)
+}
+
+// LeCunUniform returns values drawn from U(-a, a) with a = sqrt(3 / fanIn) (LeCun et al., 1998), like
+// tf.keras.initializers.LecunUniform. It is the recommended initialization for SELU networks.
+//
+// Errors:
+//
+//	Returns an error if the shape is 0-d or has a negative dimension.
+func LeCunUniform(shape []int, g *random.Generator) (*numpy.NDArray, error) {
+	return KaimingUniform(shape, 0, "fan_in", "linear", g)
+}
+
+// LeCunNormal returns values drawn from N(0, 1 / fanIn), like tf.keras.initializers.LecunNormal.
+//
+// Errors:
+//
+//	Returns an error if the shape is 0-d or has a negative dimension.
+func LeCunNormal(shape []int, g *random.Generator) (*numpy.NDArray, error) {
+	return KaimingNormal(shape, 0, "fan_in", "linear", g)
+}
+
+// TruncatedNormal returns values drawn from N(mean, std^2) restricted to [a, b], like
+// torch.nn.init.trunc_normal_. Samples are drawn by inverting the normal CDF over the allowed range, so no values
+// are rejected.
+//
+// Parameters:
+//
+//	shape ([]int): The shape of the array.
+//	mean, std (float64): The mean and standard deviation of the normal distribution before truncation.
+//	a, b (float64): The minimum and maximum values, for example mean - 2 * std and mean + 2 * std.
+//	g (*random.Generator): The source of random numbers, or nil for the package-level source.
+//
+// Returns:
+//
+//	(*numpy.NDArray, error): The values.
+//
+// Errors:
+//
+//	Returns an error if std is not positive, a is not less than b, or the shape has a negative dimension.
+func TruncatedNormal(shape []int, mean, std, a, b float64, g *random.Generator) (*numpy.NDArray, error) {
+	if std <= 0 {
+		return nil, fmt.Errorf("std must be positive, got %v", std)
+	}
+	if a >= b {
+		return nil, fmt.Errorf("a (%v) must be less than b (%v)", a, b)
+	}
+	if err := checkShape(shape); err != nil {
+		return nil, err
+	}
+	cdf := func(x float64) float64 { return (1 + math.Erf(x/math.Sqrt2)) / 2 }
+	lo, hi := cdf((a-mean)/std), cdf((b-mean)/std)
+	u, err := numpy.Array(g.Rand(shape...))
+	if err != nil {
+		return nil, err
+	}
+	return u.Apply(func(v float64) float64 {
+		p := lo + v*(hi-lo)
+		x := mean + std*math.Sqrt2*math.Erfinv(2*p-1)
+		return math.Max(a, math.Min(b, x))
+	}), nil
+}
+
+// Orthogonal returns a (semi-)orthogonal matrix scaled by gain (Saxe et al., 2013), like torch.nn.init.orthogonal_.
+//
+// The shape is viewed as a matrix of shape[0] rows and the product of the remaining dimensions as columns. The rows
+// are orthonormal if there are fewer rows than columns, and the columns otherwise. The matrix is the Q factor of the
+// QR decomposition of a matrix of normal samples, with signs chosen so the result is uniformly distributed.
+//
+// Errors:
+//
+//	Returns an error if the shape has fewer than two dimensions or a dimension that is not positive.
+func Orthogonal(shape []int, gain float64, g *random.Generator) (*numpy.NDArray, error) {
+	if len(shape) < 2 {
+		return nil, fmt.Errorf("only arrays with 2 or more dimensions are supported")
+	}
+	rows, cols := shape[0], 1
+	for _, n := range shape[1:] {
+		cols *= n
+	}
+	if rows <= 0 || cols <= 0 {
+		return nil, fmt.Errorf("dimensions must be positive, got shape %v", shape)
+	}
+
+	// Orthonormalize the columns of a tall (n, k) matrix with modified Gram-Schmidt, transposing wide shapes
+	n, k := rows, cols
+	if rows < cols {
+		n, k = cols, rows
+	}
+	q := make([][]float64, k)
+	for j := range q {
+		q[j] = make([]float64, n)
+		for i := range q[j] {
+			q[j][i] = g.NormFloat64()
+		}
+	}
+	for j := range q {
+		for p := 0; p < j; p++ {
+			dot := 0.
+			for i := range q[j] {
+				dot += q[j][i] * q[p][i]
+			}
+			for i := range q[j] {
+				q[j][i] -= dot * q[p][i]
+			}
+		}
+		norm := 0.
+		for _, v := range q[j] {
+			norm += v * v
+		}
+		norm = math.Sqrt(norm)
+		for i := range q[j] {
+			q[j][i] /= norm
+		}
+	}
+
+	out := make([]float64, rows*cols)
+	for j := range q {
+		for i, v := range q[j] {
+			if rows < cols {
+				out[j*cols+i] = gain * v
+			} else {
+				out[i*cols+j] = gain * v
+			}
+		}
+	}
+	return numpy.NewArray(out, shape...)
+}
+
+// Constant returns an array of the given shape filled with value, like torch.nn.init.constant_.
+//
+// Errors:
+//
+//	Returns an error if the shape has a negative dimension.
+func Constant(shape []int, value float64) (*numpy.NDArray, error) {
+	if err := checkShape(shape); err != nil {
+		return nil, err
+	}
+	return numpy.FullArray(value, shape...), nil
+}
+
+// Zeros returns an array of the given shape filled with zeros, like torch.nn.init.zeros_.
+//
+// Errors:
+//
+//	Returns an error if the shape has a negative dimension.
+func Zeros(shape []int) (*numpy.NDArray, error) {
+	return Constant(shape, 0)
+}
@@ -0,0 +1,288 @@
+package losses
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/timotewb/gonn/numpy"
+)
+
+// epsilon bounds probabilities away from zero and one before taking logarithms.
+const epsilon = 1e-12
+
+// BinaryCrossEntropy computes -(t * log(p) + (1 - t) * log(1 - p)) for predicted probabilities p in [0, 1].
+//
+// The probabilities are clipped to [1e-12, 1 - 1e-12] so the loss stays finite. Prefer BinaryCrossEntropyWithLogits
+// when the predictions come from a sigmoid, as it is more accurate for saturated outputs.
+//
+// Parameters:
+//
+//	pred (*numpy.NDArray): The predicted probabilities.
+//	target (*numpy.NDArray): The target probabilities, usually 0 or 1, with the same shape as pred.
+//	reduction (string): One of "mean", "sum" or "none".
+//
+// Returns:
+//
+//	(*numpy.NDArray, *numpy.NDArray, error): The loss and its gradient with respect to pred.
+//
+// Errors:
+//
+//	Returns an error if the shapes differ or the reduction is not valid.
+func BinaryCrossEntropy(pred, target *numpy.NDArray, reduction string) (*numpy.NDArray, *numpy.NDArray, error) {
+	return elementwise(pred, target, reduction, func(p, t float64) (float64, float64) {
+		p = math.Max(epsilon, math.Min(1-epsilon, p))
+		return -(t*math.Log(p) + (1-t)*math.Log(1-p)), (p - t) / (p * (1 - p))
+	})
+}
+
+// BinaryCrossEntropyWithLogits computes the binary cross-entropy of sigmoid(x) in a numerically stable form, like
+// torch.nn.BCEWithLogitsLoss:
+//
+//	max(x, 0) - x * t + log(1 + exp(-|x|))
+//
+// The gradient with respect to the logits is sigmoid(x) - t. The parameters are as for BinaryCrossEntropy, with
+// logits in place of probabilities.
+func BinaryCrossEntropyWithLogits(logits, target *numpy.NDArray, reduction string) (*numpy.NDArray, *numpy.NDArray, error) {
+	return elementwise(logits, target, reduction, func(x, t float64) (float64, float64) {
+		loss := math.Max(x, 0) - x*t + math.Log1p(math.Exp(-math.Abs(x)))
+		var s float64
+		if x >= 0 {
+			s = 1 / (1 + math.Exp(-x))
+		} else {
+			e := math.Exp(x)
+			s = e / (1 + e)
+		}
+		return loss, s - t
+	})
+}
+
+// lanes returns the contiguous data of x with the classes along the last axis, the number of classes and the shape
+// of the per-sample losses.
+func lanes(x *numpy.NDArray) ([]float64, int, []int, error) {
+	shape := x.Shape()
+	if len(shape) == 0 {
+		return nil, 0, nil, fmt.Errorf("expected at least one dimension holding the classes, got a 0-d array")
+	}
+	return x.Data(), shape[len(shape)-1], shape[:len(shape)-1], nil
+}
+
+// logSoftmax writes the log-softmax of a lane into out using the log-sum-exp trick.
+func logSoftmax(lane, out []float64) {
+	m := math.Inf(-1)
+	for _, v := range lane {
+		m = math.Max(m, v)
+	}
+	s := 0.
+	for _, v := range lane {
+		s += math.Exp(v - m)
+	}
+	lse := m + math.Log(s)
+	for i, v := range lane {
+		out[i] = v - lse
+	}
+}
+
+// CategoricalCrossEntropy computes the cross-entropy between the softmax of logits and target distributions, like
+// torch.nn.CrossEntropyLoss with probability targets.
+//
+// The classes are along the last axis, so logits of shape (batch, classes) give one loss per sample. The loss
+// -sum(t * log_softmax(x)) is computed with the log-sum-exp trick, and the gradient with respect to the logits is
+// softmax(x) * sum(t) - t. The mean reduction averages over the samples.
+//
+// Parameters:
+//
+//	logits (*numpy.NDArray): The unnormalised scores, with the classes along the last axis.
+//	target (*numpy.NDArray): The target probabilities, usually one-hot, with the same shape as logits.
+//	reduction (string): One of "mean", "sum" or "none".
+//
+// Returns:
+//
+//	(*numpy.NDArray, *numpy.NDArray, error): The loss, with one value per sample if reduction is "none", and its
+//	                                        gradient with respect to logits.
+//
+// Errors:
+//
+//	Returns an error if logits is 0-d or has an empty class axis, the shapes differ or the reduction is not valid.
+func CategoricalCrossEntropy(logits, target *numpy.NDArray, reduction string) (*numpy.NDArray, *numpy.NDArray, error) {
+	if err := checkReduction(reduction); err != nil {
+		return nil, nil, err
+	}
+	if err := checkShapes(logits, target); err != nil {
+		return nil, nil, err
+	}
+	x, c, outShape, err := lanes(logits)
+	if err != nil {
+		return nil, nil, err
+	}
+	if c == 0 {
+		return nil, nil, fmt.Errorf("expected at least one class, got logits of shape %v", logits.Shape())
+	}
+	t := target.Data()
+	losses := make([]float64, len(x)/c)
+	grad := make([]float64, len(x))
+	for s := range losses {
+		lane, g := x[s*c:(s+1)*c], grad[s*c:(s+1)*c]
+		logSoftmax(lane, g)
+		total := 0.
+		for i := range g {
+			ti := t[s*c+i]
+			if ti != 0 {
+				losses[s] -= ti * g[i]
+			}
+			total += ti
+		}
+		for i := range g {
+			g[i] = math.Exp(g[i])*total - t[s*c+i]
+		}
+	}
+	return reduce(losses, outShape, grad, logits.Shape(), reduction)
+}
+
+// labelIndices checks that labels holds one integer class index in [0, classes) per sample.
+func labelIndices(labels *numpy.NDArray, outShape []int, classes int) ([]int, error) {
+	if err := checkShapes(numpy.ZerosArray(outShape...), labels); err != nil {
+		return nil, fmt.Errorf("labels must have the shape of the input without the class axis: %v", err)
+	}
+	out := make([]int, labels.Size())
+	for i, v := range labels.Data() {
+		if v != math.Trunc(v) || v < 0 || v >= float64(classes) {
+			return nil, fmt.Errorf("label %v is out of bounds for %v classes", v, classes)
+		}
+		out[i] = int(v)
+	}
+	return out, nil
+}
+
+// SparseCategoricalCrossEntropy computes the cross-entropy between the softmax of logits and integer class labels,
+// like torch.nn.CrossEntropyLoss with class index targets. It equals CategoricalCrossEntropy with one-hot targets
+// without building them.
+//
+// Parameters:
+//
+//	logits (*numpy.NDArray): The unnormalised scores, with the classes along the last axis.
+//	labels (*numpy.NDArray): The class index of every sample, with the shape of logits without its last axis.
+//	reduction (string): One of "mean", "sum" or "none".
+//
+// Returns:
+//
+//	(*numpy.NDArray, *numpy.NDArray, error): The loss and its gradient with respect to logits.
+//
+// Errors:
+//
+//	Returns an error if the labels do not match the samples or are not integers within the number of classes, or
+//	the reduction is not valid.
+func SparseCategoricalCrossEntropy(logits, labels *numpy.NDArray, reduction string) (*numpy.NDArray, *numpy.NDArray, error) {
+	if err := checkReduction(reduction); err != nil {
+		return nil, nil, err
+	}
+	x, c, outShape, err := lanes(logits)
+	if err != nil {
+		return nil, nil, err
+	}
+	idx, err := labelIndices(labels, outShape, c)
+	if err != nil {
+		return nil, nil, err
+	}
+	losses := make([]float64, len(idx))
+	grad := make([]float64, len(x))
+	for s, k := range idx {
+		g := grad[s*c : (s+1)*c]
+		logSoftmax(x[s*c:(s+1)*c], g)
+		losses[s] = -g[k]
+		for i := range g {
+			g[i] = math.Exp(g[i])
+		}
+		g[k]--
+	}
+	return reduce(losses, outShape, grad, logits.Shape(), reduction)
+}
+
+// NLL computes the negative log likelihood loss -logProbs[label] for log-probabilities such as the output of a
+// log-softmax, like torch.nn.NLLLoss. The classes are along the last axis of logProbs and labels holds the class
+// index of every sample. The parameters and errors are as for SparseCategoricalCrossEntropy.
+func NLL(logProbs, labels *numpy.NDArray, reduction string) (*numpy.NDArray, *numpy.NDArray, error) {
+	if err := checkReduction(reduction); err != nil {
+		return nil, nil, err
+	}
+	x, c, outShape, err := lanes(logProbs)
+	if err != nil {
+		return nil, nil, err
+	}
+	idx, err := labelIndices(labels, outShape, c)
+	if err != nil {
+		return nil, nil, err
+	}
+	losses := make([]float64, len(idx))
+	grad := make([]float64, len(x))
+	for s, k := range idx {
+		losses[s] = -x[s*c+k]
+		grad[s*c+k] = -1
+	}
+	return reduce(losses, outShape, grad, logProbs.Shape(), reduction)
+}
+
+// SmoothLabels applies label smoothing (Szegedy et al., 2016) to target distributions with the classes along the
+// last axis, mixing them with the uniform distribution: t * (1 - smoothing) + smoothing / classes. Training against
+// smoothed targets with CategoricalCrossEntropy keeps the model from becoming over-confident.
+//
+// Errors:
+//
+//	Returns an error if target is 0-d or smoothing is not in [0, 1].
+func SmoothLabels(target *numpy.NDArray, smoothing float64) (*numpy.NDArray, error) {
+	if smoothing < 0 || smoothing > 1 {
+		return nil, fmt.Errorf("smoothing must be in [0, 1], got %v", smoothing)
+	}
+	_, c, _, err := lanes(target)
+	if err != nil {
+		return nil, err
+	}
+	uniform := smoothing / float64(c)
+	return target.Apply(func(t float64) float64 { return t*(1-smoothing) + uniform }), nil
+}
+
+// SmoothedSparseCategoricalCrossEntropy computes the cross-entropy between the softmax of logits and integer class
+// labels smoothed towards the uniform distribution, like torch.nn.CrossEntropyLoss with label_smoothing. The target
+// of a sample with label k is 1 - smoothing + smoothing / classes for class k and smoothing / classes for the
+// others, so the loss is
+//
+//	-(1 - smoothing) * log_softmax(x)[k] - smoothing / classes * sum(log_softmax(x))
+//
+// and the gradient with respect to the logits is softmax(x) minus the smoothed target. The other parameters are as
+// for SparseCategoricalCrossEntropy, which this equals for smoothing 0.
+//
+// Errors:
+//
+//	Returns an error if smoothing is not in [0, 1], the labels do not match the samples or are not integers within
+//	the number of classes, or the reduction is not valid.
+func SmoothedSparseCategoricalCrossEntropy(logits, labels *numpy.NDArray, smoothing float64, reduction string) (*numpy.NDArray, *numpy.NDArray, error) {
+	if smoothing < 0 || smoothing > 1 {
+		return nil, nil, fmt.Errorf("smoothing must be in [0, 1], got %v", smoothing)
+	}
+	if err := checkReduction(reduction); err != nil {
+		return nil, nil, err
+	}
+	x, c, outShape, err := lanes(logits)
+	if err != nil {
+		return nil, nil, err
+	}
+	idx, err := labelIndices(labels, outShape, c)
+	if err != nil {
+		return nil, nil, err
+	}
+	uniform := smoothing / float64(c)
+	losses := make([]float64, len(idx))
+	grad := make([]float64, len(x))
+	for s, k := range idx {
+		g := grad[s*c : (s+1)*c]
+		logSoftmax(x[s*c:(s+1)*c], g)
+		for i := range g {
+			t := uniform
+			if i == k {
+				t += 1 - smoothing
+			}
+			losses[s] -= t * g[i]
+			g[i] = math.Exp(g[i]) - t
+		}
+	}
+	return reduce(losses, outShape, grad, logits.Shape(), reduction)
+}
@@ -0,0 +1,119 @@
+package losses
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/timotewb/gonn/numpy"
+)
+
+// KLDivergence computes the Kullback-Leibler divergence t * (log(t) - x) between target probabilities t and
+// predicted log-probabilities x, like torch.nn.KLDivLoss. Elements where the target is zero contribute nothing.
+//
+// The mean reduction averages over every element, as in PyTorch; to obtain the divergence per sample use "sum" and
+// divide by the batch size.
+//
+// Parameters:
+//
+//	logPred (*numpy.NDArray): The predicted log-probabilities, such as the output of a log-softmax.
+//	target (*numpy.NDArray): The target probabilities, with the same shape as logPred.
+//	reduction (string): One of "mean", "sum" or "none".
+//
+// Returns:
+//
+//	(*numpy.NDArray, *numpy.NDArray, error): The loss and its gradient -t with respect to logPred.
+//
+// Errors:
+//
+//	Returns an error if the shapes differ or the reduction is not valid.
+func KLDivergence(logPred, target *numpy.NDArray, reduction string) (*numpy.NDArray, *numpy.NDArray, error) {
+	return elementwise(logPred, target, reduction, func(x, t float64) (float64, float64) {
+		if t <= 0 {
+			return 0, 0
+		}
+		return t * (math.Log(t) - x), -t
+	})
+}
+
+// CosineEmbedding computes the cosine embedding loss between pairs of vectors, like torch.nn.CosineEmbeddingLoss:
+//
+//	1 - cos(x1, x2)                  if y = 1
+//	max(0, cos(x1, x2) - margin)     if y = -1
+//
+// The vectors are along the last axis, so inputs of shape (batch, features) give one loss per pair. A small epsilon
+// keeps the cosine finite for zero vectors.
+//
+// Parameters:
+//
+//	x1, x2 (*numpy.NDArray): The two inputs, with the same shape.
+//	y (*numpy.NDArray): 1 for pairs that should be similar and -1 for pairs that should not, with the shape of x1
+//	                    without its last axis.
+//	margin (float64): The cosine below which dissimilar pairs are not penalised, in [-1, 1].
+//	reduction (string): One of "mean", "sum" or "none".
+//
+// Returns:
+//
+//	(*numpy.NDArray, *numpy.NDArray, *numpy.NDArray, error): The loss and its gradients with respect to x1 and x2.
+//
+// Errors:
+//
+//	Returns an error if the shapes do not match, y holds a value other than 1 or -1, or the reduction is not valid.
+func CosineEmbedding(x1, x2, y *numpy.NDArray, margin float64, reduction string) (*numpy.NDArray, *numpy.NDArray, *numpy.NDArray, error) {
+	if err := checkReduction(reduction); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := checkShapes(x1, x2); err != nil {
+		return nil, nil, nil, err
+	}
+	a, d, outShape, err := lanes(x1)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if err := checkShapes(numpy.ZerosArray(outShape...), y); err != nil {
+		return nil, nil, nil, fmt.Errorf("y must have the shape of the inputs without the last axis: %v", err)
+	}
+	b, labels := x2.Data(), y.Data()
+	losses := make([]float64, len(labels))
+	g1 := make([]float64, len(a))
+	g2 := make([]float64, len(a))
+	for s, label := range labels {
+		if label != 1 && label != -1 {
+			return nil, nil, nil, fmt.Errorf("y must be 1 or -1, got %v", label)
+		}
+		u, v := a[s*d:(s+1)*d], b[s*d:(s+1)*d]
+		dot, nu, nv := 0., 1e-12, 1e-12
+		for i := range u {
+			dot += u[i] * v[i]
+			nu += u[i] * u[i]
+			nv += v[i] * v[i]
+		}
+		nu, nv = math.Sqrt(nu), math.Sqrt(nv)
+		cos := dot / (nu * nv)
+
+		// dcos/du = v / (|u||v|) - cos * u / |u|^2, and symmetrically for v
+		scale := 0.
+		if label == 1 {
+			losses[s] = 1 - cos
+			scale = -1
+		} else if cos > margin {
+			losses[s] = cos - margin
+			scale = 1
+		}
+		if scale == 0 {
+			continue
+		}
+		for i := range u {
+			g1[s*d+i] = scale * (v[i]/(nu*nv) - cos*u[i]/(nu*nu))
+			g2[s*d+i] = scale * (u[i]/(nu*nv) - cos*v[i]/(nv*nv))
+		}
+	}
+	loss, grad1, err := reduce(losses, outShape, g1, x1.Shape(), reduction)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	_, grad2, err := reduce(losses, outShape, g2, x1.Shape(), reduction)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return loss, grad1, grad2, nil
+}
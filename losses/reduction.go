@@ -0,0 +1,72 @@
+package losses
+
+import (
+	"fmt"
+
+	"github.com/timotewb/gonn/numpy"
+)
+
+// The reduction modes accepted by every loss function.
+//
+//	"mean": The loss is averaged over every element (or every sample for losses over a class axis).
+//	"sum": The loss is summed.
+//	"none": The unreduced loss is returned with one value per element (or per sample).
+const (
+	Mean = "mean"
+	Sum  = "sum"
+	None = "none"
+)
+
+// checkReduction returns an error if reduction is not one of the supported modes.
+func checkReduction(reduction string) error {
+	switch reduction {
+	case Mean, Sum, None:
+		return nil
+	}
+	return fmt.Errorf("%v is not a valid value for reduction, expected one of mean, sum or none", reduction)
+}
+
+// checkShapes returns an error if the predictions and targets do not have the same shape.
+func checkShapes(pred, target *numpy.NDArray) error {
+	ps, ts := pred.Shape(), target.Shape()
+	if len(ps) != len(ts) {
+		return fmt.Errorf("target shape %v does not match prediction shape %v", ts, ps)
+	}
+	for i := range ps {
+		if ps[i] != ts[i] {
+			return fmt.Errorf("target shape %v does not match prediction shape %v", ts, ps)
+		}
+	}
+	return nil
+}
+
+// reduce applies the reduction to a loss with one value per element or sample, and scales the matching gradient.
+//
+// losses holds the unreduced losses in the given shape, and grad holds the derivative of each of those losses with
+// respect to the predictions. The returned gradient is the derivative of the reduced loss.
+func reduce(losses []float64, shape []int, grad []float64, gradShape []int, reduction string) (*numpy.NDArray, *numpy.NDArray, error) {
+	g, err := numpy.NewArray(grad, gradShape...)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch reduction {
+	case None:
+		l, err := numpy.NewArray(losses, shape...)
+		if err != nil {
+			return nil, nil, err
+		}
+		return l, g, nil
+	case Sum:
+		total := 0.
+		for _, v := range losses {
+			total += v
+		}
+		return numpy.Scalar(total), g, nil
+	}
+	total := 0.
+	for _, v := range losses {
+		total += v
+	}
+	n := float64(len(losses))
+	return numpy.Scalar(total / n), g.Scale(1 / n), nil
+}
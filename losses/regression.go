@@ -0,0 +1,102 @@
+package losses
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/timotewb/gonn/numpy"
+)
+
+// elementwise computes a loss that compares predictions and targets element by element.
+func elementwise(pred, target *numpy.NDArray, reduction string, fn func(p, t float64) (loss, grad float64)) (*numpy.NDArray, *numpy.NDArray, error) {
+	if err := checkReduction(reduction); err != nil {
+		return nil, nil, err
+	}
+	if err := checkShapes(pred, target); err != nil {
+		return nil, nil, err
+	}
+	p, t := pred.Data(), target.Data()
+	losses := make([]float64, len(p))
+	grad := make([]float64, len(p))
+	for i := range p {
+		losses[i], grad[i] = fn(p[i], t[i])
+	}
+	return reduce(losses, pred.Shape(), grad, pred.Shape(), reduction)
+}
+
+// MSE computes the mean squared error (p - t)^2 between predictions and targets.
+//
+// Parameters:
+//
+//	pred (*numpy.NDArray): The predictions.
+//	target (*numpy.NDArray): The targets, with the same shape as pred.
+//	reduction (string): One of "mean", "sum" or "none".
+//
+// Returns:
+//
+//	(*numpy.NDArray, *numpy.NDArray, error): The loss, a 0-d array unless reduction is "none", and the gradient of
+//	                                        the loss with respect to pred.
+//
+// Errors:
+//
+//	Returns an error if the shapes differ or the reduction is not valid.
+func MSE(pred, target *numpy.NDArray, reduction string) (*numpy.NDArray, *numpy.NDArray, error) {
+	return elementwise(pred, target, reduction, func(p, t float64) (float64, float64) {
+		d := p - t
+		return d * d, 2 * d
+	})
+}
+
+// MAE computes the mean absolute error |p - t| between predictions and targets. The gradient is taken as zero where
+// the prediction equals the target. The parameters are as for MSE.
+func MAE(pred, target *numpy.NDArray, reduction string) (*numpy.NDArray, *numpy.NDArray, error) {
+	return elementwise(pred, target, reduction, func(p, t float64) (float64, float64) {
+		d := p - t
+		return math.Abs(d), sign(d)
+	})
+}
+
+// Huber computes the Huber loss, which is quadratic for errors smaller than delta and linear beyond, like
+// torch.nn.HuberLoss:
+//
+//	0.5 * (p - t)^2                    if |p - t| <= delta
+//	delta * (|p - t| - 0.5 * delta)    otherwise
+//
+// Errors:
+//
+//	Returns an error if delta is not positive, the shapes differ or the reduction is not valid.
+func Huber(pred, target *numpy.NDArray, delta float64, reduction string) (*numpy.NDArray, *numpy.NDArray, error) {
+	if delta <= 0 {
+		return nil, nil, fmt.Errorf("delta must be positive, got %v", delta)
+	}
+	return elementwise(pred, target, reduction, func(p, t float64) (float64, float64) {
+		d := p - t
+		if math.Abs(d) <= delta {
+			return 0.5 * d * d, d
+		}
+		return delta * (math.Abs(d) - 0.5*delta), delta * sign(d)
+	})
+}
+
+// Hinge computes the hinge loss max(0, 1 - t * p) for targets of -1 or 1, as used to train support vector machines.
+// The parameters are as for MSE.
+func Hinge(pred, target *numpy.NDArray, reduction string) (*numpy.NDArray, *numpy.NDArray, error) {
+	return elementwise(pred, target, reduction, func(p, t float64) (float64, float64) {
+		margin := 1 - t*p
+		if margin <= 0 {
+			return 0, 0
+		}
+		return margin, -t
+	})
+}
+
+// sign returns -1, 0 or 1 according to the sign of x.
+func sign(x float64) float64 {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,462 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/timotewb/gonn/numpy"
+)
+
+// binary reports whether predictions hold a single probability per sample, either as a 1-D array or with a single
+// value along the last axis.
+func binary(pred *numpy.NDArray) bool {
+	s := pred.Shape()
+	return len(s) == 1 || s[len(s)-1] == 1
+}
+
+// classLabels converts a batch to predicted and true class indices. Predictions with more than one value along the
+// last axis are reduced by their argmax and compared to either one-hot targets of the same shape or integer labels
+// without the class axis. Binary predictions are probabilities of class 1 and are thresholded at 0.5, as are their
+// targets.
+func classLabels(pred, target *numpy.NDArray) ([]int, []int, error) {
+	ps, ts := pred.Shape(), target.Shape()
+	if len(ps) == 0 {
+		return nil, nil, fmt.Errorf("predictions must have at least one dimension")
+	}
+	p, t := pred.Data(), target.Data()
+	if binary(pred) {
+		if len(p) != len(t) {
+			return nil, nil, fmt.Errorf("predictions of shape %v do not match targets of shape %v", ps, ts)
+		}
+		yp, yt := make([]int, len(p)), make([]int, len(p))
+		for i := range p {
+			if p[i] > 0.5 {
+				yp[i] = 1
+			}
+			if t[i] > 0.5 {
+				yt[i] = 1
+			}
+		}
+		return yp, yt, nil
+	}
+	classes := ps[len(ps)-1]
+	if classes == 0 {
+		return nil, nil, fmt.Errorf("predictions of shape %v have no classes", ps)
+	}
+	rows := len(p) / classes
+	oneHot := slices.Equal(ps, ts)
+	if !oneHot && len(t) != rows {
+		return nil, nil, fmt.Errorf("targets of shape %v are neither one-hot nor labels for predictions of shape %v", ts, ps)
+	}
+	yp, yt := make([]int, rows), make([]int, rows)
+	for r := 0; r < rows; r++ {
+		yp[r] = argmax(p[r*classes : (r+1)*classes])
+		if oneHot {
+			yt[r] = argmax(t[r*classes : (r+1)*classes])
+		} else if yt[r] = int(t[r]); t[r] != float64(yt[r]) {
+			return nil, nil, fmt.Errorf("label %v is not an integer", t[r])
+		}
+	}
+	return yp, yt, nil
+}
+
+// argmax returns the index of the largest value, the first one on ties.
+func argmax(values []float64) int {
+	best := 0
+	for i, v := range values {
+		if v > values[best] {
+			best = i
+		}
+	}
+	return best
+}
+
+// NewAccuracy returns a metric named "accuracy" computing the fraction of correctly classified samples.
+//
+// Predictions with more than one value along the last axis are scores of each class, compared by their argmax to
+// one-hot targets of the same shape or to integer labels without the class axis. 1-D predictions and predictions
+// with a single value along the last axis are probabilities of the positive class, thresholded at 0.5.
+func NewAccuracy() Metric {
+	return &meanMetric{name: "accuracy", fn: func(pred, target *numpy.NDArray) (float64, int, error) {
+		yp, yt, err := classLabels(pred, target)
+		if err != nil {
+			return 0, 0, err
+		}
+		correct := 0.
+		for i := range yp {
+			if yp[i] == yt[i] {
+				correct++
+			}
+		}
+		return correct, len(yp), nil
+	}}
+}
+
+// NewTopKAccuracy returns a metric named "top_<k>_accuracy" computing the fraction of samples whose true class is
+// among the k highest scoring classes, as used on ImageNet with k = 5. A class tied with the true class does not
+// push it out of the top k.
+//
+// Parameters:
+//
+//	k (int): The number of highest scoring classes counted as correct.
+//
+// Errors:
+//
+//	Returns an error if k is not positive. Update returns an error if the predictions are not of shape
+//	(..., classes) or the targets are neither one-hot nor labels.
+func NewTopKAccuracy(k int) (Metric, error) {
+	if k <= 0 {
+		return nil, fmt.Errorf("k must be positive, got %v", k)
+	}
+	return &meanMetric{name: fmt.Sprintf("top_%d_accuracy", k), fn: func(pred, target *numpy.NDArray) (float64, int, error) {
+		ps, ts := pred.Shape(), target.Shape()
+		if len(ps) < 2 {
+			return 0, 0, fmt.Errorf("expected predictions of shape (..., classes), got %v", ps)
+		}
+		p, t := pred.Data(), target.Data()
+		classes := ps[len(ps)-1]
+		if classes == 0 {
+			return 0, 0, fmt.Errorf("predictions of shape %v have no classes", ps)
+		}
+		rows := len(p) / classes
+		oneHot := slices.Equal(ps, ts)
+		if !oneHot && len(t) != rows {
+			return 0, 0, fmt.Errorf("targets of shape %v are neither one-hot nor labels for predictions of shape %v", ts, ps)
+		}
+		correct := 0.
+		for r := 0; r < rows; r++ {
+			row := p[r*classes : (r+1)*classes]
+			label := int(t[r])
+			if oneHot {
+				label = argmax(t[r*classes : (r+1)*classes])
+			}
+			if label < 0 || label >= classes {
+				return 0, 0, fmt.Errorf("label %v is out of range for %v classes", label, classes)
+			}
+			higher := 0
+			for _, v := range row {
+				if v > row[label] {
+					higher++
+				}
+			}
+			if higher < k {
+				correct++
+			}
+		}
+		return correct, rows, nil
+	}}, nil
+}
+
+// NewLogLoss returns a metric named "log_loss" computing the mean negative log-likelihood of the targets, also known
+// as cross-entropy.
+//
+// Binary predictions (1-D or with a single value along the last axis) are probabilities of the positive class and
+// the loss is -(t * log(p) + (1 - t) * log(1 - p)). Other predictions are class probabilities whose rows sum to one;
+// the loss is -sum(t * log(p)) for one-hot or soft targets of the same shape and -log(p[label]) for integer labels.
+//
+// Parameters:
+//
+//	eps (float64): Probabilities are clipped to [eps, 1 - eps] to keep the loss finite. 0 selects 1e-15.
+func NewLogLoss(eps float64) Metric {
+	if eps <= 0 {
+		eps = 1e-15
+	}
+	clip := func(p float64) float64 { return math.Max(eps, math.Min(1-eps, p)) }
+	return &meanMetric{name: "log_loss", fn: func(pred, target *numpy.NDArray) (float64, int, error) {
+		ps, ts := pred.Shape(), target.Shape()
+		if len(ps) == 0 {
+			return 0, 0, fmt.Errorf("predictions must have at least one dimension")
+		}
+		p, t := pred.Data(), target.Data()
+		sum := 0.
+		if binary(pred) {
+			if len(p) != len(t) {
+				return 0, 0, fmt.Errorf("predictions of shape %v do not match targets of shape %v", ps, ts)
+			}
+			for i := range p {
+				q := clip(p[i])
+				sum -= t[i]*math.Log(q) + (1-t[i])*math.Log(1-q)
+			}
+			return sum, len(p), nil
+		}
+		classes := ps[len(ps)-1]
+		if classes == 0 {
+			return 0, 0, fmt.Errorf("predictions of shape %v have no classes", ps)
+		}
+		rows := len(p) / classes
+		if slices.Equal(ps, ts) {
+			for i := range p {
+				if t[i] != 0 {
+					sum -= t[i] * math.Log(clip(p[i]))
+				}
+			}
+			return sum, rows, nil
+		}
+		if len(t) != rows {
+			return 0, 0, fmt.Errorf("targets of shape %v are neither one-hot nor labels for predictions of shape %v", ts, ps)
+		}
+		for r := 0; r < rows; r++ {
+			label := int(t[r])
+			if label < 0 || label >= classes {
+				return 0, 0, fmt.Errorf("label %v is out of range for %v classes", t[r], classes)
+			}
+			sum -= math.Log(clip(p[r*classes+label]))
+		}
+		return sum, rows, nil
+	}}
+}
+
+// ConfusionMatrix counts the samples of each true class predicted as each class. Row i, column j holds the number
+// of samples of class i predicted as class j, as in sklearn.metrics.confusion_matrix.
+//
+// Predictions and targets are converted to classes as for NewAccuracy. Its Result is the accuracy, the fraction of
+// samples on the diagonal.
+//
+// Fields:
+//
+//	NumClasses (int): The number of classes.
+type ConfusionMatrix struct {
+	NumClasses int
+
+	counts []float64
+}
+
+// NewConfusionMatrix creates an empty confusion matrix.
+//
+// Errors:
+//
+//	Returns an error if numClasses is less than 2.
+func NewConfusionMatrix(numClasses int) (*ConfusionMatrix, error) {
+	if numClasses < 2 {
+		return nil, fmt.Errorf("numClasses must be at least 2, got %v", numClasses)
+	}
+	return &ConfusionMatrix{NumClasses: numClasses, counts: make([]float64, numClasses*numClasses)}, nil
+}
+
+// Name returns "confusion_matrix".
+func (c *ConfusionMatrix) Name() string { return "confusion_matrix" }
+
+// Reset sets every count to zero.
+func (c *ConfusionMatrix) Reset() {
+	for i := range c.counts {
+		c.counts[i] = 0
+	}
+}
+
+// Update counts the samples of a batch.
+//
+// Errors:
+//
+//	Returns an error if the shapes do not match or a class is not in [0, NumClasses).
+func (c *ConfusionMatrix) Update(pred, target *numpy.NDArray) error {
+	yp, yt, err := classLabels(pred, target)
+	if err != nil {
+		return fmt.Errorf("%v: %v", c.Name(), err)
+	}
+	for i := range yp {
+		if yp[i] < 0 || yp[i] >= c.NumClasses || yt[i] < 0 || yt[i] >= c.NumClasses {
+			return fmt.Errorf("%v: class %v or %v is out of range for %v classes", c.Name(), yt[i], yp[i], c.NumClasses)
+		}
+	}
+	for i := range yp {
+		c.counts[yt[i]*c.NumClasses+yp[i]]++
+	}
+	return nil
+}
+
+// Result returns the accuracy over every sample counted, or 0 before the first Update.
+func (c *ConfusionMatrix) Result() float64 {
+	total, diagonal := 0., 0.
+	for i, v := range c.counts {
+		total += v
+		if i/c.NumClasses == i%c.NumClasses {
+			diagonal += v
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return diagonal / total
+}
+
+// Matrix returns a copy of the counts as an array of shape (NumClasses, NumClasses).
+func (c *ConfusionMatrix) Matrix() *numpy.NDArray {
+	m, _ := numpy.NewArray(append([]float64{}, c.counts...), c.NumClasses, c.NumClasses)
+	return m
+}
+
+// classCounts returns the true positives, false positives and false negatives of every class.
+func (c *ConfusionMatrix) classCounts() (tp, fp, fn []float64) {
+	n := c.NumClasses
+	tp, fp, fn = make([]float64, n), make([]float64, n), make([]float64, n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			v := c.counts[i*n+j]
+			if i == j {
+				tp[i] += v
+			} else {
+				fn[i] += v
+				fp[j] += v
+			}
+		}
+	}
+	return tp, fp, fn
+}
+
+// classScore is a metric computed from the per-class counts of a confusion matrix, shared by Precision, Recall and
+// F1Score.
+type classScore struct {
+	name    string
+	average string
+	score   func(tp, fp, fn float64) float64
+	matrix  *ConfusionMatrix
+}
+
+// newClassScore validates the arguments shared by the precision, recall and F1 constructors.
+func newClassScore(name string, numClasses int, average string, score func(tp, fp, fn float64) float64) (classScore, error) {
+	switch average {
+	case "micro", "macro", "weighted":
+	case "binary":
+		if numClasses != 2 {
+			return classScore{}, fmt.Errorf("binary averaging requires 2 classes, got %v", numClasses)
+		}
+	default:
+		return classScore{}, fmt.Errorf("unknown average %q, expected micro, macro, weighted or binary", average)
+	}
+	m, err := NewConfusionMatrix(numClasses)
+	if err != nil {
+		return classScore{}, err
+	}
+	return classScore{name: name, average: average, score: score, matrix: m}, nil
+}
+
+// Name returns the name of the metric.
+func (s *classScore) Name() string { return s.name }
+
+// Reset clears the confusion matrix.
+func (s *classScore) Reset() { s.matrix.Reset() }
+
+// Update adds the predictions and targets of a batch to the confusion matrix.
+//
+// Errors:
+//
+//	Returns an error if the shapes do not match or a class is out of range.
+func (s *classScore) Update(pred, target *numpy.NDArray) error { return s.matrix.Update(pred, target) }
+
+// ConfusionMatrix returns the confusion matrix the score is computed from.
+func (s *classScore) ConfusionMatrix() *ConfusionMatrix { return s.matrix }
+
+// PerClass returns the score of every class, as with average=None in scikit-learn.
+func (s *classScore) PerClass() []float64 {
+	tp, fp, fn := s.matrix.classCounts()
+	out := make([]float64, len(tp))
+	for i := range tp {
+		out[i] = s.score(tp[i], fp[i], fn[i])
+	}
+	return out
+}
+
+// Result returns the averaged score. Micro averaging scores the counts summed over the classes, macro averaging
+// takes the mean of the per-class scores, weighted averaging weights them by the number of true samples of each
+// class, and binary averaging returns the score of class 1. Macro and weighted averages skip classes that appear
+// in neither the targets nor the predictions, as scikit-learn does.
+func (s *classScore) Result() float64 {
+	tp, fp, fn := s.matrix.classCounts()
+	switch s.average {
+	case "binary":
+		return s.score(tp[1], fp[1], fn[1])
+	case "micro":
+		var t, p, n float64
+		for i := range tp {
+			t, p, n = t+tp[i], p+fp[i], n+fn[i]
+		}
+		return s.score(t, p, n)
+	}
+	sum, weights := 0., 0.
+	for i := range tp {
+		if tp[i]+fp[i]+fn[i] == 0 {
+			continue
+		}
+		w := 1.
+		if s.average == "weighted" {
+			w = tp[i] + fn[i]
+		}
+		sum += w * s.score(tp[i], fp[i], fn[i])
+		weights += w
+	}
+	if weights == 0 {
+		return 0
+	}
+	return sum / weights
+}
+
+// ratio returns a / b, or 0 if b is 0.
+func ratio(a, b float64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return a / b
+}
+
+// Precision is the fraction of the samples predicted as a class that belong to it, tp / (tp + fp). It is 0 for a
+// class that is never predicted.
+type Precision struct{ classScore }
+
+// Recall is the fraction of the samples of a class predicted as it, tp / (tp + fn). It is 0 for a class with no
+// samples.
+type Recall struct{ classScore }
+
+// F1Score is the harmonic mean of precision and recall, 2 * tp / (2 * tp + fp + fn).
+type F1Score struct{ classScore }
+
+// NewPrecision creates a precision metric named "precision".
+//
+// Parameters:
+//
+//	numClasses (int): The number of classes.
+//	average (string): How the per-class scores are combined: "micro", "macro", "weighted" or, with two classes,
+//	                  "binary" for the score of class 1.
+//
+// Errors:
+//
+//	Returns an error if numClasses is less than 2 or average is not recognised.
+func NewPrecision(numClasses int, average string) (*Precision, error) {
+	s, err := newClassScore("precision", numClasses, average, func(tp, fp, fn float64) float64 {
+		return ratio(tp, tp+fp)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Precision{s}, nil
+}
+
+// NewRecall creates a recall metric named "recall". The parameters are as for NewPrecision.
+//
+// Errors:
+//
+//	Returns an error if numClasses is less than 2 or average is not recognised.
+func NewRecall(numClasses int, average string) (*Recall, error) {
+	s, err := newClassScore("recall", numClasses, average, func(tp, fp, fn float64) float64 {
+		return ratio(tp, tp+fn)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Recall{s}, nil
+}
+
+// NewF1Score creates an F1 metric named "f1". The parameters are as for NewPrecision.
+//
+// Errors:
+//
+//	Returns an error if numClasses is less than 2 or average is not recognised.
+func NewF1Score(numClasses int, average string) (*F1Score, error) {
+	s, err := newClassScore("f1", numClasses, average, func(tp, fp, fn float64) float64 {
+		return ratio(2*tp, 2*tp+fp+fn)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &F1Score{s}, nil
+}
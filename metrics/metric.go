@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/timotewb/gonn/numpy"
+)
+
+// Metric is a quantity accumulated over batches of predictions and targets. It has the same methods as model.Metric,
+// so every metric of this package can be passed to model.Compile.
+//
+// Reset clears the accumulated state, Update adds the predictions and targets of a batch, and Result returns the
+// value over every batch since the last Reset. Streaming over batches gives the same result as a single Update with
+// every sample.
+type Metric interface {
+	Name() string
+	Reset()
+	Update(pred, target *numpy.NDArray) error
+	Result() float64
+}
+
+// meanMetric is a Metric averaging a per-sample quantity over every sample seen.
+type meanMetric struct {
+	name  string
+	fn    func(pred, target *numpy.NDArray) (sum float64, count int, err error)
+	sum   float64
+	count int
+}
+
+func (m *meanMetric) Name() string { return m.name }
+
+func (m *meanMetric) Reset() { m.sum, m.count = 0, 0 }
+
+func (m *meanMetric) Update(pred, target *numpy.NDArray) error {
+	s, n, err := m.fn(pred, target)
+	if err != nil {
+		return fmt.Errorf("%v: %v", m.name, err)
+	}
+	m.sum += s
+	m.count += n
+	return nil
+}
+
+func (m *meanMetric) Result() float64 {
+	if m.count == 0 {
+		return 0
+	}
+	return m.sum / float64(m.count)
+}
+
+// Evaluate computes a metric over a single batch, resetting it first.
+//
+// Example usage:
+//
+//	acc, err := metrics.Evaluate(metrics.NewAccuracy(), pred, target)
+func Evaluate(m Metric, pred, target *numpy.NDArray) (float64, error) {
+	m.Reset()
+	if err := m.Update(pred, target); err != nil {
+		return 0, err
+	}
+	return m.Result(), nil
+}
+
+// checkSameShape returns an error if the predictions and targets differ in shape.
+func checkSameShape(pred, target *numpy.NDArray) error {
+	if !slices.Equal(pred.Shape(), target.Shape()) {
+		return fmt.Errorf("predictions of shape %v do not match targets of shape %v", pred.Shape(), target.Shape())
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/timotewb/gonn/numpy"
+)
+
+// scores accumulates the predicted scores and binary targets needed by the ranking metrics. Unlike the other
+// metrics they depend on the order of every sample, so each score is kept until Reset.
+type scores struct {
+	scores []float64
+	labels []bool
+}
+
+// update appends the scores and targets of a batch.
+func (s *scores) update(pred, target *numpy.NDArray) error {
+	if len(pred.Shape()) == 0 || !binary(pred) {
+		return fmt.Errorf("expected predictions of shape (n,) or (n, 1), got %v", pred.Shape())
+	}
+	p, t := pred.Data(), target.Data()
+	if len(p) != len(t) {
+		return fmt.Errorf("predictions of shape %v do not match targets of shape %v", pred.Shape(), target.Shape())
+	}
+	for i := range p {
+		if math.IsNaN(p[i]) {
+			return fmt.Errorf("prediction %v is NaN", i)
+		}
+	}
+	for i := range p {
+		s.scores = append(s.scores, p[i])
+		s.labels = append(s.labels, t[i] > 0.5)
+	}
+	return nil
+}
+
+// counts returns the distinct scores in decreasing order with the cumulative numbers of true and false positives
+// when every sample scoring at least that much is predicted positive.
+func (s *scores) counts() (thresholds, tps, fps []float64) {
+	order := make([]int, len(s.scores))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return s.scores[order[a]] > s.scores[order[b]] })
+	tp, fp := 0., 0.
+	for i, idx := range order {
+		if s.labels[idx] {
+			tp++
+		} else {
+			fp++
+		}
+		if i == len(order)-1 || s.scores[order[i+1]] != s.scores[idx] {
+			thresholds = append(thresholds, s.scores[idx])
+			tps, fps = append(tps, tp), append(fps, fp)
+		}
+	}
+	return thresholds, tps, fps
+}
+
+// AUC is the area under the receiver operating characteristic curve of a binary classifier, the probability that a
+// random positive sample scores higher than a random negative one.
+//
+// Predictions are scores of the positive class of shape (n,) or (n, 1), such as probabilities or logits, and
+// targets are 1 for positive samples and 0 for negative ones. Every score is kept until Reset, so the result over
+// several batches is exact.
+type AUC struct {
+	scores
+}
+
+// NewAUC creates an AUC metric named "auc".
+func NewAUC() *AUC {
+	return &AUC{}
+}
+
+// Name returns "auc".
+func (a *AUC) Name() string { return "auc" }
+
+// Reset forgets every score.
+func (a *AUC) Reset() { a.scores = scores{} }
+
+// Update adds the scores and targets of a batch.
+//
+// Errors:
+//
+//	Returns an error if the predictions do not hold one score per target or a score is NaN.
+func (a *AUC) Update(pred, target *numpy.NDArray) error {
+	if err := a.update(pred, target); err != nil {
+		return fmt.Errorf("%v: %v", a.Name(), err)
+	}
+	return nil
+}
+
+// Curve returns the ROC curve, one point per distinct score in decreasing order preceded by the point (0, 0) at
+// threshold +Inf, like sklearn.metrics.roc_curve with drop_intermediate=False.
+//
+// Returns:
+//
+//	fpr ([]float64): The false positive rate when samples scoring at least the threshold are predicted positive.
+//	tpr ([]float64): The true positive rate at each threshold.
+//	thresholds ([]float64): The thresholds.
+//
+// The rates are NaN if the targets hold no negative or no positive samples.
+func (a *AUC) Curve() (fpr, tpr, thresholds []float64) {
+	th, tps, fps := a.counts()
+	fpr, tpr = []float64{0}, []float64{0}
+	thresholds = append([]float64{math.Inf(1)}, th...)
+	positives, negatives := 0., 0.
+	if len(tps) > 0 {
+		positives, negatives = tps[len(tps)-1], fps[len(fps)-1]
+	}
+	for i := range th {
+		fpr = append(fpr, fps[i]/negatives)
+		tpr = append(tpr, tps[i]/positives)
+	}
+	return fpr, tpr, thresholds
+}
+
+// Result returns the area under the ROC curve by the trapezoidal rule, or NaN if the targets hold no negative or no
+// positive samples.
+func (a *AUC) Result() float64 {
+	fpr, tpr, _ := a.Curve()
+	if len(fpr) < 2 || math.IsNaN(fpr[1]) || math.IsNaN(tpr[1]) {
+		return math.NaN()
+	}
+	area := 0.
+	for i := 1; i < len(fpr); i++ {
+		area += (fpr[i] - fpr[i-1]) * (tpr[i] + tpr[i-1]) / 2
+	}
+	return area
+}
+
+// AveragePrecision summarizes the precision-recall curve of a binary classifier as the mean of the precisions at
+// each threshold weighted by the increase in recall, sum((R_n - R_(n-1)) * P_n), like
+// sklearn.metrics.average_precision_score.
+//
+// Predictions and targets are as for AUC, and every score is likewise kept until Reset.
+type AveragePrecision struct {
+	scores
+}
+
+// NewAveragePrecision creates an average precision metric named "average_precision".
+func NewAveragePrecision() *AveragePrecision {
+	return &AveragePrecision{}
+}
+
+// Name returns "average_precision".
+func (a *AveragePrecision) Name() string { return "average_precision" }
+
+// Reset forgets every score.
+func (a *AveragePrecision) Reset() { a.scores = scores{} }
+
+// Update adds the scores and targets of a batch.
+//
+// Errors:
+//
+//	Returns an error if the predictions do not hold one score per target or a score is NaN.
+func (a *AveragePrecision) Update(pred, target *numpy.NDArray) error {
+	if err := a.update(pred, target); err != nil {
+		return fmt.Errorf("%v: %v", a.Name(), err)
+	}
+	return nil
+}
+
+// Curve returns the precision-recall curve, one point per distinct score in decreasing order preceded by the point
+// of precision 1 and recall 0 at threshold +Inf.
+//
+// Returns:
+//
+//	precision ([]float64): The fraction of samples scoring at least the threshold that are positive.
+//	recall ([]float64): The fraction of positive samples scoring at least the threshold.
+//	thresholds ([]float64): The thresholds.
+//
+// The recalls are NaN if the targets hold no positive samples.
+func (a *AveragePrecision) Curve() (precision, recall, thresholds []float64) {
+	th, tps, fps := a.counts()
+	precision, recall = []float64{1}, []float64{0}
+	thresholds = append([]float64{math.Inf(1)}, th...)
+	positives := 0.
+	if len(tps) > 0 {
+		positives = tps[len(tps)-1]
+	}
+	for i := range th {
+		precision = append(precision, tps[i]/(tps[i]+fps[i]))
+		recall = append(recall, tps[i]/positives)
+	}
+	return precision, recall, thresholds
+}
+
+// Result returns the average precision, or NaN if the targets hold no positive samples.
+func (a *AveragePrecision) Result() float64 {
+	precision, recall, _ := a.Curve()
+	if len(recall) < 2 || math.IsNaN(recall[1]) {
+		return math.NaN()
+	}
+	ap := 0.
+	for i := 1; i < len(recall); i++ {
+		ap += (recall[i] - recall[i-1]) * precision[i]
+	}
+	return ap
+}
+
+// ROCCurve returns the ROC curve of a single batch. See AUC.Curve.
+//
+// Errors:
+//
+//	Returns an error if the predictions do not hold one score per target or a score is NaN.
+func ROCCurve(pred, target *numpy.NDArray) (fpr, tpr, thresholds []float64, err error) {
+	a := NewAUC()
+	if err := a.Update(pred, target); err != nil {
+		return nil, nil, nil, err
+	}
+	fpr, tpr, thresholds = a.Curve()
+	return fpr, tpr, thresholds, nil
+}
+
+// PrecisionRecallCurve returns the precision-recall curve of a single batch. See AveragePrecision.Curve.
+//
+// Errors:
+//
+//	Returns an error if the predictions do not hold one score per target or a score is NaN.
+func PrecisionRecallCurve(pred, target *numpy.NDArray) (precision, recall, thresholds []float64, err error) {
+	a := NewAveragePrecision()
+	if err := a.Update(pred, target); err != nil {
+		return nil, nil, nil, err
+	}
+	precision, recall, thresholds = a.Curve()
+	return precision, recall, thresholds, nil
+}
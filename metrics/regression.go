@@ -0,0 +1,142 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/timotewb/gonn/numpy"
+)
+
+// errorSum returns a metric function summing fn(pred - target) over every element.
+func errorSum(fn func(d float64) float64) func(pred, target *numpy.NDArray) (float64, int, error) {
+	return func(pred, target *numpy.NDArray) (float64, int, error) {
+		if err := checkSameShape(pred, target); err != nil {
+			return 0, 0, err
+		}
+		p, t := pred.Data(), target.Data()
+		sum := 0.
+		for i := range p {
+			sum += fn(p[i] - t[i])
+		}
+		return sum, len(p), nil
+	}
+}
+
+// NewMeanAbsoluteError returns a metric named "mae" computing the mean of |pred - target| over every element.
+func NewMeanAbsoluteError() Metric {
+	return &meanMetric{name: "mae", fn: errorSum(math.Abs)}
+}
+
+// NewMeanSquaredError returns a metric named "mse" computing the mean of (pred - target)^2 over every element.
+func NewMeanSquaredError() Metric {
+	return &meanMetric{name: "mse", fn: errorSum(func(d float64) float64 { return d * d })}
+}
+
+// rootMetric is a Metric returning the square root of another metric's result.
+type rootMetric struct {
+	Metric
+	name string
+}
+
+func (r *rootMetric) Name() string { return r.name }
+
+func (r *rootMetric) Result() float64 { return math.Sqrt(r.Metric.Result()) }
+
+// NewRootMeanSquaredError returns a metric named "rmse" computing the square root of the mean squared error over
+// every element seen, which is not the mean of the per-batch root mean squared errors.
+func NewRootMeanSquaredError() Metric {
+	return &rootMetric{Metric: NewMeanSquaredError(), name: "rmse"}
+}
+
+// R2Score is the coefficient of determination, 1 - sum((target - pred)^2) / sum((target - mean(target))^2), the
+// fraction of the variance of the targets explained by the predictions. It is 1 for perfect predictions, 0 for
+// predicting the mean and negative for worse predictions.
+//
+// 1-D arrays are a single output. For arrays of shape (n, outputs) the score of each output is computed and their
+// mean returned, like sklearn.metrics.r2_score with multioutput="uniform_average". The mean and the sum of squared
+// deviations of the targets are accumulated per output and merged across batches (Chan et al., 1979), so streaming
+// over batches gives the exact result without the cancellation of raw sums for targets far from zero.
+type R2Score struct {
+	count             float64
+	mean, m2, residSq []float64
+}
+
+// NewR2Score creates an R² metric named "r2".
+func NewR2Score() *R2Score {
+	return &R2Score{}
+}
+
+// Name returns "r2".
+func (r *R2Score) Name() string { return "r2" }
+
+// Reset forgets every sample.
+func (r *R2Score) Reset() { *r = R2Score{} }
+
+// Update adds the predictions and targets of a batch.
+//
+// Errors:
+//
+//	Returns an error if the shapes differ, are not (n,) or (n, outputs), or the number of outputs changes between
+//	batches.
+func (r *R2Score) Update(pred, target *numpy.NDArray) error {
+	if err := checkSameShape(pred, target); err != nil {
+		return fmt.Errorf("%v: %v", r.Name(), err)
+	}
+	s := pred.Shape()
+	if len(s) != 1 && len(s) != 2 {
+		return fmt.Errorf("%v: expected arrays of shape (n,) or (n, outputs), got %v", r.Name(), s)
+	}
+	outputs := 1
+	if len(s) == 2 {
+		outputs = s[1]
+	}
+	if r.mean == nil {
+		r.mean, r.m2, r.residSq = make([]float64, outputs), make([]float64, outputs), make([]float64, outputs)
+	} else if len(r.mean) != outputs {
+		return fmt.Errorf("%v: expected %v outputs, got %v", r.Name(), len(r.mean), outputs)
+	}
+	if s[0] == 0 {
+		return nil
+	}
+
+	// Compute the mean and squared deviations of the batch, then merge them into the running values
+	p, t := pred.Data(), target.Data()
+	n := float64(s[0])
+	mean, m2 := make([]float64, outputs), make([]float64, outputs)
+	for i, v := range t {
+		mean[i%outputs] += v / n
+	}
+	for i := range t {
+		j := i % outputs
+		d := t[i] - p[i]
+		m2[j] += (t[i] - mean[j]) * (t[i] - mean[j])
+		r.residSq[j] += d * d
+	}
+	total := r.count + n
+	for j := range mean {
+		delta := mean[j] - r.mean[j]
+		r.mean[j] += delta * n / total
+		r.m2[j] += m2[j] + delta*delta*r.count*n/total
+	}
+	r.count = total
+	return nil
+}
+
+// Result returns the mean R² of the outputs, or 0 before the first Update. An output with constant targets scores 1
+// if it is predicted exactly and 0 otherwise.
+func (r *R2Score) Result() float64 {
+	if r.count == 0 {
+		return 0
+	}
+	total := 0.
+	for j := range r.m2 {
+		variance := r.m2[j]
+		switch {
+		case variance > 0:
+			total += 1 - r.residSq[j]/variance
+		case r.residSq[j] == 0:
+			total++
+		}
+	}
+	return total / float64(len(r.m2))
+}
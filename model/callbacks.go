@@ -0,0 +1,377 @@
+package model
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/timotewb/gonn/nn"
+	"github.com/timotewb/gonn/numpy"
+)
+
+// Callback is notified at points of the training loop run by Fit.
+//
+// OnEpochBegin is called before the first batch of each epoch, OnBatchEnd after each optimization step with the
+// running loss and metrics of the epoch, and OnEpochEnd with the final logs of the epoch, including validation
+// results. Epochs and batches are counted from 0. Returning an error stops Fit and returns the error. Embed
+// BaseCallback to implement only some of the methods.
+type Callback interface {
+	OnEpochBegin(m *Model, epoch int) error
+	OnEpochEnd(m *Model, epoch int, logs Logs) error
+	OnBatchEnd(m *Model, batch int, logs Logs) error
+}
+
+// BaseCallback implements every method of Callback by doing nothing.
+type BaseCallback struct{}
+
+func (BaseCallback) OnEpochBegin(m *Model, epoch int) error { return nil }
+
+func (BaseCallback) OnEpochEnd(m *Model, epoch int, logs Logs) error { return nil }
+
+func (BaseCallback) OnBatchEnd(m *Model, batch int, logs Logs) error { return nil }
+
+// History records the logs of every epoch completed by Fit.
+//
+// Fields:
+//
+//	Epochs ([]int): The completed epochs.
+//	Values (map[string][]float64): The value of each log entry, such as "loss" or "val_accuracy", for every epoch.
+type History struct {
+	Epochs []int
+	Values map[string][]float64
+}
+
+// append records the logs of an epoch.
+func (h *History) append(epoch int, logs Logs) {
+	h.Epochs = append(h.Epochs, epoch)
+	for k, v := range logs {
+		h.Values[k] = append(h.Values[k], v)
+	}
+}
+
+// monitor tracks the best value of a log entry, shared by the callbacks reacting to a quantity that stops
+// improving.
+type monitor struct {
+	name     string
+	minDelta float64
+	maximize bool
+	best     float64
+	wait     int
+}
+
+// newMonitor creates a monitor. In "auto" mode the quantity is maximized if it is a score, such as accuracy, AUC,
+// precision, recall, F1 or R², and minimized otherwise.
+func newMonitor(name string, minDelta float64, mode string) (monitor, error) {
+	mon := monitor{name: name, minDelta: math.Abs(minDelta)}
+	switch mode {
+	case "min":
+	case "max":
+		mon.maximize = true
+	case "auto":
+		mon.maximize = maximized(name)
+	default:
+		return monitor{}, fmt.Errorf("unknown mode %q, expected min, max or auto", mode)
+	}
+	mon.reset()
+	return mon, nil
+}
+
+// maximized reports whether a logged quantity is a score where higher is better, ignoring any "val_" prefix.
+func maximized(name string) bool {
+	name = strings.TrimPrefix(name, "val_")
+	switch name {
+	case "auc", "average_precision", "precision", "recall", "f1", "r2":
+		return true
+	}
+	return strings.Contains(name, "acc")
+}
+
+// reset forgets the best value.
+func (mon *monitor) reset() {
+	mon.best, mon.wait = math.Inf(1), 0
+	if mon.maximize {
+		mon.best = math.Inf(-1)
+	}
+}
+
+// update records the monitored value from logs and reports whether it improved on the best value by more than
+// minDelta. Otherwise the number of epochs waited is incremented.
+func (mon *monitor) update(logs Logs) (bool, error) {
+	v, ok := logs[mon.name]
+	if !ok {
+		return false, fmt.Errorf("monitored quantity %q is not available, available keys are %v", mon.name, logs)
+	}
+	if (mon.maximize && v > mon.best+mon.minDelta) || (!mon.maximize && v < mon.best-mon.minDelta) {
+		mon.best, mon.wait = v, 0
+		return true, nil
+	}
+	mon.wait++
+	return false, nil
+}
+
+// EarlyStopping stops training when a monitored quantity has stopped improving, like
+// tf.keras.callbacks.EarlyStopping.
+type EarlyStopping struct {
+	BaseCallback
+	Patience           int
+	RestoreBestWeights bool
+	StoppedEpoch       int
+
+	monitor
+	bestWeights [][]float64
+}
+
+// NewEarlyStopping creates an early stopping callback.
+//
+// Parameters:
+//
+//	monitor (string): The log entry to monitor, for example "val_loss".
+//	minDelta (float64): The minimum change counted as an improvement.
+//	patience (int): The number of epochs without improvement after which training stops.
+//	mode (string): "min" if the quantity should decrease, "max" if it should increase, or "auto" to infer it from
+//	               the name.
+//	restoreBestWeights (bool): Whether to restore the parameters and buffers of the best epoch when training stops.
+//
+// Returns:
+//
+//	(*EarlyStopping, error): The callback, or nil and an error if the mode or patience is invalid.
+//
+// Errors:
+//
+//	Returns an error if patience is negative or the mode is not recognised.
+func NewEarlyStopping(monitor string, minDelta float64, patience int, mode string, restoreBestWeights bool) (*EarlyStopping, error) {
+	if patience < 0 {
+		return nil, fmt.Errorf("patience must be non-negative, got %v", patience)
+	}
+	mon, err := newMonitor(monitor, minDelta, mode)
+	if err != nil {
+		return nil, err
+	}
+	return &EarlyStopping{Patience: patience, RestoreBestWeights: restoreBestWeights, StoppedEpoch: -1, monitor: mon}, nil
+}
+
+// OnEpochBegin resets the callback at the start of training so it can be reused across calls to Fit.
+func (e *EarlyStopping) OnEpochBegin(m *Model, epoch int) error {
+	if epoch == 0 {
+		e.reset()
+		e.bestWeights, e.StoppedEpoch = nil, -1
+	}
+	return nil
+}
+
+// OnEpochEnd updates the best value and sets m.StopTraining once the patience is exhausted.
+func (e *EarlyStopping) OnEpochEnd(m *Model, epoch int, logs Logs) error {
+	improved, err := e.update(logs)
+	if err != nil {
+		return err
+	}
+	if improved && e.RestoreBestWeights {
+		e.bestWeights = snapshot(m.Network)
+	}
+	if !improved && e.wait >= e.Patience {
+		m.StopTraining = true
+		e.StoppedEpoch = epoch
+		if e.bestWeights != nil {
+			restore(m.Network, e.bestWeights)
+		}
+	}
+	return nil
+}
+
+// ModelCheckpoint saves the weights of the model with SaveWeights after each epoch, or only when a monitored
+// quantity improves, like tf.keras.callbacks.ModelCheckpoint.
+type ModelCheckpoint struct {
+	BaseCallback
+	Path         string
+	SaveBestOnly bool
+
+	monitor
+}
+
+// NewModelCheckpoint creates a checkpoint callback.
+//
+// Parameters:
+//
+//	path (string): The file to write. "{epoch}" is replaced by the epoch number, counted from 1, so each epoch can
+//	               be saved to a separate file.
+//	monitor (string): The log entry deciding which epochs are the best, for example "val_loss".
+//	saveBestOnly (bool): Whether to save only when the monitored quantity improves.
+//	mode (string): "min", "max" or "auto", as for NewEarlyStopping.
+//
+// Returns:
+//
+//	(*ModelCheckpoint, error): The callback, or nil and an error if the mode is invalid.
+//
+// Errors:
+//
+//	Returns an error if the mode is not recognised.
+func NewModelCheckpoint(path, monitor string, saveBestOnly bool, mode string) (*ModelCheckpoint, error) {
+	mon, err := newMonitor(monitor, 0, mode)
+	if err != nil {
+		return nil, err
+	}
+	return &ModelCheckpoint{Path: path, SaveBestOnly: saveBestOnly, monitor: mon}, nil
+}
+
+// OnEpochEnd saves the weights if required.
+func (c *ModelCheckpoint) OnEpochEnd(m *Model, epoch int, logs Logs) error {
+	if c.SaveBestOnly {
+		improved, err := c.update(logs)
+		if err != nil || !improved {
+			return err
+		}
+	}
+	return m.SaveWeights(strings.ReplaceAll(c.Path, "{epoch}", fmt.Sprint(epoch+1)))
+}
+
+// ReduceLROnPlateau multiplies the learning rate of every parameter group by a factor when a monitored quantity has
+// stopped improving, like tf.keras.callbacks.ReduceLROnPlateau.
+type ReduceLROnPlateau struct {
+	BaseCallback
+	Factor   float64
+	Patience int
+	Cooldown int
+	MinLR    float64
+
+	monitor
+	cooldown int
+}
+
+// NewReduceLROnPlateau creates a learning rate reduction callback.
+//
+// Parameters:
+//
+//	monitor (string): The log entry to monitor, for example "val_loss".
+//	factor (float64): The factor in (0, 1) by which the learning rate is multiplied.
+//	patience (int): The number of epochs without improvement after which the learning rate is reduced.
+//	minDelta (float64): The minimum change counted as an improvement.
+//	cooldown (int): The number of epochs to wait after a reduction before resuming normal operation.
+//	minLR (float64): The lower bound of the learning rate.
+//	mode (string): "min", "max" or "auto", as for NewEarlyStopping.
+//
+// Returns:
+//
+//	(*ReduceLROnPlateau, error): The callback, or nil and an error if an argument is invalid.
+//
+// Errors:
+//
+//	Returns an error if factor is not in (0, 1), patience, cooldown or minLR is negative, or the mode is not
+//	recognised.
+func NewReduceLROnPlateau(monitor string, factor float64, patience int, minDelta float64, cooldown int, minLR float64, mode string) (*ReduceLROnPlateau, error) {
+	if factor <= 0 || factor >= 1 {
+		return nil, fmt.Errorf("factor must be in (0, 1), got %v", factor)
+	}
+	if patience < 0 || cooldown < 0 || minLR < 0 {
+		return nil, fmt.Errorf("patience, cooldown and minLR must be non-negative")
+	}
+	mon, err := newMonitor(monitor, minDelta, mode)
+	if err != nil {
+		return nil, err
+	}
+	return &ReduceLROnPlateau{Factor: factor, Patience: patience, Cooldown: cooldown, MinLR: minLR, monitor: mon}, nil
+}
+
+// OnEpochEnd updates the best value and reduces the learning rate once the patience is exhausted. The reduced
+// learning rate is added to logs as "lr".
+func (r *ReduceLROnPlateau) OnEpochEnd(m *Model, epoch int, logs Logs) error {
+	improved, err := r.update(logs)
+	if err != nil {
+		return err
+	}
+	if r.cooldown > 0 {
+		r.cooldown--
+		r.wait = 0
+	}
+	if !improved && r.wait >= r.Patience {
+		for _, g := range m.Optimizer.ParamGroups() {
+			g.LR = math.Max(g.LR*r.Factor, r.MinLR)
+			logs["lr"] = g.LR
+		}
+		r.cooldown, r.wait = r.Cooldown, 0
+	}
+	return nil
+}
+
+// CSVLogger writes the logs of each epoch as a row of comma-separated values, like tf.keras.callbacks.CSVLogger.
+// The first row is a header with "epoch" followed by the log names in alphabetical order.
+//
+// Example usage:
+//
+//	f, _ := os.Create("training.csv")
+//	defer f.Close()
+//	m.Fit(x, y, 10, 32, 0.1, model.NewCSVLogger(f, ","))
+type CSVLogger struct {
+	BaseCallback
+	Separator string
+
+	w    io.Writer
+	keys []string
+}
+
+// NewCSVLogger creates a logger writing to w with the given separator.
+func NewCSVLogger(w io.Writer, separator string) *CSVLogger {
+	return &CSVLogger{w: w, Separator: separator}
+}
+
+// OnEpochEnd writes the logs of the epoch, preceded by the header on the first epoch.
+func (c *CSVLogger) OnEpochEnd(m *Model, epoch int, logs Logs) error {
+	if c.keys == nil {
+		for k := range logs {
+			c.keys = append(c.keys, k)
+		}
+		sort.Strings(c.keys)
+		if _, err := fmt.Fprintln(c.w, strings.Join(append([]string{"epoch"}, c.keys...), c.Separator)); err != nil {
+			return err
+		}
+	}
+	row := []string{fmt.Sprint(epoch)}
+	for _, k := range c.keys {
+		v, ok := logs[k]
+		if !ok {
+			row = append(row, "")
+			continue
+		}
+		row = append(row, fmt.Sprint(v))
+	}
+	_, err := fmt.Fprintln(c.w, strings.Join(row, c.Separator))
+	return err
+}
+
+// state returns the parameter values of a network followed by its buffers in the order of their names.
+func state(network nn.Layer) []*numpy.NDArray {
+	arrays := []*numpy.NDArray{}
+	for _, p := range network.Parameters() {
+		arrays = append(arrays, p.Value)
+	}
+	if b, ok := network.(nn.Buffered); ok {
+		buffers := b.Buffers()
+		names := make([]string, 0, len(buffers))
+		for name := range buffers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			arrays = append(arrays, buffers[name])
+		}
+	}
+	return arrays
+}
+
+// snapshot copies the parameters and buffers of a network.
+func snapshot(network nn.Layer) [][]float64 {
+	arrays := state(network)
+	values := make([][]float64, len(arrays))
+	for i, a := range arrays {
+		values[i] = append([]float64{}, a.Data()...)
+	}
+	return values
+}
+
+// restore copies values taken by snapshot back into the parameters and buffers of a network.
+func restore(network nn.Layer, values [][]float64) {
+	for i, a := range state(network) {
+		copy(a.Data(), values[i])
+	}
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/timotewb/gonn/metrics"
+	"github.com/timotewb/gonn/numpy"
+)
+
+// Metric is a quantity reported during training and evaluation, accumulated over the batches of an epoch.
+//
+// Reset clears the accumulated state at the start of each epoch or evaluation, Update adds the predictions and
+// targets of a batch, and Result returns the value over every batch since the last Reset. The metrics package
+// implements it for classification, ranking and regression metrics.
+type Metric interface {
+	Name() string
+	Reset()
+	Update(pred, target *numpy.NDArray) error
+	Result() float64
+}
+
+// newMetric returns the built-in metric with the given name.
+func newMetric(name string) (Metric, error) {
+	switch name {
+	case "accuracy", "acc":
+		return metrics.NewAccuracy(), nil
+	case "mse", "mean_squared_error":
+		return metrics.NewMeanSquaredError(), nil
+	case "mae", "mean_absolute_error":
+		return metrics.NewMeanAbsoluteError(), nil
+	case "rmse", "root_mean_squared_error":
+		return metrics.NewRootMeanSquaredError(), nil
+	case "r2":
+		return metrics.NewR2Score(), nil
+	case "auc":
+		return metrics.NewAUC(), nil
+	case "log_loss":
+		return metrics.NewLogLoss(0), nil
+	}
+	return nil, fmt.Errorf("unknown metric %q, expected accuracy, mse, mae, rmse, r2, auc or log_loss", name)
+}
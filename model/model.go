@@ -0,0 +1,456 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/timotewb/gonn/losses"
+	"github.com/timotewb/gonn/nn"
+	"github.com/timotewb/gonn/numpy"
+	"github.com/timotewb/gonn/numpy/random"
+	"github.com/timotewb/gonn/optim"
+)
+
+// Loss computes the loss of a batch, reduced to a scalar, and its gradient with respect to the predictions. The
+// functions of the losses package have this form once the reduction is fixed, for example
+//
+//	func(pred, target *numpy.NDArray) (*numpy.NDArray, *numpy.NDArray, error) {
+//	    return losses.Huber(pred, target, 1, losses.Mean)
+//	}
+type Loss func(pred, target *numpy.NDArray) (*numpy.NDArray, *numpy.NDArray, error)
+
+// Logs holds the values reported to callbacks, keyed by name: "loss" and the metric names for the training data,
+// and the same names prefixed with "val_" for the validation data.
+type Logs map[string]float64
+
+// Model wraps a network with an optimizer, a loss and metrics, and provides the training loop, like a compiled
+// tf.keras.Model.
+//
+// Example usage:
+//
+//	net := nn.NewSequential(d1, nn.NewReLU(), d2)
+//	m := model.New(net)
+//	opt, _ := optim.NewAdam(net.Parameters(), 1e-3, 0.9, 0.999, 1e-8, 0)
+//	m.Compile(opt, "sparse_categorical_crossentropy", "accuracy")
+//	history, _ := m.Fit(x, y, 10, 32, 0.1, model.NewEarlyStopping("val_loss", 0, 3, "auto", true))
+//
+// Fields:
+//
+//	Network (nn.Layer): The network trained by the model.
+//	Optimizer (optim.Optimizer): The optimizer set by Compile.
+//	Loss (Loss): The loss set by Compile.
+//	Metrics ([]Metric): The metrics set by Compile.
+//	BatchTransform (func): An optional transform of each training batch before the step, such as data.Mixup or
+//	                       data.CutMix. Validation and evaluation batches are not transformed.
+//	Shuffle (bool): Whether Fit shuffles the training samples before each epoch. Defaults to true.
+//	Rand (*random.Generator): The source of randomness for shuffling, or nil for the package-level source.
+//	Verbose (bool): Whether Fit prints a summary line after each epoch.
+//	StopTraining (bool): Set by callbacks such as EarlyStopping to end Fit after the current epoch.
+type Model struct {
+	Network        nn.Layer
+	Optimizer      optim.Optimizer
+	Loss           Loss
+	Metrics        []Metric
+	BatchTransform func(x, y *numpy.NDArray) (*numpy.NDArray, *numpy.NDArray, error)
+	Shuffle        bool
+	Rand           *random.Generator
+	Verbose        bool
+	StopTraining   bool
+}
+
+// New creates a model around a network. Compile must be called before Fit or Evaluate.
+func New(network nn.Layer) *Model {
+	return &Model{Network: network, Shuffle: true}
+}
+
+// Compile sets the optimizer, loss and metrics used by Fit and Evaluate.
+//
+// Parameters:
+//
+//	optimizer (optim.Optimizer): The optimizer updating the parameters of the network.
+//	loss (interface{}): A Loss, or the name of a loss from the losses package with mean reduction: "mse", "mae",
+//	                    "hinge", "binary_crossentropy", "binary_crossentropy_with_logits",
+//	                    "categorical_crossentropy" or "sparse_categorical_crossentropy". The cross-entropies other
+//	                    than "binary_crossentropy" take logits.
+//	metrics (...interface{}): Metric values, such as those of the metrics package, or the names of built-in
+//	                          metrics: "accuracy", "mse", "mae", "rmse", "r2", "auc" or "log_loss".
+//
+// Returns:
+//
+//	error: An error if an argument is invalid.
+//
+// Errors:
+//
+//	Returns an error if the optimizer or loss is nil, or a loss or metric name is not recognised.
+func (m *Model) Compile(optimizer optim.Optimizer, loss interface{}, metrics ...interface{}) error {
+	if optimizer == nil {
+		return fmt.Errorf("optimizer must not be nil")
+	}
+	var lossFn Loss
+	switch l := loss.(type) {
+	case Loss:
+		lossFn = l
+	case func(pred, target *numpy.NDArray) (*numpy.NDArray, *numpy.NDArray, error):
+		lossFn = l
+	case string:
+		var err error
+		if lossFn, err = namedLoss(l); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported loss type %T", loss)
+	}
+	if lossFn == nil {
+		return fmt.Errorf("loss must not be nil")
+	}
+
+	ms := make([]Metric, len(metrics))
+	for i, metric := range metrics {
+		switch v := metric.(type) {
+		case Metric:
+			ms[i] = v
+		case string:
+			var err error
+			if ms[i], err = newMetric(v); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported metric type %T", metric)
+		}
+	}
+	m.Optimizer, m.Loss, m.Metrics = optimizer, lossFn, ms
+	return nil
+}
+
+// namedLoss returns the loss with the given name and mean reduction.
+func namedLoss(name string) (Loss, error) {
+	var fn func(pred, target *numpy.NDArray, reduction string) (*numpy.NDArray, *numpy.NDArray, error)
+	switch name {
+	case "mse", "mean_squared_error":
+		fn = losses.MSE
+	case "mae", "mean_absolute_error":
+		fn = losses.MAE
+	case "hinge":
+		fn = losses.Hinge
+	case "binary_crossentropy":
+		fn = losses.BinaryCrossEntropy
+	case "binary_crossentropy_with_logits":
+		fn = losses.BinaryCrossEntropyWithLogits
+	case "categorical_crossentropy":
+		fn = losses.CategoricalCrossEntropy
+	case "sparse_categorical_crossentropy":
+		fn = losses.SparseCategoricalCrossEntropy
+	default:
+		return nil, fmt.Errorf("unknown loss %q", name)
+	}
+	return func(pred, target *numpy.NDArray) (*numpy.NDArray, *numpy.NDArray, error) {
+		return fn(pred, target, losses.Mean)
+	}, nil
+}
+
+// Fit trains the network for a number of epochs.
+//
+// Each epoch the training samples are shuffled (unless Shuffle is false) and split into batches. For each batch the
+// BatchTransform is applied, if any, the gradients are cleared, the loss is computed and backpropagated, the
+// penalties of parameters with a Regularizer are added to the loss and their gradients, the optimizer takes a step
+// and the Constraint of each parameter is applied. After each epoch the model is evaluated on the validation
+// samples, if any, and the callbacks receive the epoch logs.
+//
+// Parameters:
+//
+//	x (*numpy.NDArray): The inputs, with the samples along the first axis.
+//	y (*numpy.NDArray): The targets, with the same number of samples as x.
+//	epochs (int): The number of passes over the training samples.
+//	batchSize (int): The number of samples per batch. The last batch of an epoch may be smaller.
+//	validationSplit (float64): The fraction of samples, in [0, 1), held out for validation. As in Keras, the last
+//	                           samples are held out, before shuffling.
+//	callbacks (...Callback): Callbacks invoked during training.
+//
+// Returns:
+//
+//	(*History, error): The logs of every completed epoch, which are also returned alongside an error.
+//
+// Errors:
+//
+//	Returns an error if the model is not compiled, an argument is invalid, or the network, loss, optimizer or a
+//	callback reports an error.
+func (m *Model) Fit(x, y *numpy.NDArray, epochs, batchSize int, validationSplit float64, callbacks ...Callback) (*History, error) {
+	history := &History{Values: map[string][]float64{}}
+	n, err := checkSamples(x, y)
+	if err != nil {
+		return history, err
+	}
+	if m.Optimizer == nil || m.Loss == nil {
+		return history, fmt.Errorf("model must be compiled before Fit")
+	}
+	if epochs < 0 || batchSize <= 0 {
+		return history, fmt.Errorf("epochs must be non-negative and batchSize positive, got %v and %v", epochs, batchSize)
+	}
+	if validationSplit < 0 || validationSplit >= 1 {
+		return history, fmt.Errorf("validationSplit must be in [0, 1), got %v", validationSplit)
+	}
+
+	trainSize := n - int(math.Floor(float64(n)*validationSplit))
+	if trainSize == 0 {
+		return history, fmt.Errorf("validationSplit %v leaves no training samples", validationSplit)
+	}
+	order := make([]int, trainSize)
+	for i := range order {
+		order[i] = i
+	}
+	var xVal, yVal *numpy.NDArray
+	if trainSize < n {
+		if xVal, err = x.View(numpy.S(trainSize, n)); err != nil {
+			return history, err
+		}
+		if yVal, err = y.View(numpy.S(trainSize, n)); err != nil {
+			return history, err
+		}
+	}
+
+	// Batches are gathered from the flat data, so strided inputs are copied once rather than for every batch
+	x, y = x.AsContiguous(), y.AsContiguous()
+	m.StopTraining = false
+	for epoch := 0; epoch < epochs && !m.StopTraining; epoch++ {
+		for _, c := range callbacks {
+			if err := c.OnEpochBegin(m, epoch); err != nil {
+				return history, err
+			}
+		}
+		nn.Train(m.Network)
+		if m.Shuffle {
+			m.Rand.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+		}
+		for _, metric := range m.Metrics {
+			metric.Reset()
+		}
+
+		lossSum := 0.
+		for batch, start := 0, 0; start < trainSize; batch, start = batch+1, start+batchSize {
+			idx := order[start:min(start+batchSize, trainSize)]
+			xb, err := take(x, idx)
+			if err != nil {
+				return history, err
+			}
+			yb, err := take(y, idx)
+			if err != nil {
+				return history, err
+			}
+			if m.BatchTransform != nil {
+				if xb, yb, err = m.BatchTransform(xb, yb); err != nil {
+					return history, fmt.Errorf("epoch %v, batch %v: %v", epoch, batch, err)
+				}
+			}
+			loss, err := m.trainBatch(xb, yb)
+			if err != nil {
+				return history, fmt.Errorf("epoch %v, batch %v: %v", epoch, batch, err)
+			}
+			lossSum += loss * float64(len(idx))
+			logs := m.logs(lossSum/float64(start+len(idx)), "")
+			for _, c := range callbacks {
+				if err := c.OnBatchEnd(m, batch, logs); err != nil {
+					return history, err
+				}
+			}
+		}
+
+		logs := m.logs(lossSum/float64(trainSize), "")
+		if xVal != nil {
+			val, err := m.Evaluate(xVal, yVal, batchSize)
+			if err != nil {
+				return history, fmt.Errorf("epoch %v, validation: %v", epoch, err)
+			}
+			for k, v := range val {
+				logs["val_"+k] = v
+			}
+		}
+		history.append(epoch, logs)
+		if m.Verbose {
+			fmt.Printf("Epoch %v/%v - %v\n", epoch+1, epochs, logs)
+		}
+		for _, c := range callbacks {
+			if err := c.OnEpochEnd(m, epoch, logs); err != nil {
+				return history, err
+			}
+		}
+	}
+	return history, nil
+}
+
+// trainBatch runs one optimization step and returns the loss of the batch, including the regularization penalties.
+func (m *Model) trainBatch(x, y *numpy.NDArray) (float64, error) {
+	m.Optimizer.ZeroGrad()
+	pred, err := m.Network.Forward(x)
+	if err != nil {
+		return 0, err
+	}
+	loss, grad, err := m.Loss(pred, y)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := m.Network.Backward(grad); err != nil {
+		return 0, err
+	}
+	penalty, err := nn.Regularize(m.Network)
+	if err != nil {
+		return 0, err
+	}
+	if err := m.Optimizer.Step(); err != nil {
+		return 0, err
+	}
+	if err := nn.ApplyConstraints(m.Network); err != nil {
+		return 0, err
+	}
+	for _, metric := range m.Metrics {
+		if err := metric.Update(pred, y); err != nil {
+			return 0, err
+		}
+	}
+	l, err := loss.Item()
+	if err != nil {
+		return 0, err
+	}
+	return l + penalty, nil
+}
+
+// logs returns the loss and the current result of every metric, with names prefixed by prefix.
+func (m *Model) logs(loss float64, prefix string) Logs {
+	logs := Logs{prefix + "loss": loss}
+	for _, metric := range m.Metrics {
+		logs[prefix+metric.Name()] = metric.Result()
+	}
+	return logs
+}
+
+// Evaluate returns the loss and metrics of the network over x and y in evaluation mode, without updating the
+// parameters. As in training, the loss includes the penalties of parameters with a Regularizer.
+//
+// Parameters:
+//
+//	x (*numpy.NDArray): The inputs, with the samples along the first axis.
+//	y (*numpy.NDArray): The targets, with the same number of samples as x.
+//	batchSize (int): The number of samples per forward pass.
+//
+// Returns:
+//
+//	(Logs, error): The loss, averaged over the samples, and the result of every metric.
+//
+// Errors:
+//
+//	Returns an error if the model is not compiled, the arguments are invalid, or the network or loss reports an
+//	error.
+func (m *Model) Evaluate(x, y *numpy.NDArray, batchSize int) (Logs, error) {
+	if m.Loss == nil {
+		return nil, fmt.Errorf("model must be compiled before Evaluate")
+	}
+	n, err := checkSamples(x, y)
+	if err != nil {
+		return nil, err
+	}
+	if batchSize <= 0 {
+		return nil, fmt.Errorf("batchSize must be positive, got %v", batchSize)
+	}
+	nn.Eval(m.Network)
+	for _, metric := range m.Metrics {
+		metric.Reset()
+	}
+	lossSum := 0.
+	for start := 0; start < n; start += batchSize {
+		end := min(start+batchSize, n)
+		xb, _ := x.View(numpy.S(start, end))
+		yb, _ := y.View(numpy.S(start, end))
+		pred, err := m.Network.Forward(xb)
+		if err != nil {
+			return nil, err
+		}
+		loss, _, err := m.Loss(pred, yb)
+		if err != nil {
+			return nil, err
+		}
+		l, err := loss.Item()
+		if err != nil {
+			return nil, err
+		}
+		lossSum += l * float64(end-start)
+		for _, metric := range m.Metrics {
+			if err := metric.Update(pred, yb); err != nil {
+				return nil, err
+			}
+		}
+	}
+	penalty, err := nn.Penalty(m.Network)
+	if err != nil {
+		return nil, err
+	}
+	return m.logs(lossSum/float64(n)+penalty, ""), nil
+}
+
+// Predict returns the outputs of the network for x in evaluation mode, computed batchSize samples at a time and
+// concatenated along the first axis.
+//
+// Errors:
+//
+//	Returns an error if x has no sample axis, batchSize is not positive, or the network reports an error.
+func (m *Model) Predict(x *numpy.NDArray, batchSize int) (*numpy.NDArray, error) {
+	if x.Ndim() == 0 {
+		return nil, fmt.Errorf("inputs must have a sample axis")
+	}
+	if batchSize <= 0 {
+		return nil, fmt.Errorf("batchSize must be positive, got %v", batchSize)
+	}
+	nn.Eval(m.Network)
+	n := x.Shape()[0]
+	outputs := []*numpy.NDArray{}
+	for start := 0; start < n; start += batchSize {
+		xb, _ := x.View(numpy.S(start, min(start+batchSize, n)))
+		y, err := m.Network.Forward(xb)
+		if err != nil {
+			return nil, err
+		}
+		// Layers may return views of internal buffers, so each batch is copied before the next forward pass
+		outputs = append(outputs, y.Copy())
+	}
+	if len(outputs) == 0 {
+		return nil, fmt.Errorf("inputs must contain at least one sample")
+	}
+	return numpy.Concatenate(outputs, 0)
+}
+
+// checkSamples returns the number of samples of x and y.
+func checkSamples(x, y *numpy.NDArray) (int, error) {
+	if x.Ndim() == 0 || y.Ndim() == 0 {
+		return 0, fmt.Errorf("inputs and targets must have a sample axis")
+	}
+	if x.Shape()[0] != y.Shape()[0] {
+		return 0, fmt.Errorf("inputs have %v samples but targets have %v", x.Shape()[0], y.Shape()[0])
+	}
+	return x.Shape()[0], nil
+}
+
+// take returns a contiguous array with the samples of a at the given indices along the first axis.
+func take(a *numpy.NDArray, idx []int) (*numpy.NDArray, error) {
+	shape := append([]int{len(idx)}, a.Shape()[1:]...)
+	data := a.Data()
+	size := len(data) / a.Shape()[0]
+	out := make([]float64, len(idx)*size)
+	for i, j := range idx {
+		copy(out[i*size:(i+1)*size], data[j*size:(j+1)*size])
+	}
+	return numpy.NewArray(out, shape...)
+}
+
+// String formats the logs as "name: value" pairs in alphabetical order.
+func (l Logs) String() string {
+	keys := make([]string, 0, len(l))
+	for k := range l {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%v: %.4f", k, l[k])
+	}
+	return strings.Join(parts, " - ")
+}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/timotewb/gonn/nn"
+	"github.com/timotewb/gonn/numpy"
+)
+
+// weightsFile is the JSON layout written by SaveWeights.
+type weightsFile struct {
+	Parameters []*numpy.NDArray          `json:"parameters"`
+	Buffers    map[string]*numpy.NDArray `json:"buffers,omitempty"`
+}
+
+// SaveWeights writes the parameter values of the network, in the order of Parameters, and its buffers (such as the
+// running statistics of BatchNorm) to a JSON file.
+//
+// Errors:
+//
+//	Returns an error if the file cannot be written.
+func (m *Model) SaveWeights(path string) error {
+	f := weightsFile{}
+	for _, p := range m.Network.Parameters() {
+		f.Parameters = append(f.Parameters, p.Value)
+	}
+	if b, ok := m.Network.(nn.Buffered); ok {
+		f.Buffers = b.Buffers()
+	}
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// LoadWeights copies the values written by SaveWeights into the parameters and buffers of the network, which must
+// have the same architecture as the saved one.
+//
+// Errors:
+//
+//	Returns an error if the file cannot be read or does not match the parameters and buffers of the network.
+func (m *Model) LoadWeights(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	f := weightsFile{}
+	if err := json.Unmarshal(data, &f); err != nil {
+		return err
+	}
+	params := m.Network.Parameters()
+	if len(f.Parameters) != len(params) {
+		return fmt.Errorf("file has %v parameters but the network has %v", len(f.Parameters), len(params))
+	}
+	for i, p := range params {
+		if !slices.Equal(p.Value.Shape(), f.Parameters[i].Shape()) {
+			return fmt.Errorf("parameter %v (%v) has shape %v but the file has %v", i, p.Name, p.Value.Shape(), f.Parameters[i].Shape())
+		}
+	}
+	buffers := map[string]*numpy.NDArray{}
+	if b, ok := m.Network.(nn.Buffered); ok {
+		buffers = b.Buffers()
+	}
+	for name, v := range f.Buffers {
+		b, ok := buffers[name]
+		if !ok || !slices.Equal(b.Shape(), v.Shape()) {
+			return fmt.Errorf("buffer %q does not match the network", name)
+		}
+	}
+	for i, p := range params {
+		copy(p.Value.Data(), f.Parameters[i].Data())
+	}
+	for name, v := range f.Buffers {
+		copy(buffers[name].Data(), v.Data())
+	}
+	return nil
+}
@@ -0,0 +1,334 @@
+package nn
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/timotewb/gonn/numpy"
+)
+
+// elementwise is the shared implementation of activations that are applied to every element independently.
+// f returns the activation of x and df its derivative given x and the activation y.
+type elementwise struct {
+	f  func(x float64) float64
+	df func(x, y float64) float64
+
+	input, output *numpy.NDArray
+}
+
+// forward applies the activation and caches the input and output for the backward pass.
+func (e *elementwise) forward(x *numpy.NDArray) (*numpy.NDArray, error) {
+	e.input = x
+	e.output = x.Apply(e.f)
+	return e.output, nil
+}
+
+// backward multiplies grad by the derivative of the activation at the cached input.
+func (e *elementwise) backward(grad *numpy.NDArray) (*numpy.NDArray, error) {
+	if e.input == nil {
+		return nil, fmt.Errorf("backward called before forward")
+	}
+	if e.input.Size() != grad.Size() {
+		return nil, fmt.Errorf("grad has shape %v but the output has shape %v", grad.Shape(), e.output.Shape())
+	}
+	x, y, g := e.input.Data(), e.output.Data(), grad.Data()
+	out := make([]float64, len(g))
+	for i := range out {
+		out[i] = g[i] * e.df(x[i], y[i])
+	}
+	return numpy.NewArray(out, grad.Shape()...)
+}
+
+// ReLU applies the rectified linear unit max(0, x) element-wise.
+type ReLU struct {
+	elementwise
+}
+
+// NewReLU creates a ReLU activation.
+func NewReLU() *ReLU {
+	return &ReLU{elementwise{
+		f: func(x float64) float64 { return math.Max(0, x) },
+		df: func(x, _ float64) float64 {
+			if x > 0 {
+				return 1
+			}
+			return 0
+		},
+	}}
+}
+
+// Forward returns max(0, x).
+func (r *ReLU) Forward(x *numpy.NDArray) (*numpy.NDArray, error) { return r.forward(x) }
+
+// Backward passes the gradient through where the input was positive.
+func (r *ReLU) Backward(grad *numpy.NDArray) (*numpy.NDArray, error) { return r.backward(grad) }
+
+// Parameters returns nil because ReLU has no learnable parameters.
+func (r *ReLU) Parameters() []*Parameter { return nil }
+
+// LeakyReLU applies x for positive inputs and alpha * x otherwise, keeping a small gradient for negative inputs.
+type LeakyReLU struct {
+	elementwise
+	Alpha float64
+}
+
+// NewLeakyReLU creates a LeakyReLU activation with the given negative slope (0.01 is common).
+func NewLeakyReLU(alpha float64) *LeakyReLU {
+	return &LeakyReLU{
+		Alpha: alpha,
+		elementwise: elementwise{
+			f: func(x float64) float64 {
+				if x > 0 {
+					return x
+				}
+				return alpha * x
+			},
+			df: func(x, _ float64) float64 {
+				if x > 0 {
+					return 1
+				}
+				return alpha
+			},
+		},
+	}
+}
+
+// Forward returns x where x > 0 and alpha * x elsewhere.
+func (l *LeakyReLU) Forward(x *numpy.NDArray) (*numpy.NDArray, error) { return l.forward(x) }
+
+// Backward scales the gradient by 1 or alpha depending on the sign of the input.
+func (l *LeakyReLU) Backward(grad *numpy.NDArray) (*numpy.NDArray, error) { return l.backward(grad) }
+
+// Parameters returns nil because LeakyReLU has no learnable parameters.
+func (l *LeakyReLU) Parameters() []*Parameter { return nil }
+
+// Sigmoid applies the logistic function 1 / (1 + exp(-x)) element-wise.
+type Sigmoid struct {
+	elementwise
+}
+
+// NewSigmoid creates a Sigmoid activation.
+func NewSigmoid() *Sigmoid {
+	return &Sigmoid{elementwise{
+		f:  sigmoid,
+		df: func(_, y float64) float64 { return y * (1 - y) },
+	}}
+}
+
+// sigmoid computes 1 / (1 + exp(-x)) without overflowing for large negative x.
+func sigmoid(x float64) float64 {
+	if x >= 0 {
+		return 1 / (1 + math.Exp(-x))
+	}
+	e := math.Exp(x)
+	return e / (1 + e)
+}
+
+// Forward returns 1 / (1 + exp(-x)).
+func (s *Sigmoid) Forward(x *numpy.NDArray) (*numpy.NDArray, error) { return s.forward(x) }
+
+// Backward scales the gradient by y * (1 - y).
+func (s *Sigmoid) Backward(grad *numpy.NDArray) (*numpy.NDArray, error) { return s.backward(grad) }
+
+// Parameters returns nil because Sigmoid has no learnable parameters.
+func (s *Sigmoid) Parameters() []*Parameter { return nil }
+
+// Tanh applies the hyperbolic tangent element-wise.
+type Tanh struct {
+	elementwise
+}
+
+// NewTanh creates a Tanh activation.
+func NewTanh() *Tanh {
+	return &Tanh{elementwise{
+		f:  math.Tanh,
+		df: func(_, y float64) float64 { return 1 - y*y },
+	}}
+}
+
+// Forward returns tanh(x).
+func (t *Tanh) Forward(x *numpy.NDArray) (*numpy.NDArray, error) { return t.forward(x) }
+
+// Backward scales the gradient by 1 - tanh(x)^2.
+func (t *Tanh) Backward(grad *numpy.NDArray) (*numpy.NDArray, error) { return t.backward(grad) }
+
+// Parameters returns nil because Tanh has no learnable parameters.
+func (t *Tanh) Parameters() []*Parameter { return nil }
+
+// GELU applies the Gaussian error linear unit x * Phi(x), where Phi is the standard normal CDF, using the exact erf
+// formulation.
+type GELU struct {
+	elementwise
+}
+
+// NewGELU creates a GELU activation.
+func NewGELU() *GELU {
+	return &GELU{elementwise{
+		f: func(x float64) float64 { return 0.5 * x * (1 + math.Erf(x/math.Sqrt2)) },
+		df: func(x, _ float64) float64 {
+			cdf := 0.5 * (1 + math.Erf(x/math.Sqrt2))
+			pdf := math.Exp(-0.5*x*x) / math.Sqrt(2*math.Pi)
+			return cdf + x*pdf
+		},
+	}}
+}
+
+// Forward returns x * Phi(x).
+func (g *GELU) Forward(x *numpy.NDArray) (*numpy.NDArray, error) { return g.forward(x) }
+
+// Backward scales the gradient by Phi(x) + x * phi(x).
+func (g *GELU) Backward(grad *numpy.NDArray) (*numpy.NDArray, error) { return g.backward(grad) }
+
+// Parameters returns nil because GELU has no learnable parameters.
+func (g *GELU) Parameters() []*Parameter { return nil }
+
+// SELU applies the scaled exponential linear unit lambda * x for positive inputs and lambda * alpha * (exp(x) - 1)
+// otherwise, with the constants of Klambauer et al. (2017) that make deep networks self-normalizing. Use it with
+// LeCun normal initialization and AlphaDropout.
+type SELU struct {
+	elementwise
+}
+
+// Constants of SELU.
+const (
+	seluAlpha  = 1.6732632423543772
+	seluLambda = 1.0507009873554805
+)
+
+// NewSELU creates a SELU activation.
+func NewSELU() *SELU {
+	return &SELU{elementwise{
+		f: func(x float64) float64 {
+			if x > 0 {
+				return seluLambda * x
+			}
+			return seluLambda * seluAlpha * math.Expm1(x)
+		},
+		df: func(x, y float64) float64 {
+			if x > 0 {
+				return seluLambda
+			}
+			return y + seluLambda*seluAlpha
+		},
+	}}
+}
+
+// Forward returns lambda * x where x > 0 and lambda * alpha * (exp(x) - 1) elsewhere.
+func (s *SELU) Forward(x *numpy.NDArray) (*numpy.NDArray, error) { return s.forward(x) }
+
+// Backward scales the gradient by lambda where the input was positive and lambda * alpha * exp(x) elsewhere.
+func (s *SELU) Backward(grad *numpy.NDArray) (*numpy.NDArray, error) { return s.backward(grad) }
+
+// Parameters returns nil because SELU has no learnable parameters.
+func (s *SELU) Parameters() []*Parameter { return nil }
+
+// Softmax normalises the inputs along an axis into probabilities, exp(x_i) / sum_j exp(x_j).
+type Softmax struct {
+	Axis int
+
+	output *numpy.NDArray
+}
+
+// NewSoftmax creates a Softmax activation over the given axis; -1 normalises over the last axis.
+func NewSoftmax(axis int) *Softmax {
+	return &Softmax{Axis: axis}
+}
+
+// Forward returns the softmax of x along the layer's axis.
+//
+// Errors:
+//
+//	Returns an error if the axis is out of bounds for x.
+func (s *Softmax) Forward(x *numpy.NDArray) (*numpy.NDArray, error) {
+	out, err := SoftmaxArray(x, s.Axis)
+	if err != nil {
+		return nil, err
+	}
+	s.output = out
+	return out, nil
+}
+
+// Backward returns y * (grad - sum(grad * y)) along the axis, the product of grad with the softmax Jacobian.
+//
+// Errors:
+//
+//	Returns an error if Forward has not been called or grad does not match the output of Forward.
+func (s *Softmax) Backward(grad *numpy.NDArray) (*numpy.NDArray, error) {
+	if s.output == nil {
+		return nil, fmt.Errorf("backward called before forward")
+	}
+	gy, err := grad.Mul(s.output)
+	if err != nil {
+		return nil, err
+	}
+	dot, err := sumKeepDims(gy, s.Axis)
+	if err != nil {
+		return nil, err
+	}
+	diff, err := grad.Sub(dot)
+	if err != nil {
+		return nil, err
+	}
+	return diff.Mul(s.output)
+}
+
+// Parameters returns nil because Softmax has no learnable parameters.
+func (s *Softmax) Parameters() []*Parameter { return nil }
+
+// SoftmaxArray computes the softmax of x along axis, subtracting the maximum first for numerical stability.
+//
+// Errors:
+//
+//	Returns an error if the axis is out of bounds for x.
+func SoftmaxArray(x *numpy.NDArray, axis int) (*numpy.NDArray, error) {
+	shape := x.Shape()
+	if axis < -len(shape) || axis >= len(shape) {
+		return nil, fmt.Errorf("axis %v is out of bounds for array of dimension %v", axis, len(shape))
+	}
+	if axis < 0 {
+		axis += len(shape)
+	}
+
+	// Work on lanes along the axis by moving it last
+	t, err := x.SwapAxes(axis, -1)
+	if err != nil {
+		return nil, err
+	}
+	values := t.Copy()
+	data := values.Data()
+	n := shape[axis]
+	for start := 0; start < len(data); start += n {
+		lane := data[start : start+n]
+		m := math.Inf(-1)
+		for _, v := range lane {
+			m = math.Max(m, v)
+		}
+		s := 0.
+		for i, v := range lane {
+			lane[i] = math.Exp(v - m)
+			s += lane[i]
+		}
+		for i := range lane {
+			lane[i] /= s
+		}
+	}
+	out, err := values.SwapAxes(axis, -1)
+	if err != nil {
+		return nil, err
+	}
+	return out.AsContiguous(), nil
+}
+
+// sumKeepDims sums x along axis, keeping the axis with length one so the result broadcasts against x.
+func sumKeepDims(x *numpy.NDArray, axis int) (*numpy.NDArray, error) {
+	s, err := numpy.Sum(x, axis)
+	if err != nil {
+		return nil, err
+	}
+	shape := x.Shape()
+	if axis < 0 {
+		axis += len(shape)
+	}
+	shape[axis] = 1
+	return s.Reshape(shape...)
+}
@@ -0,0 +1,292 @@
+package nn
+
+import (
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/timotewb/gonn/numpy"
+)
+
+// MultiHeadAttention implements scaled dot-product attention with several heads (Vaswani et al., 2017), like
+// torch.nn.MultiheadAttention with batch-first inputs of shape (batch, time, embedDim).
+//
+// The query, key and value are projected by the Query, Key and Value layers and split into NumHeads heads of
+// embedDim / NumHeads features. Each head computes softmax(Q @ K^T / sqrt(headDim)) @ V with numpy.Matmul over the
+// batch and head axes, and the concatenated heads are projected by Out.
+//
+// Forward computes self-attention, using its input as query, key and value. Attend and BackwardAttend take separate
+// inputs for cross-attention, as in the decoder of a transformer.
+//
+// Fields:
+//
+//	Query, Key, Value, Out (*Dense): The input and output projections.
+//	NumHeads (int): The number of attention heads.
+//	Causal (bool): Whether each position may only attend to itself and earlier positions, as in a decoder.
+type MultiHeadAttention struct {
+	Query    *Dense
+	Key      *Dense
+	Value    *Dense
+	Out      *Dense
+	NumHeads int
+	Causal   bool
+
+	paddingMask *numpy.NDArray
+	q, k, v     *numpy.NDArray
+	weights     *numpy.NDArray
+}
+
+// NewMultiHeadAttention creates a multi-head attention layer.
+//
+// Parameters:
+//
+//	embedDim (int): The number of features of the inputs and output.
+//	numHeads (int): The number of heads. embedDim must be divisible by it.
+//	bias (bool): Whether the projections learn an additive bias.
+//
+// Returns:
+//
+//	(*MultiHeadAttention, error): The new layer, or nil and an error if the sizes are invalid.
+//
+// Errors:
+//
+//	Returns an error if a size is not positive or embedDim is not divisible by numHeads.
+func NewMultiHeadAttention(embedDim, numHeads int, bias bool) (*MultiHeadAttention, error) {
+	if embedDim <= 0 || numHeads <= 0 || embedDim%numHeads != 0 {
+		return nil, fmt.Errorf("embedDim (%v) must be positive and divisible by numHeads (%v)", embedDim, numHeads)
+	}
+	m := &MultiHeadAttention{NumHeads: numHeads}
+	for _, d := range []**Dense{&m.Query, &m.Key, &m.Value, &m.Out} {
+		l, err := NewDense(embedDim, embedDim, bias)
+		if err != nil {
+			return nil, err
+		}
+		*d = l
+	}
+	return m, nil
+}
+
+// SetPaddingMask sets the key positions to ignore in the following calls, like key_padding_mask in PyTorch. mask
+// has shape (batch, keyTime) and is nonzero at padding positions. nil removes the mask.
+func (m *MultiHeadAttention) SetPaddingMask(mask *numpy.NDArray) {
+	m.paddingMask = mask
+}
+
+// AttentionWeights returns the attention probabilities of the last call, of shape (batch, heads, queryTime,
+// keyTime), or nil before the first call.
+func (m *MultiHeadAttention) AttentionWeights() *numpy.NDArray {
+	return m.weights
+}
+
+// splitHeads reshapes (batch, time, embedDim) to (batch, heads, time, headDim).
+func (m *MultiHeadAttention) splitHeads(x *numpy.NDArray) (*numpy.NDArray, error) {
+	s := x.Shape()
+	r, err := x.Reshape(s[0], s[1], m.NumHeads, s[2]/m.NumHeads)
+	if err != nil {
+		return nil, err
+	}
+	return r.Transpose(0, 2, 1, 3)
+}
+
+// mergeHeads reshapes (batch, heads, time, headDim) back to (batch, time, embedDim).
+func mergeHeads(x *numpy.NDArray) (*numpy.NDArray, error) {
+	s := x.Shape()
+	t, err := x.Transpose(0, 2, 1, 3)
+	if err != nil {
+		return nil, err
+	}
+	return t.Reshape(s[0], s[2], s[1]*s[3])
+}
+
+// Forward computes self-attention over x of shape (batch, time, embedDim).
+//
+// Errors:
+//
+//	Returns an error if x does not have shape (batch, time, embedDim) or the padding mask does not match it.
+func (m *MultiHeadAttention) Forward(x *numpy.NDArray) (*numpy.NDArray, error) {
+	return m.Attend(x, x, x)
+}
+
+// Backward returns the gradient with respect to the input of Forward, which is the sum of the gradients through the
+// query, key and value.
+//
+// Errors:
+//
+//	Returns an error if Forward has not been called or grad does not match the output of Forward.
+func (m *MultiHeadAttention) Backward(grad *numpy.NDArray) (*numpy.NDArray, error) {
+	dq, dk, dv, err := m.BackwardAttend(grad)
+	if err != nil {
+		return nil, err
+	}
+	s, err := dq.Add(dk)
+	if err != nil {
+		return nil, err
+	}
+	return s.Add(dv)
+}
+
+// Attend computes attention of query over key and value.
+//
+// Parameters:
+//
+//	query (*numpy.NDArray): The queries, of shape (batch, queryTime, embedDim).
+//	key, value (*numpy.NDArray): The keys and values, of shape (batch, keyTime, embedDim).
+//
+// Returns:
+//
+//	(*numpy.NDArray, error): The output, of shape (batch, queryTime, embedDim).
+//
+// Errors:
+//
+//	Returns an error if the shapes do not match or the padding mask does not have shape (batch, keyTime).
+func (m *MultiHeadAttention) Attend(query, key, value *numpy.NDArray) (*numpy.NDArray, error) {
+	qs, ks := query.Shape(), key.Shape()
+	if len(qs) != 3 || len(ks) != 3 || qs[0] != ks[0] || !slices.Equal(ks, value.Shape()) {
+		return nil, fmt.Errorf("expected query of shape (batch, time, embed) and key and value of the same shape, got %v, %v and %v", qs, ks, value.Shape())
+	}
+	batches, lq, lk := qs[0], qs[1], ks[1]
+	var padding []float64
+	if m.paddingMask != nil {
+		if err := checkShape(m.paddingMask, []int{batches, lk}); err != nil {
+			return nil, fmt.Errorf("padding mask: %v", err)
+		}
+		padding = m.paddingMask.Data()
+	}
+
+	var err error
+	heads := make([]*numpy.NDArray, 3)
+	for i, p := range []struct {
+		l *Dense
+		x *numpy.NDArray
+	}{{m.Query, query}, {m.Key, key}, {m.Value, value}} {
+		y, err := p.l.Forward(p.x)
+		if err != nil {
+			return nil, err
+		}
+		if heads[i], err = m.splitHeads(y); err != nil {
+			return nil, err
+		}
+	}
+	m.q, m.k, m.v = heads[0], heads[1], heads[2]
+
+	kT, _ := m.k.SwapAxes(-1, -2)
+	scores, err := numpy.Matmul(m.q, kT)
+	if err != nil {
+		return nil, err
+	}
+	scores = scores.Scale(1 / math.Sqrt(float64(m.q.Shape()[3])))
+	sd := scores.Data()
+	for b := 0; b < batches; b++ {
+		for h := 0; h < m.NumHeads; h++ {
+			for i := 0; i < lq; i++ {
+				row := sd[((b*m.NumHeads+h)*lq+i)*lk:]
+				for j := 0; j < lk; j++ {
+					if (m.Causal && j > i) || (padding != nil && padding[b*lk+j] != 0) {
+						row[j] = math.Inf(-1)
+					}
+				}
+			}
+		}
+	}
+	if m.weights, err = SoftmaxArray(scores, -1); err != nil {
+		return nil, err
+	}
+
+	// Rows where every key is masked attend to nothing instead of producing NaN
+	wd := m.weights.Data()
+	for i, w := range wd {
+		if math.IsNaN(w) {
+			wd[i] = 0
+		}
+	}
+	ctx, err := numpy.Matmul(m.weights, m.v)
+	if err != nil {
+		return nil, err
+	}
+	merged, err := mergeHeads(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return m.Out.Forward(merged)
+}
+
+// BackwardAttend accumulates the gradients of the projections and returns the gradients with respect to the query,
+// key and value of the last Attend call.
+//
+// Errors:
+//
+//	Returns an error if Attend has not been called or grad does not match its output.
+func (m *MultiHeadAttention) BackwardAttend(grad *numpy.NDArray) (*numpy.NDArray, *numpy.NDArray, *numpy.NDArray, error) {
+	if m.weights == nil {
+		return nil, nil, nil, fmt.Errorf("backward called before forward")
+	}
+	dMerged, err := m.Out.Backward(grad)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	dCtx, err := m.splitHeads(dMerged)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// ctx = A @ V, so dA = dCtx @ V^T and dV = A^T @ dCtx
+	vT, _ := m.v.SwapAxes(-1, -2)
+	aT, _ := m.weights.SwapAxes(-1, -2)
+	dA, err := numpy.Matmul(dCtx, vT)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	dV, err := numpy.Matmul(aT, dCtx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Softmax backward along the key axis, then the 1 / sqrt(headDim) scale
+	ad, dad := m.weights.Data(), dA.Data()
+	lk := m.weights.Shape()[3]
+	scale := 1 / math.Sqrt(float64(m.q.Shape()[3]))
+	dS := make([]float64, len(ad))
+	for r := 0; r < len(ad); r += lk {
+		dot := 0.
+		for j := r; j < r+lk; j++ {
+			dot += dad[j] * ad[j]
+		}
+		for j := r; j < r+lk; j++ {
+			dS[j] = ad[j] * (dad[j] - dot) * scale
+		}
+	}
+	dSA, _ := numpy.NewArray(dS, m.weights.Shape()...)
+	dSAT, _ := dSA.SwapAxes(-1, -2)
+	dQ, err := numpy.Matmul(dSA, m.k)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	dK, err := numpy.Matmul(dSAT, m.q)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	grads := make([]*numpy.NDArray, 3)
+	for i, p := range []struct {
+		l *Dense
+		g *numpy.NDArray
+	}{{m.Query, dQ}, {m.Key, dK}, {m.Value, dV}} {
+		merged, err := mergeHeads(p.g)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if grads[i], err = p.l.Backward(merged); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	return grads[0], grads[1], grads[2], nil
+}
+
+// Parameters returns the parameters of the query, key, value and output projections.
+func (m *MultiHeadAttention) Parameters() []*Parameter {
+	params := []*Parameter{}
+	for _, d := range []*Dense{m.Query, m.Key, m.Value, m.Out} {
+		params = append(params, d.Parameters()...)
+	}
+	return params
+}
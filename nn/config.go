@@ -0,0 +1,652 @@
+package nn
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// LayerConfig describes the architecture of a layer without its parameter values, so a network can be saved as
+// JSON and rebuilt with FromConfig, like the configs of tf.keras layers.
+//
+// Fields:
+//
+//	Type (string): The name the layer type is registered under, for example "Dense".
+//	Config (json.RawMessage): The arguments needed to construct the layer, such as its sizes.
+//	Layers ([]*LayerConfig): The layers of a Sequential container, in order.
+type LayerConfig struct {
+	Type   string          `json:"type"`
+	Config json.RawMessage `json:"config,omitempty"`
+	Layers []*LayerConfig  `json:"layers,omitempty"`
+}
+
+// layerCodec converts a registered layer type to and from its configuration.
+type layerCodec struct {
+	config func(l Layer) (interface{}, error)
+	build  func(config json.RawMessage) (Layer, error)
+}
+
+var (
+	layerCodecs = map[string]layerCodec{}
+	layerNames  = map[reflect.Type]string{}
+)
+
+// RegisterLayer makes a layer type available to GetConfig and FromConfig, so networks containing custom layers can
+// be saved and loaded. The layers of this package are registered under the names of their types.
+//
+// Parameters:
+//
+//	name (string): The name stored in LayerConfig.Type. Registering an existing name replaces it.
+//	layer (Layer): A value of the layer type, used only for its type.
+//	config (func(Layer) (interface{}, error)): Returns a JSON-encodable value holding the arguments of the layer.
+//	build (func(json.RawMessage) (Layer, error)): Constructs a new layer from the encoded arguments.
+func RegisterLayer(name string, layer Layer, config func(l Layer) (interface{}, error), build func(config json.RawMessage) (Layer, error)) {
+	layerCodecs[name] = layerCodec{config: config, build: build}
+	layerNames[reflect.TypeOf(layer)] = name
+}
+
+// GetConfig returns the configuration of a layer and, for Sequential, of the layers it contains.
+//
+// Errors:
+//
+//	Returns an error if the type of a layer is not registered.
+func GetConfig(l Layer) (*LayerConfig, error) {
+	if s, ok := l.(*Sequential); ok {
+		c := &LayerConfig{Type: "Sequential"}
+		for i, layer := range s.Layers {
+			lc, err := GetConfig(layer)
+			if err != nil {
+				return nil, fmt.Errorf("layer %v: %v", i, err)
+			}
+			c.Layers = append(c.Layers, lc)
+		}
+		return c, nil
+	}
+	name, ok := layerNames[reflect.TypeOf(l)]
+	if !ok {
+		return nil, fmt.Errorf("layer type %T is not registered, see RegisterLayer", l)
+	}
+	v, err := layerCodecs[name].config(l)
+	if err != nil {
+		return nil, err
+	}
+	c := &LayerConfig{Type: name}
+	if v != nil {
+		if c.Config, err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// FromConfig constructs a new layer from a configuration returned by GetConfig. The parameters of the new layer are
+// freshly initialized; saved values are loaded separately.
+//
+// Errors:
+//
+//	Returns an error if a type is not registered or its configuration is invalid.
+func FromConfig(c *LayerConfig) (Layer, error) {
+	if c.Type == "Sequential" {
+		s := NewSequential()
+		for i, lc := range c.Layers {
+			l, err := FromConfig(lc)
+			if err != nil {
+				return nil, fmt.Errorf("layer %v: %v", i, err)
+			}
+			s.Add(l)
+		}
+		return s, nil
+	}
+	codec, ok := layerCodecs[c.Type]
+	if !ok {
+		return nil, fmt.Errorf("layer type %q is not registered, see RegisterLayer", c.Type)
+	}
+	l, err := codec.build(c.Config)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", c.Type, err)
+	}
+	return l, nil
+}
+
+// decodeConfig unmarshals a configuration, treating a missing one as empty.
+func decodeConfig(data json.RawMessage, v interface{}) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, v)
+}
+
+// registerStateless registers a layer without arguments.
+func registerStateless(name string, layer Layer, build func() Layer) {
+	RegisterLayer(name, layer,
+		func(l Layer) (interface{}, error) { return nil, nil },
+		func(json.RawMessage) (Layer, error) { return build(), nil })
+}
+
+type denseConfig struct {
+	InFeatures  int  `json:"in_features"`
+	OutFeatures int  `json:"out_features"`
+	Bias        bool `json:"bias"`
+}
+
+type conv2DConfig struct {
+	InChannels  int    `json:"in_channels"`
+	OutChannels int    `json:"out_channels"`
+	KernelSize  [2]int `json:"kernel_size"`
+	Stride      [2]int `json:"stride"`
+	Padding     [2]int `json:"padding"`
+	SamePadding bool   `json:"same_padding"`
+	Dilation    [2]int `json:"dilation"`
+	Groups      int    `json:"groups"`
+	Bias        bool   `json:"bias"`
+}
+
+type conv1DConfig struct {
+	InChannels  int  `json:"in_channels"`
+	OutChannels int  `json:"out_channels"`
+	KernelSize  int  `json:"kernel_size"`
+	Stride      int  `json:"stride"`
+	Padding     int  `json:"padding"`
+	SamePadding bool `json:"same_padding"`
+	Dilation    int  `json:"dilation"`
+	Groups      int  `json:"groups"`
+	Bias        bool `json:"bias"`
+}
+
+type pool2DConfig struct {
+	KernelSize [2]int `json:"kernel_size"`
+	Stride     [2]int `json:"stride"`
+	Padding    [2]int `json:"padding"`
+}
+
+type pool1DConfig struct {
+	KernelSize int `json:"kernel_size"`
+	Stride     int `json:"stride"`
+	Padding    int `json:"padding"`
+}
+
+type layerNormConfig struct {
+	NormalizedShape   []int   `json:"normalized_shape"`
+	Eps               float64 `json:"eps"`
+	ElementwiseAffine bool    `json:"elementwise_affine"`
+}
+
+type batchNormConfig struct {
+	NumFeatures       int     `json:"num_features"`
+	Eps               float64 `json:"eps"`
+	Momentum          float64 `json:"momentum"`
+	Affine            bool    `json:"affine"`
+	TrackRunningStats bool    `json:"track_running_stats"`
+}
+
+type groupNormConfig struct {
+	NumGroups   int     `json:"num_groups"`
+	NumChannels int     `json:"num_channels"`
+	Eps         float64 `json:"eps"`
+	Affine      bool    `json:"affine"`
+}
+
+type embeddingConfig struct {
+	NumEmbeddings int      `json:"num_embeddings"`
+	EmbeddingDim  int      `json:"embedding_dim"`
+	PaddingIdx    *int     `json:"padding_idx,omitempty"`
+	MaxNorm       float64  `json:"max_norm,omitempty"`
+	NormType      *float64 `json:"norm_type,omitempty"`
+	Sparse        bool     `json:"sparse,omitempty"`
+}
+
+type positionalConfig struct {
+	MaxLen int `json:"max_len"`
+	DModel int `json:"d_model"`
+}
+
+type recurrentConfig struct {
+	InputSize       int    `json:"input_size"`
+	HiddenSize      int    `json:"hidden_size"`
+	NumLayers       int    `json:"num_layers"`
+	Nonlinearity    string `json:"nonlinearity,omitempty"`
+	Bidirectional   bool   `json:"bidirectional"`
+	BatchFirst      bool   `json:"batch_first"`
+	ReturnSequences bool   `json:"return_sequences"`
+	Stateful        bool   `json:"stateful"`
+	BPTTSteps       int    `json:"bptt_steps"`
+}
+
+type attentionConfig struct {
+	EmbedDim int  `json:"embed_dim"`
+	NumHeads int  `json:"num_heads"`
+	Bias     bool `json:"bias"`
+	Causal   bool `json:"causal"`
+}
+
+type transformerConfig struct {
+	DModel         int     `json:"d_model"`
+	NumHeads       int     `json:"num_heads"`
+	DimFeedforward int     `json:"dim_feedforward"`
+	Dropout        float64 `json:"dropout"`
+	Activation     string  `json:"activation"`
+	NormFirst      bool    `json:"norm_first"`
+}
+
+// recurrentConfigOf returns the configuration shared by RNN, GRU and LSTM.
+func recurrentConfigOf(r *recurrent) recurrentConfig {
+	c := recurrentConfig{
+		InputSize:       r.InputSize,
+		HiddenSize:      r.HiddenSize,
+		NumLayers:       r.NumLayers,
+		Bidirectional:   r.Bidirectional,
+		BatchFirst:      r.BatchFirst,
+		ReturnSequences: r.ReturnSequences,
+		Stateful:        r.Stateful,
+		BPTTSteps:       r.BPTTSteps,
+	}
+	if cell, ok := r.cell.(*rnnCell); ok {
+		c.Nonlinearity = "tanh"
+		if cell.relu {
+			c.Nonlinearity = "relu"
+		}
+	}
+	return c
+}
+
+// buildRecurrent decodes a recurrentConfig, calls newLayer with it and applies the options set after construction.
+func buildRecurrent(data json.RawMessage, newLayer func(c recurrentConfig) (Layer, *recurrent, error)) (Layer, error) {
+	c := recurrentConfig{}
+	if err := decodeConfig(data, &c); err != nil {
+		return nil, err
+	}
+	l, r, err := newLayer(c)
+	if err != nil {
+		return nil, err
+	}
+	r.ReturnSequences, r.Stateful, r.BPTTSteps = c.ReturnSequences, c.Stateful, c.BPTTSteps
+	return l, nil
+}
+
+// transformerConfigOf recovers the constructor arguments of a transformer layer from its sublayers.
+func transformerConfigOf(attn *MultiHeadAttention, ff *Sequential, block *residual) transformerConfig {
+	activation := "relu"
+	if _, ok := ff.Layers[1].(*GELU); ok {
+		activation = "gelu"
+	}
+	return transformerConfig{
+		DModel:         attn.Query.Weight.Value.Shape()[0],
+		NumHeads:       attn.NumHeads,
+		DimFeedforward: ff.Layers[0].(*Dense).Weight.Value.Shape()[1],
+		Dropout:        block.dropout.P,
+		Activation:     activation,
+		NormFirst:      block.normFirst,
+	}
+}
+
+func init() {
+	registerStateless("ReLU", &ReLU{}, func() Layer { return NewReLU() })
+	registerStateless("Sigmoid", &Sigmoid{}, func() Layer { return NewSigmoid() })
+	registerStateless("Tanh", &Tanh{}, func() Layer { return NewTanh() })
+	registerStateless("GELU", &GELU{}, func() Layer { return NewGELU() })
+	registerStateless("SELU", &SELU{}, func() Layer { return NewSELU() })
+	registerStateless("Flatten", &Flatten{}, func() Layer { return NewFlatten() })
+	registerStateless("GlobalAvgPool", &GlobalAvgPool{}, func() Layer { return NewGlobalAvgPool() })
+	registerStateless("GlobalMaxPool", &GlobalMaxPool{}, func() Layer { return NewGlobalMaxPool() })
+
+	RegisterLayer("LeakyReLU", &LeakyReLU{},
+		func(l Layer) (interface{}, error) { return map[string]float64{"alpha": l.(*LeakyReLU).Alpha}, nil },
+		func(data json.RawMessage) (Layer, error) {
+			c := map[string]float64{}
+			if err := decodeConfig(data, &c); err != nil {
+				return nil, err
+			}
+			return NewLeakyReLU(c["alpha"]), nil
+		})
+	RegisterLayer("Softmax", &Softmax{},
+		func(l Layer) (interface{}, error) { return map[string]int{"axis": l.(*Softmax).Axis}, nil },
+		func(data json.RawMessage) (Layer, error) {
+			c := map[string]int{}
+			if err := decodeConfig(data, &c); err != nil {
+				return nil, err
+			}
+			return NewSoftmax(c["axis"]), nil
+		})
+	RegisterLayer("Dropout", &Dropout{},
+		func(l Layer) (interface{}, error) { return map[string]float64{"p": l.(*Dropout).P}, nil },
+		func(data json.RawMessage) (Layer, error) {
+			c := map[string]float64{}
+			if err := decodeConfig(data, &c); err != nil {
+				return nil, err
+			}
+			return NewDropout(c["p"])
+		})
+	RegisterLayer("SpatialDropout", &SpatialDropout{},
+		func(l Layer) (interface{}, error) { return map[string]float64{"p": l.(*SpatialDropout).P}, nil },
+		func(data json.RawMessage) (Layer, error) {
+			c := map[string]float64{}
+			if err := decodeConfig(data, &c); err != nil {
+				return nil, err
+			}
+			return NewSpatialDropout(c["p"])
+		})
+	RegisterLayer("AlphaDropout", &AlphaDropout{},
+		func(l Layer) (interface{}, error) { return map[string]float64{"p": l.(*AlphaDropout).P}, nil },
+		func(data json.RawMessage) (Layer, error) {
+			c := map[string]float64{}
+			if err := decodeConfig(data, &c); err != nil {
+				return nil, err
+			}
+			return NewAlphaDropout(c["p"])
+		})
+
+	RegisterLayer("Dense", &Dense{},
+		func(l Layer) (interface{}, error) {
+			d := l.(*Dense)
+			s := d.Weight.Value.Shape()
+			return denseConfig{InFeatures: s[0], OutFeatures: s[1], Bias: d.Bias != nil}, nil
+		},
+		func(data json.RawMessage) (Layer, error) {
+			c := denseConfig{}
+			if err := decodeConfig(data, &c); err != nil {
+				return nil, err
+			}
+			return NewDense(c.InFeatures, c.OutFeatures, c.Bias)
+		})
+
+	RegisterLayer("Conv2D", &Conv2D{},
+		func(l Layer) (interface{}, error) {
+			conv := l.(*Conv2D)
+			s := conv.Weight.Value.Shape()
+			return conv2DConfig{
+				InChannels:  s[1] * conv.Groups,
+				OutChannels: s[0],
+				KernelSize:  [2]int{s[2], s[3]},
+				Stride:      conv.Stride,
+				Padding:     conv.Padding,
+				SamePadding: conv.SamePadding,
+				Dilation:    conv.Dilation,
+				Groups:      conv.Groups,
+				Bias:        conv.Bias != nil,
+			}, nil
+		},
+		func(data json.RawMessage) (Layer, error) {
+			c := conv2DConfig{}
+			if err := decodeConfig(data, &c); err != nil {
+				return nil, err
+			}
+			var padding interface{} = c.Padding
+			if c.SamePadding {
+				padding = "same"
+			}
+			return NewConv2D(c.InChannels, c.OutChannels, c.KernelSize, c.Stride, padding, c.Dilation, c.Groups, c.Bias)
+		})
+	RegisterLayer("Conv1D", &Conv1D{},
+		func(l Layer) (interface{}, error) {
+			conv := l.(*Conv1D)
+			s := conv.Weight.Value.Shape()
+			return conv1DConfig{
+				InChannels:  s[1] * conv.Groups,
+				OutChannels: s[0],
+				KernelSize:  s[3],
+				Stride:      conv.Stride[1],
+				Padding:     conv.Padding[1],
+				SamePadding: conv.SamePadding,
+				Dilation:    conv.Dilation[1],
+				Groups:      conv.Groups,
+				Bias:        conv.Bias != nil,
+			}, nil
+		},
+		func(data json.RawMessage) (Layer, error) {
+			c := conv1DConfig{}
+			if err := decodeConfig(data, &c); err != nil {
+				return nil, err
+			}
+			var padding interface{} = c.Padding
+			if c.SamePadding {
+				padding = "same"
+			}
+			return NewConv1D(c.InChannels, c.OutChannels, c.KernelSize, c.Stride, padding, c.Dilation, c.Groups, c.Bias)
+		})
+
+	pool2DConfigOf := func(p *pool2d) pool2DConfig {
+		return pool2DConfig{KernelSize: p.KernelSize, Stride: p.Stride, Padding: p.Padding}
+	}
+	RegisterLayer("MaxPool2D", &MaxPool2D{},
+		func(l Layer) (interface{}, error) { return pool2DConfigOf(&l.(*MaxPool2D).pool2d), nil },
+		func(data json.RawMessage) (Layer, error) {
+			c := pool2DConfig{}
+			if err := decodeConfig(data, &c); err != nil {
+				return nil, err
+			}
+			return NewMaxPool2D(c.KernelSize, c.Stride, c.Padding)
+		})
+	RegisterLayer("AvgPool2D", &AvgPool2D{},
+		func(l Layer) (interface{}, error) { return pool2DConfigOf(&l.(*AvgPool2D).pool2d), nil },
+		func(data json.RawMessage) (Layer, error) {
+			c := pool2DConfig{}
+			if err := decodeConfig(data, &c); err != nil {
+				return nil, err
+			}
+			return NewAvgPool2D(c.KernelSize, c.Stride, c.Padding)
+		})
+	pool1DConfigOf := func(p *pool2d) pool1DConfig {
+		return pool1DConfig{KernelSize: p.KernelSize[1], Stride: p.Stride[1], Padding: p.Padding[1]}
+	}
+	RegisterLayer("MaxPool1D", &MaxPool1D{},
+		func(l Layer) (interface{}, error) {
+			return pool1DConfigOf(&l.(*MaxPool1D).layer.(*MaxPool2D).pool2d), nil
+		},
+		func(data json.RawMessage) (Layer, error) {
+			c := pool1DConfig{}
+			if err := decodeConfig(data, &c); err != nil {
+				return nil, err
+			}
+			return NewMaxPool1D(c.KernelSize, c.Stride, c.Padding)
+		})
+	RegisterLayer("AvgPool1D", &AvgPool1D{},
+		func(l Layer) (interface{}, error) {
+			return pool1DConfigOf(&l.(*AvgPool1D).layer.(*AvgPool2D).pool2d), nil
+		},
+		func(data json.RawMessage) (Layer, error) {
+			c := pool1DConfig{}
+			if err := decodeConfig(data, &c); err != nil {
+				return nil, err
+			}
+			return NewAvgPool1D(c.KernelSize, c.Stride, c.Padding)
+		})
+
+	RegisterLayer("LayerNorm", &LayerNorm{},
+		func(l Layer) (interface{}, error) {
+			n := l.(*LayerNorm)
+			return layerNormConfig{NormalizedShape: n.normalizedShape, Eps: n.Eps, ElementwiseAffine: n.Weight != nil}, nil
+		},
+		func(data json.RawMessage) (Layer, error) {
+			c := layerNormConfig{}
+			if err := decodeConfig(data, &c); err != nil {
+				return nil, err
+			}
+			return NewLayerNorm(c.NormalizedShape, c.Eps, c.ElementwiseAffine)
+		})
+	batchNormConfigOf := func(n *channelNorm, r *runningStats) batchNormConfig {
+		return batchNormConfig{
+			NumFeatures:       n.channels,
+			Eps:               n.Eps,
+			Momentum:          r.Momentum,
+			Affine:            n.Weight != nil,
+			TrackRunningStats: r.RunningMean != nil,
+		}
+	}
+	RegisterLayer("BatchNorm", &BatchNorm{},
+		func(l Layer) (interface{}, error) {
+			n := l.(*BatchNorm)
+			return batchNormConfigOf(&n.channelNorm, &n.runningStats), nil
+		},
+		func(data json.RawMessage) (Layer, error) {
+			c := batchNormConfig{}
+			if err := decodeConfig(data, &c); err != nil {
+				return nil, err
+			}
+			return NewBatchNorm(c.NumFeatures, c.Eps, c.Momentum, c.Affine, c.TrackRunningStats)
+		})
+	RegisterLayer("InstanceNorm", &InstanceNorm{},
+		func(l Layer) (interface{}, error) {
+			n := l.(*InstanceNorm)
+			return batchNormConfigOf(&n.channelNorm, &n.runningStats), nil
+		},
+		func(data json.RawMessage) (Layer, error) {
+			c := batchNormConfig{}
+			if err := decodeConfig(data, &c); err != nil {
+				return nil, err
+			}
+			return NewInstanceNorm(c.NumFeatures, c.Eps, c.Momentum, c.Affine, c.TrackRunningStats)
+		})
+	RegisterLayer("GroupNorm", &GroupNorm{},
+		func(l Layer) (interface{}, error) {
+			n := l.(*GroupNorm)
+			return groupNormConfig{NumGroups: n.NumGroups, NumChannels: n.channels, Eps: n.Eps, Affine: n.Weight != nil}, nil
+		},
+		func(data json.RawMessage) (Layer, error) {
+			c := groupNormConfig{}
+			if err := decodeConfig(data, &c); err != nil {
+				return nil, err
+			}
+			return NewGroupNorm(c.NumGroups, c.NumChannels, c.Eps, c.Affine)
+		})
+
+	RegisterLayer("Embedding", &Embedding{},
+		func(l Layer) (interface{}, error) {
+			e := l.(*Embedding)
+			s := e.Weight.Value.Shape()
+			c := embeddingConfig{NumEmbeddings: s[0], EmbeddingDim: s[1], MaxNorm: e.MaxNorm, Sparse: e.Sparse}
+			if e.paddingIdx >= 0 {
+				idx := e.paddingIdx
+				c.PaddingIdx = &idx
+			}
+			if e.NormType != 2 {
+				p := e.NormType
+				c.NormType = &p
+			}
+			return c, nil
+		},
+		func(data json.RawMessage) (Layer, error) {
+			c := embeddingConfig{}
+			if err := decodeConfig(data, &c); err != nil {
+				return nil, err
+			}
+			e, err := NewEmbedding(c.NumEmbeddings, c.EmbeddingDim)
+			if err != nil {
+				return nil, err
+			}
+			e.MaxNorm, e.Sparse = c.MaxNorm, c.Sparse
+			if c.NormType != nil {
+				e.NormType = *c.NormType
+			}
+			if c.PaddingIdx != nil {
+				if err := e.SetPaddingIdx(*c.PaddingIdx); err != nil {
+					return nil, err
+				}
+			}
+			return e, nil
+		})
+	RegisterLayer("SinusoidalPositionalEncoding", &SinusoidalPositionalEncoding{},
+		func(l Layer) (interface{}, error) {
+			s := l.(*SinusoidalPositionalEncoding).encoding.Shape()
+			return positionalConfig{MaxLen: s[0], DModel: s[1]}, nil
+		},
+		func(data json.RawMessage) (Layer, error) {
+			c := positionalConfig{}
+			if err := decodeConfig(data, &c); err != nil {
+				return nil, err
+			}
+			return NewSinusoidalPositionalEncoding(c.MaxLen, c.DModel)
+		})
+	RegisterLayer("LearnedPositionalEncoding", &LearnedPositionalEncoding{},
+		func(l Layer) (interface{}, error) {
+			s := l.(*LearnedPositionalEncoding).Weight.Value.Shape()
+			return positionalConfig{MaxLen: s[0], DModel: s[1]}, nil
+		},
+		func(data json.RawMessage) (Layer, error) {
+			c := positionalConfig{}
+			if err := decodeConfig(data, &c); err != nil {
+				return nil, err
+			}
+			return NewLearnedPositionalEncoding(c.MaxLen, c.DModel)
+		})
+
+	RegisterLayer("RNN", &RNN{},
+		func(l Layer) (interface{}, error) { return recurrentConfigOf(&l.(*RNN).recurrent), nil },
+		func(data json.RawMessage) (Layer, error) {
+			return buildRecurrent(data, func(c recurrentConfig) (Layer, *recurrent, error) {
+				r, err := NewRNN(c.InputSize, c.HiddenSize, c.NumLayers, c.Nonlinearity, c.Bidirectional, c.BatchFirst)
+				if err != nil {
+					return nil, nil, err
+				}
+				return r, &r.recurrent, nil
+			})
+		})
+	RegisterLayer("GRU", &GRU{},
+		func(l Layer) (interface{}, error) { return recurrentConfigOf(&l.(*GRU).recurrent), nil },
+		func(data json.RawMessage) (Layer, error) {
+			return buildRecurrent(data, func(c recurrentConfig) (Layer, *recurrent, error) {
+				r, err := NewGRU(c.InputSize, c.HiddenSize, c.NumLayers, c.Bidirectional, c.BatchFirst)
+				if err != nil {
+					return nil, nil, err
+				}
+				return r, &r.recurrent, nil
+			})
+		})
+	RegisterLayer("LSTM", &LSTM{},
+		func(l Layer) (interface{}, error) { return recurrentConfigOf(&l.(*LSTM).recurrent), nil },
+		func(data json.RawMessage) (Layer, error) {
+			return buildRecurrent(data, func(c recurrentConfig) (Layer, *recurrent, error) {
+				r, err := NewLSTM(c.InputSize, c.HiddenSize, c.NumLayers, c.Bidirectional, c.BatchFirst)
+				if err != nil {
+					return nil, nil, err
+				}
+				return r, &r.recurrent, nil
+			})
+		})
+
+	RegisterLayer("MultiHeadAttention", &MultiHeadAttention{},
+		func(l Layer) (interface{}, error) {
+			m := l.(*MultiHeadAttention)
+			return attentionConfig{
+				EmbedDim: m.Query.Weight.Value.Shape()[0],
+				NumHeads: m.NumHeads,
+				Bias:     m.Query.Bias != nil,
+				Causal:   m.Causal,
+			}, nil
+		},
+		func(data json.RawMessage) (Layer, error) {
+			c := attentionConfig{}
+			if err := decodeConfig(data, &c); err != nil {
+				return nil, err
+			}
+			m, err := NewMultiHeadAttention(c.EmbedDim, c.NumHeads, c.Bias)
+			if err != nil {
+				return nil, err
+			}
+			m.Causal = c.Causal
+			return m, nil
+		})
+	RegisterLayer("TransformerEncoderLayer", &TransformerEncoderLayer{},
+		func(l Layer) (interface{}, error) {
+			t := l.(*TransformerEncoderLayer)
+			return transformerConfigOf(t.SelfAttention, t.FeedForward, t.attnBlock), nil
+		},
+		func(data json.RawMessage) (Layer, error) {
+			c := transformerConfig{}
+			if err := decodeConfig(data, &c); err != nil {
+				return nil, err
+			}
+			return NewTransformerEncoderLayer(c.DModel, c.NumHeads, c.DimFeedforward, c.Dropout, c.Activation, c.NormFirst)
+		})
+	RegisterLayer("TransformerDecoderLayer", &TransformerDecoderLayer{},
+		func(l Layer) (interface{}, error) {
+			t := l.(*TransformerDecoderLayer)
+			return transformerConfigOf(t.SelfAttention, t.FeedForward, t.selfBlock), nil
+		},
+		func(data json.RawMessage) (Layer, error) {
+			c := transformerConfig{}
+			if err := decodeConfig(data, &c); err != nil {
+				return nil, err
+			}
+			return NewTransformerDecoderLayer(c.DModel, c.NumHeads, c.DimFeedforward, c.Dropout, c.Activation, c.NormFirst)
+		})
+}
@@ -0,0 +1,477 @@
+package nn
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/timotewb/gonn/numpy"
+	"github.com/timotewb/gonn/numpy/random"
+)
+
+// pair converts an int or a slice of two ints to a pair of ints, used for the kernel size, stride, padding and
+// dilation of 2-D layers.
+func pair(v interface{}, name string) ([2]int, error) {
+	switch t := v.(type) {
+	case int:
+		return [2]int{t, t}, nil
+	case [2]int:
+		return t, nil
+	case []int:
+		if len(t) == 2 {
+			return [2]int{t[0], t[1]}, nil
+		}
+	}
+	return [2]int{}, fmt.Errorf("%v must be an int or a pair of ints, got %v", name, v)
+}
+
+// positivePair is like pair but also checks that both values are positive.
+func positivePair(v interface{}, name string) ([2]int, error) {
+	p, err := pair(v, name)
+	if err != nil {
+		return p, err
+	}
+	if p[0] <= 0 || p[1] <= 0 {
+		return p, fmt.Errorf("%v must be positive, got %v", name, v)
+	}
+	return p, nil
+}
+
+// convGeometry describes a 2-D sliding window over an input of shape (n, c, h, w). pad holds the padding before and
+// after each spatial axis as (top, bottom, left, right).
+type convGeometry struct {
+	n, c, h, w     int
+	kh, kw         int
+	sh, sw         int
+	dh, dw         int
+	pad            [4]int
+	outH, outW     int
+	rows, colCount int
+}
+
+// newConvGeometry computes the output size of a sliding window.
+func newConvGeometry(shape []int, kernel, stride, dilation [2]int, pad [4]int) (convGeometry, error) {
+	g := convGeometry{
+		n: shape[0], c: shape[1], h: shape[2], w: shape[3],
+		kh: kernel[0], kw: kernel[1],
+		sh: stride[0], sw: stride[1],
+		dh: dilation[0], dw: dilation[1],
+		pad: pad,
+	}
+	g.outH = (g.h+pad[0]+pad[1]-g.dh*(g.kh-1)-1)/g.sh + 1
+	g.outW = (g.w+pad[2]+pad[3]-g.dw*(g.kw-1)-1)/g.sw + 1
+	if g.outH <= 0 || g.outW <= 0 {
+		return g, fmt.Errorf("input of spatial size (%v, %v) is too small for the kernel", g.h, g.w)
+	}
+	g.rows = g.n * g.outH * g.outW
+	g.colCount = g.c * g.kh * g.kw
+	return g, nil
+}
+
+// samePadding returns the padding that keeps the output size equal to ceil(input / stride), putting any odd pixel
+// after the input as TensorFlow does.
+func samePadding(size, kernel, stride, dilation int) (int, int) {
+	out := (size + stride - 1) / stride
+	total := (out-1)*stride + (kernel-1)*dilation + 1 - size
+	if total < 0 {
+		total = 0
+	}
+	return total / 2, total - total/2
+}
+
+// im2col unrolls every window of the input x into a row of a (n*outH*outW, c*kh*kw) matrix. Positions in the padding
+// are zero.
+func im2col(x []float64, g convGeometry) []float64 {
+	cols := make([]float64, g.rows*g.colCount)
+	for n := 0; n < g.n; n++ {
+		for oy := 0; oy < g.outH; oy++ {
+			for ox := 0; ox < g.outW; ox++ {
+				row := cols[((n*g.outH+oy)*g.outW+ox)*g.colCount:]
+				col := 0
+				for c := 0; c < g.c; c++ {
+					plane := x[(n*g.c+c)*g.h*g.w:]
+					for ky := 0; ky < g.kh; ky++ {
+						y := oy*g.sh - g.pad[0] + ky*g.dh
+						for kx := 0; kx < g.kw; kx++ {
+							xx := ox*g.sw - g.pad[2] + kx*g.dw
+							if y >= 0 && y < g.h && xx >= 0 && xx < g.w {
+								row[col] = plane[y*g.w+xx]
+							}
+							col++
+						}
+					}
+				}
+			}
+		}
+	}
+	return cols
+}
+
+// col2im is the adjoint of im2col: it sums every entry of the unrolled matrix back into the input position it was
+// read from, returning an array of shape (n, c, h, w).
+func col2im(cols []float64, g convGeometry) []float64 {
+	x := make([]float64, g.n*g.c*g.h*g.w)
+	for n := 0; n < g.n; n++ {
+		for oy := 0; oy < g.outH; oy++ {
+			for ox := 0; ox < g.outW; ox++ {
+				row := cols[((n*g.outH+oy)*g.outW+ox)*g.colCount:]
+				col := 0
+				for c := 0; c < g.c; c++ {
+					plane := x[(n*g.c+c)*g.h*g.w:]
+					for ky := 0; ky < g.kh; ky++ {
+						y := oy*g.sh - g.pad[0] + ky*g.dh
+						for kx := 0; kx < g.kw; kx++ {
+							xx := ox*g.sw - g.pad[2] + kx*g.dw
+							if y >= 0 && y < g.h && xx >= 0 && xx < g.w {
+								plane[y*g.w+xx] += row[col]
+							}
+							col++
+						}
+					}
+				}
+			}
+		}
+	}
+	return x
+}
+
+// Conv2D is a 2-D convolution (strictly a cross-correlation, as in every deep learning library) over inputs of shape
+// (batch, channels, height, width).
+//
+// The weight has shape (outChannels, inChannels / groups, kernelHeight, kernelWidth). Each window of the input is
+// unrolled into a row of a matrix (im2col) and multiplied by the reshaped weight with numpy.Matmul; Backward uses the
+// transposed products and folds the input gradient back with col2im.
+//
+// Fields:
+//
+//	Weight, Bias (*Parameter): The kernels and the optional per-channel bias.
+//	Stride, Dilation ([2]int): The step between windows and the spacing between kernel elements.
+//	Padding ([2]int): The zeros added on both sides of the height and width axes. Ignored if SamePadding is set.
+//	SamePadding (bool): Whether to pad so that the output size is ceil(input / stride), as with padding "same".
+//	Groups (int): The number of groups of channels convolved independently.
+type Conv2D struct {
+	Weight      *Parameter
+	Bias        *Parameter
+	Stride      [2]int
+	Dilation    [2]int
+	Padding     [2]int
+	SamePadding bool
+	Groups      int
+
+	cols  *numpy.NDArray
+	geom  convGeometry
+	shape []int
+}
+
+// NewConv2D creates a 2-D convolutional layer.
+//
+// The weights are drawn with random.Randn and scaled by sqrt(1 / fanIn), where fanIn is the number of inputs to each
+// output (inChannels / groups * kernelHeight * kernelWidth). The bias starts at zero.
+//
+// Parameters:
+//
+//	inChannels (int): The number of channels of the input.
+//	outChannels (int): The number of channels produced by the convolution.
+//	kernelSize (interface{}): The size of the kernel, as an int or a pair of ints (height, width).
+//	stride (interface{}): The step between windows, as an int or a pair of ints.
+//	padding (interface{}): "valid" for no padding, "same" to keep the output size at ceil(input / stride), or the
+//	                       explicit zero padding on each side as an int or a pair of ints.
+//	dilation (interface{}): The spacing between kernel elements, as an int or a pair of ints. 1 is a normal kernel.
+//	groups (int): The number of blocked connections from input to output channels. Both channel counts must be
+//	              divisible by it; groups equal to inChannels gives a depthwise convolution.
+//	bias (bool): Whether the layer learns an additive bias.
+//
+// Returns:
+//
+//	(*Conv2D, error): The new layer, or nil and an error if an argument is invalid.
+//
+// Errors:
+//
+//	Returns an error if a size is not positive, padding is not recognised, or the channels are not divisible by
+//	groups.
+func NewConv2D(inChannels, outChannels int, kernelSize, stride, padding, dilation interface{}, groups int, bias bool) (*Conv2D, error) {
+	if inChannels <= 0 || outChannels <= 0 {
+		return nil, fmt.Errorf("inChannels and outChannels must be positive, got %v and %v", inChannels, outChannels)
+	}
+	if groups <= 0 || inChannels%groups != 0 || outChannels%groups != 0 {
+		return nil, fmt.Errorf("inChannels (%v) and outChannels (%v) must be divisible by groups (%v)", inChannels, outChannels, groups)
+	}
+	kernel, err := positivePair(kernelSize, "kernelSize")
+	if err != nil {
+		return nil, err
+	}
+	c := &Conv2D{Groups: groups}
+	if c.Stride, err = positivePair(stride, "stride"); err != nil {
+		return nil, err
+	}
+	if c.Dilation, err = positivePair(dilation, "dilation"); err != nil {
+		return nil, err
+	}
+	switch padding {
+	case "same":
+		c.SamePadding = true
+	case "valid":
+	default:
+		if c.Padding, err = pair(padding, "padding"); err != nil {
+			return nil, fmt.Errorf("padding must be \"same\", \"valid\", an int or a pair of ints, got %v", padding)
+		}
+		if c.Padding[0] < 0 || c.Padding[1] < 0 {
+			return nil, fmt.Errorf("padding must not be negative, got %v", padding)
+		}
+	}
+
+	perGroup := inChannels / groups
+	w, err := numpy.Array(random.Randn(outChannels, perGroup, kernel[0], kernel[1]))
+	if err != nil {
+		return nil, err
+	}
+	c.Weight = NewParameter("weight", w.Scale(math.Sqrt(1/float64(perGroup*kernel[0]*kernel[1]))))
+	if bias {
+		c.Bias = NewParameter("bias", numpy.ZerosArray(outChannels))
+	}
+	return c, nil
+}
+
+// geometry resolves the padding for an input shape and checks it against the weight.
+func (c *Conv2D) geometry(shape []int) (convGeometry, error) {
+	ws := c.Weight.Value.Shape()
+	if len(shape) != 4 {
+		return convGeometry{}, fmt.Errorf("expected input of shape (batch, channels, height, width), got %v", shape)
+	}
+	if shape[1] != ws[1]*c.Groups {
+		return convGeometry{}, fmt.Errorf("expected input with %v channels, got %v", ws[1]*c.Groups, shape[1])
+	}
+	pad := [4]int{c.Padding[0], c.Padding[0], c.Padding[1], c.Padding[1]}
+	if c.SamePadding {
+		pad[0], pad[1] = samePadding(shape[2], ws[2], c.Stride[0], c.Dilation[0])
+		pad[2], pad[3] = samePadding(shape[3], ws[3], c.Stride[1], c.Dilation[1])
+	}
+	return newConvGeometry(shape, [2]int{ws[2], ws[3]}, c.Stride, c.Dilation, pad)
+}
+
+// groupMatrices returns, for group gi, the columns of the unrolled input and the rows of the reshaped weight that
+// take part in it.
+func (c *Conv2D) groupMatrices(gi int) (*numpy.NDArray, *numpy.NDArray, error) {
+	outChannels := c.Weight.Value.Shape()[0]
+	k := c.geom.colCount / c.Groups
+	oc := outChannels / c.Groups
+	cols, err := c.cols.View(numpy.S(), numpy.S(gi*k, (gi+1)*k))
+	if err != nil {
+		return nil, nil, err
+	}
+	w, err := c.Weight.Value.Reshape(outChannels, k)
+	if err != nil {
+		return nil, nil, err
+	}
+	w, err = w.View(numpy.S(gi*oc, (gi+1)*oc))
+	if err != nil {
+		return nil, nil, err
+	}
+	return cols, w, nil
+}
+
+// Forward computes the convolution of x, a batch of shape (batch, channels, height, width).
+//
+// Errors:
+//
+//	Returns an error if x is not 4-D, has the wrong number of channels, or is smaller than the kernel.
+func (c *Conv2D) Forward(x *numpy.NDArray) (*numpy.NDArray, error) {
+	g, err := c.geometry(x.Shape())
+	if err != nil {
+		return nil, err
+	}
+	c.geom = g
+	c.shape = x.Shape()
+	c.cols, _ = numpy.NewArray(im2col(x.Data(), g), g.rows, g.colCount)
+
+	outChannels := c.Weight.Value.Shape()[0]
+	oc := outChannels / c.Groups
+	spatial := g.outH * g.outW
+	out := make([]float64, g.n*outChannels*spatial)
+	for gi := 0; gi < c.Groups; gi++ {
+		cols, w, err := c.groupMatrices(gi)
+		if err != nil {
+			return nil, err
+		}
+		y, err := numpy.Matmul(cols, w.T())
+		if err != nil {
+			return nil, err
+		}
+
+		// y has one row per output position; scatter it into channel-first layout
+		yd := y.Data()
+		for r := 0; r < g.rows; r++ {
+			n, p := r/spatial, r%spatial
+			for o := 0; o < oc; o++ {
+				out[(n*outChannels+gi*oc+o)*spatial+p] = yd[r*oc+o]
+			}
+		}
+	}
+	if c.Bias != nil {
+		b := c.Bias.Value.Data()
+		for i := range out {
+			out[i] += b[(i/spatial)%outChannels]
+		}
+	}
+	return numpy.NewArray(out, g.n, outChannels, g.outH, g.outW)
+}
+
+// Backward accumulates the gradients of the weight and bias and returns the gradient with respect to the input.
+//
+// Errors:
+//
+//	Returns an error if Forward has not been called or grad does not match the output of Forward.
+func (c *Conv2D) Backward(grad *numpy.NDArray) (*numpy.NDArray, error) {
+	if c.cols == nil {
+		return nil, fmt.Errorf("backward called before forward")
+	}
+	g := c.geom
+	outChannels := c.Weight.Value.Shape()[0]
+	oc := outChannels / c.Groups
+	k := g.colCount / c.Groups
+	spatial := g.outH * g.outW
+	if err := checkShape(grad, []int{g.n, outChannels, g.outH, g.outW}); err != nil {
+		return nil, err
+	}
+	gd := grad.Data()
+
+	gw := make([]float64, 0, outChannels*k)
+	dCols := make([]float64, g.rows*g.colCount)
+	for gi := 0; gi < c.Groups; gi++ {
+		// Gather the gradient of this group with one row per output position
+		rows := make([]float64, g.rows*oc)
+		for r := 0; r < g.rows; r++ {
+			n, p := r/spatial, r%spatial
+			for o := 0; o < oc; o++ {
+				rows[r*oc+o] = gd[(n*outChannels+gi*oc+o)*spatial+p]
+			}
+		}
+		gy, _ := numpy.NewArray(rows, g.rows, oc)
+		cols, w, err := c.groupMatrices(gi)
+		if err != nil {
+			return nil, err
+		}
+		dw, err := numpy.Matmul(gy.T(), cols)
+		if err != nil {
+			return nil, err
+		}
+		gw = append(gw, dw.Data()...)
+		dc, err := numpy.Matmul(gy, w)
+		if err != nil {
+			return nil, err
+		}
+		dcd := dc.Data()
+		for r := 0; r < g.rows; r++ {
+			copy(dCols[r*g.colCount+gi*k:r*g.colCount+(gi+1)*k], dcd[r*k:(r+1)*k])
+		}
+	}
+
+	dw, _ := numpy.NewArray(gw, c.Weight.Value.Shape()...)
+	if err := c.Weight.AccumulateGrad(dw); err != nil {
+		return nil, err
+	}
+	if c.Bias != nil {
+		gb := make([]float64, outChannels)
+		for i, v := range gd {
+			gb[(i/spatial)%outChannels] += v
+		}
+		db, _ := numpy.NewArray(gb, outChannels)
+		if err := c.Bias.AccumulateGrad(db); err != nil {
+			return nil, err
+		}
+	}
+	return numpy.NewArray(col2im(dCols, g), c.shape...)
+}
+
+// Parameters returns the weight and, if present, the bias.
+func (c *Conv2D) Parameters() []*Parameter {
+	if c.Bias == nil {
+		return []*Parameter{c.Weight}
+	}
+	return []*Parameter{c.Weight, c.Bias}
+}
+
+// Conv1D is a 1-D convolution over inputs of shape (batch, channels, length), such as audio or sequences of feature
+// vectors. It is computed as a Conv2D over inputs of height one, so the weight has shape
+// (outChannels, inChannels / groups, 1, kernelSize).
+type Conv1D struct {
+	*Conv2D
+}
+
+// NewConv1D creates a 1-D convolutional layer. The parameters are as for NewConv2D, with single ints for kernelSize,
+// stride and dilation, and "valid", "same" or an int for padding.
+//
+// Errors:
+//
+//	Returns an error if an argument is invalid.
+func NewConv1D(inChannels, outChannels, kernelSize, stride int, padding interface{}, dilation, groups int, bias bool) (*Conv1D, error) {
+	switch p := padding.(type) {
+	case int:
+		padding = []int{0, p}
+	case string:
+	default:
+		return nil, fmt.Errorf("padding must be \"same\", \"valid\" or an int, got %v", padding)
+	}
+	c, err := NewConv2D(inChannels, outChannels, []int{1, kernelSize}, []int{1, stride}, padding, []int{1, dilation}, groups, bias)
+	if err != nil {
+		return nil, err
+	}
+	return &Conv1D{c}, nil
+}
+
+// Forward computes the convolution of x, a batch of shape (batch, channels, length).
+//
+// Errors:
+//
+//	Returns an error if x is not 3-D, has the wrong number of channels, or is shorter than the kernel.
+func (c *Conv1D) Forward(x *numpy.NDArray) (*numpy.NDArray, error) {
+	shape := x.Shape()
+	if len(shape) != 3 {
+		return nil, fmt.Errorf("expected input of shape (batch, channels, length), got %v", shape)
+	}
+	x4, err := x.Reshape(shape[0], shape[1], 1, shape[2])
+	if err != nil {
+		return nil, err
+	}
+	y, err := c.Conv2D.Forward(x4)
+	if err != nil {
+		return nil, err
+	}
+	ys := y.Shape()
+	return y.Reshape(ys[0], ys[1], ys[3])
+}
+
+// Backward accumulates the parameter gradients and returns the gradient with respect to the input.
+//
+// Errors:
+//
+//	Returns an error if Forward has not been called or grad does not match the output of Forward.
+func (c *Conv1D) Backward(grad *numpy.NDArray) (*numpy.NDArray, error) {
+	shape := grad.Shape()
+	if len(shape) != 3 {
+		return nil, fmt.Errorf("expected gradient of shape (batch, channels, length), got %v", shape)
+	}
+	g4, err := grad.Reshape(shape[0], shape[1], 1, shape[2])
+	if err != nil {
+		return nil, err
+	}
+	dx, err := c.Conv2D.Backward(g4)
+	if err != nil {
+		return nil, err
+	}
+	xs := dx.Shape()
+	return dx.Reshape(xs[0], xs[1], xs[3])
+}
+
+// checkShape returns an error if x does not have the expected shape.
+func checkShape(x *numpy.NDArray, shape []int) error {
+	xs := x.Shape()
+	if len(xs) != len(shape) {
+		return fmt.Errorf("expected shape %v, got %v", shape, xs)
+	}
+	for i := range xs {
+		if xs[i] != shape[i] {
+			return fmt.Errorf("expected shape %v, got %v", shape, xs)
+		}
+	}
+	return nil
+}
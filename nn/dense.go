@@ -0,0 +1,119 @@
+package nn
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/timotewb/gonn/numpy"
+	"github.com/timotewb/gonn/numpy/random"
+)
+
+// Dense is a fully connected (linear) layer computing y = x @ W + b.
+//
+// The weights have shape (inFeatures, outFeatures), so inputs are row vectors as in numpy code, and the layer accepts
+// any input whose last axis has length inFeatures; leading axes are treated as batch dimensions.
+type Dense struct {
+	Weight *Parameter
+	Bias   *Parameter
+
+	input *numpy.NDArray
+}
+
+// NewDense creates a fully connected layer.
+//
+// The weights are drawn with random.Randn and scaled by sqrt(1 / inFeatures) so that the variance of the outputs
+// does not grow with the number of inputs. The bias starts at zero.
+//
+// Parameters:
+//
+//	inFeatures (int): The size of each input sample.
+//	outFeatures (int): The size of each output sample.
+//	bias (bool): Whether the layer learns an additive bias.
+//
+// Returns:
+//
+//	(*Dense, error): The new layer, or nil and an error if a size is not positive.
+//
+// Errors:
+//
+//	Returns an error if inFeatures or outFeatures is not positive.
+func NewDense(inFeatures, outFeatures int, bias bool) (*Dense, error) {
+	if inFeatures <= 0 || outFeatures <= 0 {
+		return nil, fmt.Errorf("inFeatures and outFeatures must be positive, got %v and %v", inFeatures, outFeatures)
+	}
+	w, err := numpy.Array(random.Randn(inFeatures, outFeatures))
+	if err != nil {
+		return nil, err
+	}
+	d := &Dense{Weight: NewParameter("weight", w.Scale(math.Sqrt(1/float64(inFeatures))))}
+	if bias {
+		d.Bias = NewParameter("bias", numpy.ZerosArray(outFeatures))
+	}
+	return d, nil
+}
+
+// Forward computes x @ W + b.
+//
+// Errors:
+//
+//	Returns an error if the last axis of x does not have length inFeatures.
+func (d *Dense) Forward(x *numpy.NDArray) (*numpy.NDArray, error) {
+	y, err := numpy.Matmul(x, d.Weight.Value)
+	if err != nil {
+		return nil, err
+	}
+	if d.Bias != nil {
+		if y, err = y.Add(d.Bias.Value); err != nil {
+			return nil, err
+		}
+	}
+	d.input = x
+	return y, nil
+}
+
+// Backward accumulates dW = x^T @ grad and db = sum(grad) over the batch, and returns grad @ W^T.
+//
+// Errors:
+//
+//	Returns an error if Forward has not been called or grad does not match the output of Forward.
+func (d *Dense) Backward(grad *numpy.NDArray) (*numpy.NDArray, error) {
+	if d.input == nil {
+		return nil, fmt.Errorf("backward called before forward")
+	}
+	in, out := d.Weight.Value.Shape()[0], d.Weight.Value.Shape()[1]
+
+	// Flatten the batch dimensions so the parameter gradients are plain matrix products
+	x, err := d.input.Reshape(-1, in)
+	if err != nil {
+		return nil, err
+	}
+	g, err := grad.Reshape(-1, out)
+	if err != nil {
+		return nil, err
+	}
+	gw, err := numpy.Matmul(x.T(), g)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.Weight.AccumulateGrad(gw); err != nil {
+		return nil, err
+	}
+	if d.Bias != nil {
+		gb, err := numpy.Sum(g, 0)
+		if err != nil {
+			return nil, err
+		}
+		if err := d.Bias.AccumulateGrad(gb); err != nil {
+			return nil, err
+		}
+	}
+	return numpy.Matmul(grad, d.Weight.Value.T())
+}
+
+// Parameters returns the weight and, if present, the bias.
+func (d *Dense) Parameters() []*Parameter {
+	if d.Bias == nil {
+		return []*Parameter{d.Weight}
+	}
+	return []*Parameter{d.Weight, d.Bias}
+}
@@ -0,0 +1,226 @@
+package nn
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/timotewb/gonn/numpy"
+	"github.com/timotewb/gonn/numpy/random"
+)
+
+// Dropout randomly zeroes elements of its input with probability P during training, scaling the remaining elements by
+// 1 / (1 - P) so that the expected value is unchanged (inverted dropout). In evaluation mode it returns its input.
+//
+// New layers start in training mode; use Train and Eval (or SetTraining) to switch. The masks are drawn from Rand,
+// which can be set to a seeded generator to make them reproducible; nil uses the package-level source.
+type Dropout struct {
+	P    float64
+	Rand *random.Generator
+
+	training bool
+	mask     *numpy.NDArray
+}
+
+// NewDropout creates a Dropout layer that drops elements with probability p.
+//
+// Errors:
+//
+//	Returns an error if p is not in the range [0, 1).
+func NewDropout(p float64) (*Dropout, error) {
+	if p < 0 || p >= 1 {
+		return nil, fmt.Errorf("dropout probability has to be in the range [0, 1), got %v", p)
+	}
+	return &Dropout{P: p, training: true}, nil
+}
+
+// SetTraining switches between training (dropping elements) and evaluation (identity) mode.
+func (d *Dropout) SetTraining(training bool) {
+	d.training = training
+}
+
+// SetRand sets the source of the masks.
+func (d *Dropout) SetRand(g *random.Generator) {
+	d.Rand = g
+}
+
+// Forward drops elements of x at random in training mode and returns x unchanged in evaluation mode.
+func (d *Dropout) Forward(x *numpy.NDArray) (*numpy.NDArray, error) {
+	if !d.training || d.P == 0 {
+		d.mask = nil
+		return x, nil
+	}
+	scale := 1 / (1 - d.P)
+	d.mask = numpy.ZerosArray(x.Shape()...)
+	mask := d.mask.Data()
+	for i := range mask {
+		if d.Rand.Float64() >= d.P {
+			mask[i] = scale
+		}
+	}
+	return x.Mul(d.mask)
+}
+
+// Backward applies the mask of the last Forward call to the gradient.
+func (d *Dropout) Backward(grad *numpy.NDArray) (*numpy.NDArray, error) {
+	if d.mask == nil {
+		return grad, nil
+	}
+	return grad.Mul(d.mask)
+}
+
+// Parameters returns nil because Dropout has no learnable parameters.
+func (d *Dropout) Parameters() []*Parameter { return nil }
+
+// SpatialDropout drops whole channels of inputs of shape (batch, channels, ...) with probability P during training,
+// like torch.nn.Dropout2d. Adjacent pixels of a feature map are strongly correlated, so dropping them independently
+// as Dropout does barely regularizes convolutional layers; dropping entire feature maps does. Kept channels are
+// scaled by 1 / (1 - P) and evaluation mode returns the input. The masks are drawn from Rand as for Dropout.
+type SpatialDropout struct {
+	P    float64
+	Rand *random.Generator
+
+	training bool
+	mask     *numpy.NDArray
+}
+
+// NewSpatialDropout creates a SpatialDropout layer that drops channels with probability p.
+//
+// Errors:
+//
+//	Returns an error if p is not in the range [0, 1).
+func NewSpatialDropout(p float64) (*SpatialDropout, error) {
+	if p < 0 || p >= 1 {
+		return nil, fmt.Errorf("dropout probability has to be in the range [0, 1), got %v", p)
+	}
+	return &SpatialDropout{P: p, training: true}, nil
+}
+
+// SetTraining switches between training (dropping channels) and evaluation (identity) mode.
+func (d *SpatialDropout) SetTraining(training bool) {
+	d.training = training
+}
+
+// SetRand sets the source of the masks.
+func (d *SpatialDropout) SetRand(g *random.Generator) {
+	d.Rand = g
+}
+
+// Forward drops channels of x at random in training mode and returns x unchanged in evaluation mode.
+//
+// Errors:
+//
+//	Returns an error if x has fewer than 3 dimensions.
+func (d *SpatialDropout) Forward(x *numpy.NDArray) (*numpy.NDArray, error) {
+	shape := x.Shape()
+	if len(shape) < 3 {
+		return nil, fmt.Errorf("expected input of shape (batch, channels, ...), got %v", shape)
+	}
+	if !d.training || d.P == 0 {
+		d.mask = nil
+		return x, nil
+	}
+	scale := 1 / (1 - d.P)
+	d.mask = numpy.ZerosArray(shape...)
+	mask := d.mask.Data()
+	planes := shape[0] * shape[1]
+	if planes == 0 {
+		return x.Mul(d.mask)
+	}
+	spatial := len(mask) / planes
+	for c := 0; c < planes; c++ {
+		if d.Rand.Float64() >= d.P {
+			for i := c * spatial; i < (c+1)*spatial; i++ {
+				mask[i] = scale
+			}
+		}
+	}
+	return x.Mul(d.mask)
+}
+
+// Backward applies the mask of the last Forward call to the gradient.
+func (d *SpatialDropout) Backward(grad *numpy.NDArray) (*numpy.NDArray, error) {
+	if d.mask == nil {
+		return grad, nil
+	}
+	return grad.Mul(d.mask)
+}
+
+// Parameters returns nil because SpatialDropout has no learnable parameters.
+func (d *SpatialDropout) Parameters() []*Parameter { return nil }
+
+// seluAlphaPrime is -lambda * alpha of SELU, the value its output saturates to for large negative inputs.
+const seluAlphaPrime = -seluLambda * seluAlpha
+
+// AlphaDropout is the dropout of self-normalizing networks (Klambauer et al., 2017), like torch.nn.AlphaDropout.
+// During training it sets elements to the negative saturation value of SELU with probability P and applies an
+// affine transformation chosen so that inputs with zero mean and unit variance keep them. It is meant to follow
+// SELU activations. In evaluation mode it returns its input. The masks are drawn from Rand as for Dropout.
+type AlphaDropout struct {
+	P    float64
+	Rand *random.Generator
+
+	training bool
+	mask     *numpy.NDArray
+}
+
+// NewAlphaDropout creates an AlphaDropout layer that drops elements with probability p.
+//
+// Errors:
+//
+//	Returns an error if p is not in the range [0, 1).
+func NewAlphaDropout(p float64) (*AlphaDropout, error) {
+	if p < 0 || p >= 1 {
+		return nil, fmt.Errorf("dropout probability has to be in the range [0, 1), got %v", p)
+	}
+	return &AlphaDropout{P: p, training: true}, nil
+}
+
+// SetTraining switches between training (dropping elements) and evaluation (identity) mode.
+func (d *AlphaDropout) SetTraining(training bool) {
+	d.training = training
+}
+
+// SetRand sets the source of the masks.
+func (d *AlphaDropout) SetRand(g *random.Generator) {
+	d.Rand = g
+}
+
+// affine returns the scale a and shift b restoring zero mean and unit variance after dropping with probability p.
+func (d *AlphaDropout) affine() (float64, float64) {
+	a := 1 / math.Sqrt((1-d.P)*(1+d.P*seluAlphaPrime*seluAlphaPrime))
+	return a, -a * seluAlphaPrime * d.P
+}
+
+// Forward computes a * (x * keep + alpha' * (1 - keep)) + b in training mode and returns x unchanged in evaluation
+// mode.
+func (d *AlphaDropout) Forward(x *numpy.NDArray) (*numpy.NDArray, error) {
+	if !d.training || d.P == 0 {
+		d.mask = nil
+		return x, nil
+	}
+	a, b := d.affine()
+	d.mask = numpy.ZerosArray(x.Shape()...)
+	mask := d.mask.Data()
+	xd := x.Data()
+	out := make([]float64, len(xd))
+	for i, v := range xd {
+		if d.Rand.Float64() >= d.P {
+			mask[i] = a
+			out[i] = a*v + b
+		} else {
+			out[i] = a*seluAlphaPrime + b
+		}
+	}
+	return numpy.NewArray(out, x.Shape()...)
+}
+
+// Backward scales the gradient of kept elements by a and zeroes that of dropped elements.
+func (d *AlphaDropout) Backward(grad *numpy.NDArray) (*numpy.NDArray, error) {
+	if d.mask == nil {
+		return grad, nil
+	}
+	return grad.Mul(d.mask)
+}
+
+// Parameters returns nil because AlphaDropout has no learnable parameters.
+func (d *AlphaDropout) Parameters() []*Parameter { return nil }
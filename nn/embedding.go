@@ -0,0 +1,354 @@
+package nn
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/timotewb/gonn/numpy"
+	"github.com/timotewb/gonn/numpy/random"
+)
+
+// Embedding is a lookup table mapping integer indices, such as token ids, to dense vectors, like torch.nn.Embedding.
+//
+// The input holds indices of any shape and the output appends an axis of EmbeddingDim features. Backward adds the
+// gradient of every output vector to the row of Weight it was read from. Indices are not differentiable, so the
+// returned input gradient is zero.
+//
+// Fields:
+//
+//	Weight (*Parameter): The table, of shape (numEmbeddings, embeddingDim).
+//	MaxNorm (float64): If positive, every row looked up by Forward whose norm exceeds MaxNorm is rescaled in place
+//	                   to norm MaxNorm, as with max_norm in PyTorch.
+//	NormType (float64): The p of the p-norm used with MaxNorm. Defaults to 2.
+//	Sparse (bool): Whether Backward produces a sparse gradient, recording the rows it touched in Weight.Rows so the
+//	               optimizer and ZeroGrad only visit those rows. Use it for large vocabularies.
+type Embedding struct {
+	Weight   *Parameter
+	MaxNorm  float64
+	NormType float64
+	Sparse   bool
+
+	paddingIdx int
+	indices    []int
+	shape      []int
+}
+
+// NewEmbedding creates an embedding table of numEmbeddings rows with embeddingDim features, drawn from a standard
+// normal distribution as in PyTorch.
+//
+// Errors:
+//
+//	Returns an error if a size is not positive.
+func NewEmbedding(numEmbeddings, embeddingDim int) (*Embedding, error) {
+	if numEmbeddings <= 0 || embeddingDim <= 0 {
+		return nil, fmt.Errorf("numEmbeddings and embeddingDim must be positive, got %v and %v", numEmbeddings, embeddingDim)
+	}
+	w, err := numpy.Array(random.Randn(numEmbeddings, embeddingDim))
+	if err != nil {
+		return nil, err
+	}
+	return &Embedding{Weight: NewParameter("weight", w), NormType: 2, paddingIdx: -1}, nil
+}
+
+// NewEmbeddingFromPretrained creates an embedding whose table is a copy of weights, like
+// torch.nn.Embedding.from_pretrained. To keep the table fixed during training, leave its parameters out of the
+// optimizer.
+//
+// Errors:
+//
+//	Returns an error if weights is not 2-D or is empty.
+func NewEmbeddingFromPretrained(weights *numpy.NDArray) (*Embedding, error) {
+	s := weights.Shape()
+	if len(s) != 2 || s[0] == 0 || s[1] == 0 {
+		return nil, fmt.Errorf("expected pretrained weights of shape (numEmbeddings, embeddingDim), got %v", s)
+	}
+	return &Embedding{Weight: NewParameter("weight", weights.Copy()), NormType: 2, paddingIdx: -1}, nil
+}
+
+// LoadEmbedding creates an embedding from pretrained weights stored in a .npy file, such as word vectors exported
+// with numpy.save.
+//
+// Errors:
+//
+//	Returns an error if the file cannot be read or does not hold a 2-D array.
+//
+// Example usage:
+//
+//	emb, err := nn.LoadEmbedding("glove.6B.50d.npy")
+//	emb.SetPaddingIdx(0)
+func LoadEmbedding(path string) (*Embedding, error) {
+	w, err := numpy.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	e, err := NewEmbeddingFromPretrained(w)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	return e, nil
+}
+
+// SetPaddingIdx marks a row as padding, like padding_idx in PyTorch: the row is set to zeros and never receives a
+// gradient, so padded positions of a sequence map to a fixed zero vector. A negative idx removes the padding row,
+// leaving its values unchanged.
+//
+// Errors:
+//
+//	Returns an error if idx is not a row of the table.
+func (e *Embedding) SetPaddingIdx(idx int) error {
+	rows, dim := e.Weight.Value.Shape()[0], e.Weight.Value.Shape()[1]
+	if idx >= rows {
+		return fmt.Errorf("padding index %v is out of range for an embedding of %v rows", idx, rows)
+	}
+	if idx < 0 {
+		e.paddingIdx = -1
+		return nil
+	}
+	e.paddingIdx = idx
+	w := e.Weight.Value.Data()
+	for j := idx * dim; j < (idx+1)*dim; j++ {
+		w[j] = 0
+	}
+	return nil
+}
+
+// PaddingIdx returns the padding row, or -1 if there is none.
+func (e *Embedding) PaddingIdx() int {
+	return e.paddingIdx
+}
+
+// renorm rescales a row to norm MaxNorm if its norm exceeds it.
+func (e *Embedding) renorm(row []float64) {
+	p := e.NormType
+	if p <= 0 {
+		p = 2
+	}
+	norm := 0.
+	for _, v := range row {
+		norm += math.Pow(math.Abs(v), p)
+	}
+	norm = math.Pow(norm, 1/p)
+	if norm > e.MaxNorm {
+		scale := e.MaxNorm / (norm + 1e-7)
+		for j := range row {
+			row[j] *= scale
+		}
+	}
+}
+
+// Forward looks up the vector of every index in x, renormalizing the rows used if MaxNorm is set.
+//
+// Errors:
+//
+//	Returns an error if an element of x is not an integer in [0, numEmbeddings).
+func (e *Embedding) Forward(x *numpy.NDArray) (*numpy.NDArray, error) {
+	rows, dim := e.Weight.Value.Shape()[0], e.Weight.Value.Shape()[1]
+	w := e.Weight.Value.Data()
+	xd := x.Data()
+	indices := make([]int, len(xd))
+	for i, v := range xd {
+		if v != math.Trunc(v) || v < 0 || v >= float64(rows) {
+			return nil, fmt.Errorf("index %v is out of range for an embedding of %v rows", v, rows)
+		}
+		indices[i] = int(v)
+	}
+	if e.MaxNorm > 0 {
+		done := map[int]bool{}
+		for _, idx := range indices {
+			if !done[idx] {
+				done[idx] = true
+				e.renorm(w[idx*dim : (idx+1)*dim])
+			}
+		}
+	}
+	out := make([]float64, len(xd)*dim)
+	for i, idx := range indices {
+		copy(out[i*dim:(i+1)*dim], w[idx*dim:(idx+1)*dim])
+	}
+	e.indices, e.shape = indices, x.Shape()
+	return numpy.NewArray(out, append(x.Shape(), dim)...)
+}
+
+// Backward accumulates the gradient of every looked up row except the padding row and returns a zero gradient for
+// the indices. With Sparse set, the rows touched are added to Weight.Rows.
+//
+// Errors:
+//
+//	Returns an error if Forward has not been called or grad does not match the output of Forward.
+func (e *Embedding) Backward(grad *numpy.NDArray) (*numpy.NDArray, error) {
+	if e.shape == nil {
+		return nil, fmt.Errorf("backward called before forward")
+	}
+	dim := e.Weight.Value.Shape()[1]
+	if err := checkShape(grad, append(append([]int{}, e.shape...), dim)); err != nil {
+		return nil, err
+	}
+	gd := grad.Data()
+	gw := e.Weight.Grad.Data()
+	var touched []int
+	if e.Sparse && e.Weight.Rows == nil {
+		// The gradient is dense, for example before the first ZeroGrad, so record the rows it already holds once
+		for r := 0; r < len(gw)/dim; r++ {
+			for j := r * dim; j < (r+1)*dim; j++ {
+				if gw[j] != 0 {
+					touched = append(touched, r)
+					break
+				}
+			}
+		}
+	}
+	for i, idx := range e.indices {
+		if idx == e.paddingIdx {
+			continue
+		}
+		for j := 0; j < dim; j++ {
+			gw[idx*dim+j] += gd[i*dim+j]
+		}
+		touched = append(touched, idx)
+	}
+	if e.Sparse {
+		e.Weight.AddRows(touched...)
+	} else {
+		e.Weight.Rows = nil
+	}
+	return numpy.ZerosArray(e.shape...), nil
+}
+
+// Parameters returns the embedding table.
+func (e *Embedding) Parameters() []*Parameter {
+	return []*Parameter{e.Weight}
+}
+
+// SinusoidalPositionalEncoding adds the fixed sine and cosine position signals of Vaswani et al. (2017) to inputs of
+// shape (batch, time, dModel):
+//
+//	PE(pos, 2i) = sin(pos / 10000^(2i / dModel))
+//	PE(pos, 2i+1) = cos(pos / 10000^(2i / dModel))
+type SinusoidalPositionalEncoding struct {
+	encoding *numpy.NDArray
+}
+
+// NewSinusoidalPositionalEncoding precomputes the encodings for sequences of up to maxLen positions.
+//
+// Errors:
+//
+//	Returns an error if maxLen or dModel is not positive.
+func NewSinusoidalPositionalEncoding(maxLen, dModel int) (*SinusoidalPositionalEncoding, error) {
+	if maxLen <= 0 || dModel <= 0 {
+		return nil, fmt.Errorf("maxLen and dModel must be positive, got %v and %v", maxLen, dModel)
+	}
+	pe := numpy.ZerosArray(maxLen, dModel)
+	d := pe.Data()
+	for pos := 0; pos < maxLen; pos++ {
+		for i := 0; i < dModel; i += 2 {
+			angle := float64(pos) / math.Pow(10000, float64(i)/float64(dModel))
+			d[pos*dModel+i] = math.Sin(angle)
+			if i+1 < dModel {
+				d[pos*dModel+i+1] = math.Cos(angle)
+			}
+		}
+	}
+	return &SinusoidalPositionalEncoding{encoding: pe}, nil
+}
+
+// positions returns the first steps rows of a (maxLen, dModel) table after checking x against it.
+func positions(table *numpy.NDArray, x *numpy.NDArray) (*numpy.NDArray, error) {
+	shape, ts := x.Shape(), table.Shape()
+	if len(shape) != 3 || shape[2] != ts[1] {
+		return nil, fmt.Errorf("expected input of shape (batch, time, %v), got %v", ts[1], shape)
+	}
+	if shape[1] > ts[0] {
+		return nil, fmt.Errorf("sequence of length %v is longer than the maximum of %v positions", shape[1], ts[0])
+	}
+	return table.View(numpy.S(0, shape[1]))
+}
+
+// Forward adds the encoding of each position to x.
+//
+// Errors:
+//
+//	Returns an error if x is not of shape (batch, time, dModel) or is longer than maxLen.
+func (p *SinusoidalPositionalEncoding) Forward(x *numpy.NDArray) (*numpy.NDArray, error) {
+	pe, err := positions(p.encoding, x)
+	if err != nil {
+		return nil, err
+	}
+	return x.Add(pe)
+}
+
+// Backward returns grad unchanged, as the encodings are constant.
+func (p *SinusoidalPositionalEncoding) Backward(grad *numpy.NDArray) (*numpy.NDArray, error) {
+	return grad, nil
+}
+
+// Parameters returns nil because the encodings are fixed.
+func (p *SinusoidalPositionalEncoding) Parameters() []*Parameter { return nil }
+
+// LearnedPositionalEncoding adds a trained vector per position to inputs of shape (batch, time, dModel), as in BERT
+// and GPT.
+type LearnedPositionalEncoding struct {
+	Weight *Parameter
+
+	steps int
+}
+
+// NewLearnedPositionalEncoding creates position vectors for sequences of up to maxLen positions, drawn from a normal
+// distribution with standard deviation 0.02.
+//
+// Errors:
+//
+//	Returns an error if maxLen or dModel is not positive.
+func NewLearnedPositionalEncoding(maxLen, dModel int) (*LearnedPositionalEncoding, error) {
+	if maxLen <= 0 || dModel <= 0 {
+		return nil, fmt.Errorf("maxLen and dModel must be positive, got %v and %v", maxLen, dModel)
+	}
+	w, err := numpy.Array(random.Randn(maxLen, dModel))
+	if err != nil {
+		return nil, err
+	}
+	return &LearnedPositionalEncoding{Weight: NewParameter("weight", w.Scale(0.02))}, nil
+}
+
+// Forward adds the vector of each position to x.
+//
+// Errors:
+//
+//	Returns an error if x is not of shape (batch, time, dModel) or is longer than maxLen.
+func (p *LearnedPositionalEncoding) Forward(x *numpy.NDArray) (*numpy.NDArray, error) {
+	pe, err := positions(p.Weight.Value, x)
+	if err != nil {
+		return nil, err
+	}
+	p.steps = x.Shape()[1]
+	return x.Add(pe)
+}
+
+// Backward accumulates the gradient of the used positions, summed over the batch, and returns grad unchanged.
+//
+// Errors:
+//
+//	Returns an error if Forward has not been called.
+func (p *LearnedPositionalEncoding) Backward(grad *numpy.NDArray) (*numpy.NDArray, error) {
+	if p.steps == 0 {
+		return nil, fmt.Errorf("backward called before forward")
+	}
+	s, err := numpy.Sum(grad, 0)
+	if err != nil {
+		return nil, err
+	}
+	gw := p.Weight.Grad.Data()
+	addTo(gw[:s.Size()], s.Data())
+	return grad, nil
+}
+
+// Parameters returns the position vectors.
+func (p *LearnedPositionalEncoding) Parameters() []*Parameter {
+	return []*Parameter{p.Weight}
+}
+
+// addTo adds src to dst element-wise.
+func addTo(dst, src []float64) {
+	for i, v := range src {
+		dst[i] += v
+	}
+}
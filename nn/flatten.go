@@ -0,0 +1,47 @@
+package nn
+
+import (
+	"fmt"
+
+	"github.com/timotewb/gonn/numpy"
+)
+
+// Flatten reshapes inputs of shape (batch, ...) to (batch, features), typically between convolutional and Dense
+// layers.
+type Flatten struct {
+	shape []int
+}
+
+// NewFlatten creates a flatten layer.
+func NewFlatten() *Flatten {
+	return &Flatten{}
+}
+
+// Forward flattens every axis of x after the first.
+//
+// Errors:
+//
+//	Returns an error if x is 0-d.
+func (f *Flatten) Forward(x *numpy.NDArray) (*numpy.NDArray, error) {
+	shape := x.Shape()
+	if len(shape) == 0 {
+		return nil, fmt.Errorf("expected input with a batch axis, got a 0-d array")
+	}
+	f.shape = shape
+	return x.Reshape(shape[0], -1)
+}
+
+// Backward reshapes the gradient back to the shape of the input.
+//
+// Errors:
+//
+//	Returns an error if Forward has not been called or grad has a different number of elements.
+func (f *Flatten) Backward(grad *numpy.NDArray) (*numpy.NDArray, error) {
+	if f.shape == nil {
+		return nil, fmt.Errorf("backward called before forward")
+	}
+	return grad.Reshape(f.shape...)
+}
+
+// Parameters returns nil because Flatten has no learnable parameters.
+func (f *Flatten) Parameters() []*Parameter { return nil }
@@ -0,0 +1,160 @@
+package nn
+
+import (
+	"github.com/timotewb/gonn/numpy"
+	"github.com/timotewb/gonn/numpy/random"
+)
+
+// Layer is a building block of a neural network.
+//
+// Forward computes the output of the layer for a batch of inputs and caches whatever Backward needs. Backward
+// receives the gradient of the loss with respect to the output of the most recent Forward call, adds the gradients
+// of the layer's parameters to their Grad, and returns the gradient with respect to the input. A layer therefore
+// supports one Forward/Backward pair at a time and should not be reused twice within the same pass.
+type Layer interface {
+	Forward(x *numpy.NDArray) (*numpy.NDArray, error)
+	Backward(grad *numpy.NDArray) (*numpy.NDArray, error)
+	Parameters() []*Parameter
+}
+
+// Trainable is implemented by layers that behave differently during training and evaluation, such as Dropout.
+type Trainable interface {
+	SetTraining(training bool)
+}
+
+// Randomized is implemented by layers drawing random numbers during training, such as Dropout, and by containers
+// holding such layers.
+type Randomized interface {
+	SetRand(g *random.Generator)
+}
+
+// Buffered is implemented by layers holding state that is not learned by gradient descent but must be saved with
+// the model, such as the running statistics of BatchNorm. The arrays are returned by reference, so loading saved
+// values means copying them into the returned arrays.
+type Buffered interface {
+	Buffers() map[string]*numpy.NDArray
+}
+
+// Parameter is a learnable array of a layer together with its accumulated gradient.
+//
+// Fields:
+//
+//	Name (string): A short name identifying the parameter within its layer, for example "weight" or "bias".
+//	Value (*numpy.NDArray): The current values. Optimizers update this array in place.
+//	Grad (*numpy.NDArray): The gradient of the loss with respect to Value, accumulated by Backward. It has the same
+//	                       shape as Value and is reset by ZeroGrad.
+//	Regularizer (Regularizer): An optional penalty on Value added to the loss by Regularize, such as L2.
+//	Constraint (Constraint): An optional projection of Value applied by ApplyConstraints after each update, such as
+//	                         a max-norm constraint.
+//	Rows ([]int): For sparse gradients, the rows along the first axis where Grad may be nonzero, each listed once.
+//	              nil means the gradient is dense. Layers such as Embedding with Sparse set it in Backward;
+//	              optimizers then update only these rows and ZeroGrad clears only them.
+type Parameter struct {
+	Name        string
+	Value       *numpy.NDArray
+	Grad        *numpy.NDArray
+	Regularizer Regularizer
+	Constraint  Constraint
+	Rows        []int
+}
+
+// NewParameter creates a parameter holding value with a zero gradient of the same shape.
+func NewParameter(name string, value *numpy.NDArray) *Parameter {
+	return &Parameter{
+		Name:  name,
+		Value: value,
+		Grad:  numpy.ZerosArray(value.Shape()...),
+	}
+}
+
+// AccumulateGrad adds g to the gradient of the parameter. The gradient becomes dense, so Rows is reset to nil.
+func (p *Parameter) AccumulateGrad(g *numpy.NDArray) error {
+	sum, err := p.Grad.Add(g)
+	if err != nil {
+		return err
+	}
+	copy(p.Grad.Data(), sum.Data())
+	p.Rows = nil
+	return nil
+}
+
+// AddRows records rows along the first axis where a sparse gradient may be nonzero, skipping those already in Rows.
+// It is called by layers producing sparse gradients after adding to Grad.
+func (p *Parameter) AddRows(rows ...int) {
+	seen := make(map[int]bool, len(p.Rows))
+	for _, r := range p.Rows {
+		seen[r] = true
+	}
+	if p.Rows == nil {
+		p.Rows = []int{}
+	}
+	for _, r := range rows {
+		if !seen[r] {
+			seen[r] = true
+			p.Rows = append(p.Rows, r)
+		}
+	}
+}
+
+// RowSpans returns the ranges [start, end) of the flat gradient that may be nonzero: the whole array for a dense
+// gradient, and one range per row in Rows for a sparse one. Optimizers iterate over them to update only the rows
+// seen since the last ZeroGrad.
+func (p *Parameter) RowSpans() [][2]int {
+	size := p.Grad.Size()
+	if p.Rows == nil {
+		return [][2]int{{0, size}}
+	}
+	rowSize := 0
+	if n := p.Grad.Shape(); len(n) > 0 && n[0] > 0 {
+		rowSize = size / n[0]
+	}
+	spans := make([][2]int, len(p.Rows))
+	for i, r := range p.Rows {
+		spans[i] = [2]int{r * rowSize, (r + 1) * rowSize}
+	}
+	return spans
+}
+
+// ZeroGrad resets the gradient of the parameter to zero. A sparse gradient is cleared row by row and stays sparse
+// with no rows.
+func (p *Parameter) ZeroGrad() {
+	grad := p.Grad.Data()
+	for _, s := range p.RowSpans() {
+		for i := s[0]; i < s[1]; i++ {
+			grad[i] = 0
+		}
+	}
+	if p.Rows != nil {
+		p.Rows = p.Rows[:0]
+	}
+}
+
+// ZeroGrad resets the gradients of every parameter of the layer to zero. Gradients accumulate across Backward calls,
+// so this is called before each training step.
+func ZeroGrad(l Layer) {
+	for _, p := range l.Parameters() {
+		p.ZeroGrad()
+	}
+}
+
+// Train puts the layer, and any layers it contains, into training mode.
+func Train(l Layer) {
+	if t, ok := l.(Trainable); ok {
+		t.SetTraining(true)
+	}
+}
+
+// Eval puts the layer, and any layers it contains, into evaluation mode.
+func Eval(l Layer) {
+	if t, ok := l.(Trainable); ok {
+		t.SetTraining(false)
+	}
+}
+
+// SetRand makes the layer, and any layers it contains, draw their random numbers from g, so that seeding g makes
+// training reproducible. A nil g restores the package-level source.
+func SetRand(l Layer, g *random.Generator) {
+	if r, ok := l.(Randomized); ok {
+		r.SetRand(g)
+	}
+}
@@ -0,0 +1,613 @@
+package nn
+
+import (
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/timotewb/gonn/numpy"
+)
+
+// LayerNorm normalizes every sample over its last axes to zero mean and unit variance, then applies a learned
+// element-wise scale (Weight) and shift (Bias), like torch.nn.LayerNorm (Ba et al., 2016).
+//
+// Unlike batch normalization the statistics are computed per sample, so the layer behaves the same during training
+// and evaluation and works with any batch size.
+type LayerNorm struct {
+	Weight *Parameter
+	Bias   *Parameter
+	Eps    float64
+
+	normalizedShape []int
+	xhat            []float64
+	invStd          []float64
+	shape           []int
+}
+
+// NewLayerNorm creates a layer normalization layer.
+//
+// Parameters:
+//
+//	normalizedShape ([]int): The shape of the trailing axes normalized together, for example []int{dModel}.
+//	eps (float64): A value added to the variance for numerical stability, typically 1e-5.
+//	elementwiseAffine (bool): Whether to learn a scale (initialised to one) and shift (initialised to zero) of
+//	                          normalizedShape.
+//
+// Returns:
+//
+//	(*LayerNorm, error): The new layer, or nil and an error if normalizedShape is empty or not positive.
+//
+// Errors:
+//
+//	Returns an error if normalizedShape is empty or has a dimension that is not positive.
+func NewLayerNorm(normalizedShape []int, eps float64, elementwiseAffine bool) (*LayerNorm, error) {
+	if len(normalizedShape) == 0 {
+		return nil, fmt.Errorf("normalizedShape must have at least one dimension")
+	}
+	for _, n := range normalizedShape {
+		if n <= 0 {
+			return nil, fmt.Errorf("normalizedShape must be positive, got %v", normalizedShape)
+		}
+	}
+	l := &LayerNorm{Eps: eps, normalizedShape: append([]int{}, normalizedShape...)}
+	if elementwiseAffine {
+		l.Weight = NewParameter("weight", numpy.OnesArray(normalizedShape...))
+		l.Bias = NewParameter("bias", numpy.ZerosArray(normalizedShape...))
+	}
+	return l, nil
+}
+
+// Forward normalizes x over its trailing axes.
+//
+// Errors:
+//
+//	Returns an error if the trailing axes of x do not match normalizedShape.
+func (l *LayerNorm) Forward(x *numpy.NDArray) (*numpy.NDArray, error) {
+	shape := x.Shape()
+	k := len(l.normalizedShape)
+	if len(shape) < k || !slices.Equal(shape[len(shape)-k:], l.normalizedShape) {
+		return nil, fmt.Errorf("expected input with trailing shape %v, got %v", l.normalizedShape, shape)
+	}
+	n := 1
+	for _, v := range l.normalizedShape {
+		n *= v
+	}
+	xd := x.Data()
+	rows := len(xd) / n
+	l.xhat = make([]float64, len(xd))
+	l.invStd = make([]float64, rows)
+	out := make([]float64, len(xd))
+	for r := 0; r < rows; r++ {
+		lane := xd[r*n : (r+1)*n]
+		mean, variance := 0., 0.
+		for _, v := range lane {
+			mean += v
+		}
+		mean /= float64(n)
+		for _, v := range lane {
+			variance += (v - mean) * (v - mean)
+		}
+		variance /= float64(n)
+		inv := 1 / math.Sqrt(variance+l.Eps)
+		l.invStd[r] = inv
+		for i, v := range lane {
+			xh := (v - mean) * inv
+			l.xhat[r*n+i] = xh
+			if l.Weight != nil {
+				xh = xh*l.Weight.Value.Data()[i] + l.Bias.Value.Data()[i]
+			}
+			out[r*n+i] = xh
+		}
+	}
+	l.shape = shape
+	return numpy.NewArray(out, shape...)
+}
+
+// Backward accumulates the gradients of the scale and shift and returns the gradient with respect to the input.
+//
+// Errors:
+//
+//	Returns an error if Forward has not been called or grad does not match the output of Forward.
+func (l *LayerNorm) Backward(grad *numpy.NDArray) (*numpy.NDArray, error) {
+	if l.shape == nil {
+		return nil, fmt.Errorf("backward called before forward")
+	}
+	if err := checkShape(grad, l.shape); err != nil {
+		return nil, err
+	}
+	gd := grad.Data()
+	n := len(gd) / len(l.invStd)
+	dx := make([]float64, len(gd))
+	var gw, gb []float64
+	if l.Weight != nil {
+		gw = make([]float64, n)
+		gb = make([]float64, n)
+	}
+	dxhat := make([]float64, n)
+	for r, inv := range l.invStd {
+		sum, dot := 0., 0.
+		for i := 0; i < n; i++ {
+			g, xh := gd[r*n+i], l.xhat[r*n+i]
+			if gw != nil {
+				gw[i] += g * xh
+				gb[i] += g
+				g *= l.Weight.Value.Data()[i]
+			}
+			dxhat[i] = g
+			sum += g
+			dot += g * xh
+		}
+		for i := 0; i < n; i++ {
+			dx[r*n+i] = inv / float64(n) * (float64(n)*dxhat[i] - sum - l.xhat[r*n+i]*dot)
+		}
+	}
+	if gw != nil {
+		dw, _ := numpy.NewArray(gw, l.normalizedShape...)
+		db, _ := numpy.NewArray(gb, l.normalizedShape...)
+		if err := l.Weight.AccumulateGrad(dw); err != nil {
+			return nil, err
+		}
+		if err := l.Bias.AccumulateGrad(db); err != nil {
+			return nil, err
+		}
+	}
+	return numpy.NewArray(dx, l.shape...)
+}
+
+// Parameters returns the scale and shift, or nil without an elementwise affine transform.
+func (l *LayerNorm) Parameters() []*Parameter {
+	if l.Weight == nil {
+		return nil
+	}
+	return []*Parameter{l.Weight, l.Bias}
+}
+
+// channelNorm normalizes inputs of shape (batch, channels, ...) over groups of elements and applies a per-channel
+// scale and shift. It implements the computation shared by BatchNorm, InstanceNorm and GroupNorm, which differ only
+// in how elements are grouped.
+type channelNorm struct {
+	Weight *Parameter
+	Bias   *Parameter
+	Eps    float64
+
+	channels int
+	xhat     []float64
+	invStd   []float64
+	group    []int
+	counts   []float64
+	fixed    bool
+	shape    []int
+}
+
+// newChannelNorm creates the scale (initialised to one) and shift (initialised to zero) if affine is set.
+func newChannelNorm(channels int, eps float64, affine bool) (channelNorm, error) {
+	if channels <= 0 {
+		return channelNorm{}, fmt.Errorf("numFeatures must be positive, got %v", channels)
+	}
+	n := channelNorm{Eps: eps, channels: channels}
+	if affine {
+		n.Weight = NewParameter("weight", numpy.OnesArray(channels))
+		n.Bias = NewParameter("bias", numpy.ZerosArray(channels))
+	}
+	return n, nil
+}
+
+// checkInput returns an error if x is not of shape (batch, channels, ...).
+func (n *channelNorm) checkInput(x *numpy.NDArray) error {
+	shape := x.Shape()
+	if len(shape) < 2 || shape[1] != n.channels {
+		return fmt.Errorf("expected input of shape (batch, %v, ...), got %v", n.channels, shape)
+	}
+	return nil
+}
+
+// forward normalizes x. groupOf maps a sample and channel to one of groups groups. If mean and variance are given
+// they are used instead of the statistics of x (evaluation with running statistics). It returns the output and the
+// mean and biased variance of each group. An empty input gives an empty output.
+func (n *channelNorm) forward(x *numpy.NDArray, groups int, groupOf func(sample, channel int) int, mean, variance []float64) (*numpy.NDArray, []float64, []float64) {
+	shape := x.Shape()
+	xd := x.Data()
+	spatial := 0
+	if planes := shape[0] * shape[1]; planes > 0 {
+		spatial = len(xd) / planes
+	}
+
+	// Assign every (sample, channel) plane to its group
+	n.group = make([]int, shape[0]*shape[1])
+	n.counts = make([]float64, groups)
+	for s := 0; s < shape[0]; s++ {
+		for c := 0; c < shape[1]; c++ {
+			g := groupOf(s, c)
+			n.group[s*shape[1]+c] = g
+			n.counts[g] += float64(spatial)
+		}
+	}
+
+	n.fixed = mean != nil
+	if !n.fixed {
+		mean = make([]float64, groups)
+		variance = make([]float64, groups)
+		for p, g := range n.group {
+			for _, v := range xd[p*spatial : (p+1)*spatial] {
+				mean[g] += v
+			}
+		}
+		for g := range mean {
+			mean[g] /= n.counts[g]
+		}
+		for p, g := range n.group {
+			for _, v := range xd[p*spatial : (p+1)*spatial] {
+				variance[g] += (v - mean[g]) * (v - mean[g])
+			}
+		}
+		for g := range variance {
+			variance[g] /= n.counts[g]
+		}
+	}
+	n.invStd = make([]float64, groups)
+	for g := range n.invStd {
+		n.invStd[g] = 1 / math.Sqrt(variance[g]+n.Eps)
+	}
+
+	n.xhat = make([]float64, len(xd))
+	out := make([]float64, len(xd))
+	for p, g := range n.group {
+		c := p % shape[1]
+		for i := p * spatial; i < (p+1)*spatial; i++ {
+			n.xhat[i] = (xd[i] - mean[g]) * n.invStd[g]
+			out[i] = n.xhat[i]
+			if n.Weight != nil {
+				out[i] = out[i]*n.Weight.Value.Data()[c] + n.Bias.Value.Data()[c]
+			}
+		}
+	}
+	n.shape = shape
+	y, _ := numpy.NewArray(out, shape...)
+	return y, mean, variance
+}
+
+// backward accumulates the gradients of the scale and shift and returns the gradient with respect to the input.
+func (n *channelNorm) backward(grad *numpy.NDArray) (*numpy.NDArray, error) {
+	if n.shape == nil {
+		return nil, fmt.Errorf("backward called before forward")
+	}
+	if err := checkShape(grad, n.shape); err != nil {
+		return nil, err
+	}
+	gd := grad.Data()
+	spatial := 0
+	if len(n.group) > 0 {
+		spatial = len(gd) / len(n.group)
+	}
+	var gw, gb []float64
+	if n.Weight != nil {
+		gw = make([]float64, n.channels)
+		gb = make([]float64, n.channels)
+	}
+
+	// dxhat is the gradient with respect to the normalized values; sum and dot are its per-group reductions
+	dxhat := make([]float64, len(gd))
+	sum := make([]float64, len(n.invStd))
+	dot := make([]float64, len(n.invStd))
+	for p, g := range n.group {
+		c := p % n.channels
+		for i := p * spatial; i < (p+1)*spatial; i++ {
+			d := gd[i]
+			if gw != nil {
+				gw[c] += d * n.xhat[i]
+				gb[c] += d
+				d *= n.Weight.Value.Data()[c]
+			}
+			dxhat[i] = d
+			sum[g] += d
+			dot[g] += d * n.xhat[i]
+		}
+	}
+	dx := make([]float64, len(gd))
+	for p, g := range n.group {
+		for i := p * spatial; i < (p+1)*spatial; i++ {
+			if n.fixed {
+				dx[i] = dxhat[i] * n.invStd[g]
+			} else {
+				dx[i] = n.invStd[g] / n.counts[g] * (n.counts[g]*dxhat[i] - sum[g] - n.xhat[i]*dot[g])
+			}
+		}
+	}
+	if gw != nil {
+		dw, _ := numpy.NewArray(gw, n.channels)
+		db, _ := numpy.NewArray(gb, n.channels)
+		if err := n.Weight.AccumulateGrad(dw); err != nil {
+			return nil, err
+		}
+		if err := n.Bias.AccumulateGrad(db); err != nil {
+			return nil, err
+		}
+	}
+	return numpy.NewArray(dx, n.shape...)
+}
+
+// Parameters returns the scale and shift, or nil without an affine transform.
+func (n *channelNorm) Parameters() []*Parameter {
+	if n.Weight == nil {
+		return nil
+	}
+	return []*Parameter{n.Weight, n.Bias}
+}
+
+// runningStats holds the running mean and variance tracked by BatchNorm and InstanceNorm.
+//
+// Fields:
+//
+//	RunningMean, RunningVar (*numpy.NDArray): The running estimates of the per-channel mean and unbiased variance,
+//	                                          nil if statistics are not tracked.
+//	Momentum (float64): The weight of the current batch in the running estimates,
+//	                    running = (1 - Momentum) * running + Momentum * batch.
+type runningStats struct {
+	RunningMean *numpy.NDArray
+	RunningVar  *numpy.NDArray
+	Momentum    float64
+
+	training bool
+}
+
+// newRunningStats creates running statistics starting at zero mean and unit variance if track is set.
+func newRunningStats(channels int, momentum float64, track bool) (runningStats, error) {
+	if momentum < 0 || momentum > 1 {
+		return runningStats{}, fmt.Errorf("momentum must be in [0, 1], got %v", momentum)
+	}
+	r := runningStats{Momentum: momentum, training: true}
+	if track {
+		r.RunningMean = numpy.ZerosArray(channels)
+		r.RunningVar = numpy.OnesArray(channels)
+	}
+	return r, nil
+}
+
+// SetTraining switches between training mode, which normalizes with the statistics of each batch and updates the
+// running estimates, and evaluation mode, which normalizes with the running estimates.
+func (r *runningStats) SetTraining(training bool) {
+	r.training = training
+}
+
+// useRunning reports whether the running estimates should be used for normalization.
+func (r *runningStats) useRunning() bool {
+	return !r.training && r.RunningMean != nil
+}
+
+// update moves the running estimates towards per-channel batch statistics, correcting the variance of count
+// elements to its unbiased estimate as PyTorch does.
+func (r *runningStats) update(mean, variance []float64, count float64) {
+	if !r.training || r.RunningMean == nil {
+		return
+	}
+	rm, rv := r.RunningMean.Data(), r.RunningVar.Data()
+	correction := 1.
+	if count > 1 {
+		correction = count / (count - 1)
+	}
+	for c := range rm {
+		rm[c] = (1-r.Momentum)*rm[c] + r.Momentum*mean[c]
+		rv[c] = (1-r.Momentum)*rv[c] + r.Momentum*variance[c]*correction
+	}
+}
+
+// Buffers returns the running mean and variance, or nil if statistics are not tracked.
+func (r *runningStats) Buffers() map[string]*numpy.NDArray {
+	if r.RunningMean == nil {
+		return nil
+	}
+	return map[string]*numpy.NDArray{"running_mean": r.RunningMean, "running_var": r.RunningVar}
+}
+
+// BatchNorm normalizes every channel over the batch and spatial axes (Ioffe and Szegedy, 2015), like
+// torch.nn.BatchNorm1d, BatchNorm2d and BatchNorm3d. It accepts inputs of shape (batch, channels),
+// (batch, channels, length), (batch, channels, height, width) and so on.
+//
+// In training mode each batch is normalized with its own statistics and the running estimates are updated; in
+// evaluation mode the running estimates are used. The running estimates are available from Buffers so they can be
+// saved with the model.
+type BatchNorm struct {
+	channelNorm
+	runningStats
+}
+
+// NewBatchNorm creates a batch normalization layer.
+//
+// Parameters:
+//
+//	numFeatures (int): The number of channels (axis 1 of the input).
+//	eps (float64): A value added to the variance for numerical stability, typically 1e-5.
+//	momentum (float64): The weight of each batch in the running estimates, typically 0.1.
+//	affine (bool): Whether to learn a per-channel scale and shift.
+//	trackRunningStats (bool): Whether to keep running estimates. Without them batch statistics are also used in
+//	                          evaluation mode.
+//
+// Returns:
+//
+//	(*BatchNorm, error): The new layer, or nil and an error if an argument is invalid.
+//
+// Errors:
+//
+//	Returns an error if numFeatures is not positive or momentum is not in [0, 1].
+func NewBatchNorm(numFeatures int, eps, momentum float64, affine, trackRunningStats bool) (*BatchNorm, error) {
+	n, err := newChannelNorm(numFeatures, eps, affine)
+	if err != nil {
+		return nil, err
+	}
+	r, err := newRunningStats(numFeatures, momentum, trackRunningStats)
+	if err != nil {
+		return nil, err
+	}
+	return &BatchNorm{n, r}, nil
+}
+
+// Forward normalizes x of shape (batch, numFeatures, ...).
+//
+// Errors:
+//
+//	Returns an error if axis 1 of x does not have numFeatures channels, or a training batch has a single value per
+//	channel.
+func (b *BatchNorm) Forward(x *numpy.NDArray) (*numpy.NDArray, error) {
+	if err := b.checkInput(x); err != nil {
+		return nil, err
+	}
+	channelOf := func(sample, channel int) int { return channel }
+	if b.useRunning() {
+		y, _, _ := b.forward(x, b.channels, channelOf, b.RunningMean.Data(), b.RunningVar.Data())
+		return y, nil
+	}
+	count := x.Size() / b.channels
+	if b.training && count <= 1 {
+		return nil, fmt.Errorf("expected more than 1 value per channel when training, got input of shape %v", x.Shape())
+	}
+	y, mean, variance := b.forward(x, b.channels, channelOf, nil, nil)
+	b.update(mean, variance, float64(count))
+	return y, nil
+}
+
+// Backward accumulates the gradients of the scale and shift and returns the gradient with respect to the input.
+//
+// Errors:
+//
+//	Returns an error if Forward has not been called or grad does not match the output of Forward.
+func (b *BatchNorm) Backward(grad *numpy.NDArray) (*numpy.NDArray, error) {
+	return b.backward(grad)
+}
+
+// InstanceNorm normalizes every channel of every sample over its spatial axes (Ulyanov et al., 2016), like
+// torch.nn.InstanceNorm1d and InstanceNorm2d, for inputs of shape (batch, channels, ...) with at least one spatial
+// axis.
+//
+// With trackRunningStats the per-channel statistics, averaged over the batch, are tracked and used in evaluation
+// mode as in PyTorch.
+type InstanceNorm struct {
+	channelNorm
+	runningStats
+}
+
+// NewInstanceNorm creates an instance normalization layer. The parameters are as for NewBatchNorm; PyTorch's
+// defaults are affine and trackRunningStats false.
+//
+// Errors:
+//
+//	Returns an error if numFeatures is not positive or momentum is not in [0, 1].
+func NewInstanceNorm(numFeatures int, eps, momentum float64, affine, trackRunningStats bool) (*InstanceNorm, error) {
+	n, err := newChannelNorm(numFeatures, eps, affine)
+	if err != nil {
+		return nil, err
+	}
+	r, err := newRunningStats(numFeatures, momentum, trackRunningStats)
+	if err != nil {
+		return nil, err
+	}
+	return &InstanceNorm{n, r}, nil
+}
+
+// Forward normalizes x of shape (batch, numFeatures, ...).
+//
+// Errors:
+//
+//	Returns an error if x has no spatial axis or axis 1 does not have numFeatures channels.
+func (in *InstanceNorm) Forward(x *numpy.NDArray) (*numpy.NDArray, error) {
+	if err := in.checkInput(x); err != nil {
+		return nil, err
+	}
+	shape := x.Shape()
+	if len(shape) < 3 {
+		return nil, fmt.Errorf("expected input of shape (batch, channels, ...) with at least one spatial axis, got %v", shape)
+	}
+	if in.useRunning() {
+		// Every sample uses the running statistics of its channel
+		mean := make([]float64, shape[0]*in.channels)
+		variance := make([]float64, len(mean))
+		for p := range mean {
+			mean[p] = in.RunningMean.Data()[p%in.channels]
+			variance[p] = in.RunningVar.Data()[p%in.channels]
+		}
+		y, _, _ := in.forward(x, len(mean), func(s, c int) int { return s*in.channels + c }, mean, variance)
+		return y, nil
+	}
+	y, mean, variance := in.forward(x, shape[0]*in.channels, func(s, c int) int { return s*in.channels + c }, nil, nil)
+	if x.Size() == 0 {
+		// An empty batch has no statistics to track
+		return y, nil
+	}
+
+	// The running estimates average the per-instance statistics over the batch
+	cm := make([]float64, in.channels)
+	cv := make([]float64, in.channels)
+	for p := range mean {
+		cm[p%in.channels] += mean[p] / float64(shape[0])
+		cv[p%in.channels] += variance[p] / float64(shape[0])
+	}
+	in.update(cm, cv, float64(x.Size()/(shape[0]*in.channels)))
+	return y, nil
+}
+
+// Backward accumulates the gradients of the scale and shift and returns the gradient with respect to the input.
+//
+// Errors:
+//
+//	Returns an error if Forward has not been called or grad does not match the output of Forward.
+func (in *InstanceNorm) Backward(grad *numpy.NDArray) (*numpy.NDArray, error) {
+	return in.backward(grad)
+}
+
+// GroupNorm divides the channels into groups and normalizes every group of every sample over its channels and
+// spatial axes (Wu and He, 2018), like torch.nn.GroupNorm. It behaves the same in training and evaluation and does
+// not depend on the batch size. One group is equivalent to layer normalization over (channels, ...) with a
+// per-channel affine transform, and as many groups as channels is instance normalization.
+type GroupNorm struct {
+	channelNorm
+	NumGroups int
+}
+
+// NewGroupNorm creates a group normalization layer.
+//
+// Parameters:
+//
+//	numGroups (int): The number of groups. numChannels must be divisible by it.
+//	numChannels (int): The number of channels (axis 1 of the input).
+//	eps (float64): A value added to the variance for numerical stability, typically 1e-5.
+//	affine (bool): Whether to learn a per-channel scale and shift.
+//
+// Returns:
+//
+//	(*GroupNorm, error): The new layer, or nil and an error if the sizes are invalid.
+//
+// Errors:
+//
+//	Returns an error if a size is not positive or numChannels is not divisible by numGroups.
+func NewGroupNorm(numGroups, numChannels int, eps float64, affine bool) (*GroupNorm, error) {
+	if numGroups <= 0 || numChannels%numGroups != 0 {
+		return nil, fmt.Errorf("numChannels (%v) must be divisible by numGroups (%v)", numChannels, numGroups)
+	}
+	n, err := newChannelNorm(numChannels, eps, affine)
+	if err != nil {
+		return nil, err
+	}
+	return &GroupNorm{n, numGroups}, nil
+}
+
+// Forward normalizes x of shape (batch, numChannels, ...).
+//
+// Errors:
+//
+//	Returns an error if axis 1 of x does not have numChannels channels.
+func (g *GroupNorm) Forward(x *numpy.NDArray) (*numpy.NDArray, error) {
+	if err := g.checkInput(x); err != nil {
+		return nil, err
+	}
+	perGroup := g.channels / g.NumGroups
+	y, _, _ := g.forward(x, x.Shape()[0]*g.NumGroups, func(s, c int) int { return s*g.NumGroups + c/perGroup }, nil, nil)
+	return y, nil
+}
+
+// Backward accumulates the gradients of the scale and shift and returns the gradient with respect to the input.
+//
+// Errors:
+//
+//	Returns an error if Forward has not been called or grad does not match the output of Forward.
+func (g *GroupNorm) Backward(grad *numpy.NDArray) (*numpy.NDArray, error) {
+	return g.backward(grad)
+}
@@ -0,0 +1,413 @@
+package nn
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/timotewb/gonn/numpy"
+)
+
+// pool2d holds the configuration and cached state shared by MaxPool2D and AvgPool2D.
+type pool2d struct {
+	KernelSize [2]int
+	Stride     [2]int
+	Padding    [2]int
+
+	geom   convGeometry
+	shape  []int
+	argmax []int
+}
+
+// newPool2d parses the window arguments of a pooling layer. A nil stride defaults to the kernel size, so windows do
+// not overlap.
+func newPool2d(kernelSize, stride, padding interface{}) (pool2d, error) {
+	var p pool2d
+	var err error
+	if p.KernelSize, err = positivePair(kernelSize, "kernelSize"); err != nil {
+		return p, err
+	}
+	p.Stride = p.KernelSize
+	if stride != nil {
+		if p.Stride, err = positivePair(stride, "stride"); err != nil {
+			return p, err
+		}
+	}
+	if p.Padding, err = pair(padding, "padding"); err != nil {
+		return p, err
+	}
+	for i := range p.Padding {
+		if p.Padding[i] < 0 || p.Padding[i] > p.KernelSize[i]/2 {
+			return p, fmt.Errorf("padding should be at most half of the kernel size, got padding %v and kernel size %v", p.Padding, p.KernelSize)
+		}
+	}
+	return p, nil
+}
+
+// geometry computes the sliding windows for an input of shape (batch, channels, height, width).
+func (p *pool2d) geometry(shape []int) (convGeometry, error) {
+	if len(shape) != 4 {
+		return convGeometry{}, fmt.Errorf("expected input of shape (batch, channels, height, width), got %v", shape)
+	}
+	pad := [4]int{p.Padding[0], p.Padding[0], p.Padding[1], p.Padding[1]}
+	return newConvGeometry(shape, p.KernelSize, p.Stride, [2]int{1, 1}, pad)
+}
+
+// windows calls fn for every output position with the index of the output element and the indices of the input
+// elements in its window that are not padding.
+func (g convGeometry) windows(fn func(out int, in []int)) {
+	in := make([]int, 0, g.kh*g.kw)
+	out := 0
+	for plane := 0; plane < g.n*g.c; plane++ {
+		for oy := 0; oy < g.outH; oy++ {
+			for ox := 0; ox < g.outW; ox++ {
+				in = in[:0]
+				for ky := 0; ky < g.kh; ky++ {
+					y := oy*g.sh - g.pad[0] + ky*g.dh
+					for kx := 0; kx < g.kw; kx++ {
+						x := ox*g.sw - g.pad[2] + kx*g.dw
+						if y >= 0 && y < g.h && x >= 0 && x < g.w {
+							in = append(in, (plane*g.h+y)*g.w+x)
+						}
+					}
+				}
+				fn(out, in)
+				out++
+			}
+		}
+	}
+}
+
+// MaxPool2D takes the maximum over sliding windows of inputs of shape (batch, channels, height, width). Padding is
+// ignored when taking the maximum. Backward routes each gradient to the element that was the maximum of its window.
+type MaxPool2D struct {
+	pool2d
+}
+
+// NewMaxPool2D creates a 2-D max pooling layer.
+//
+// Parameters:
+//
+//	kernelSize (interface{}): The size of the window, as an int or a pair of ints (height, width).
+//	stride (interface{}): The step between windows, as an int or a pair of ints. nil uses the kernel size.
+//	padding (interface{}): The implicit padding on each side, as an int or a pair of ints. At most half the kernel.
+//
+// Returns:
+//
+//	(*MaxPool2D, error): The new layer, or nil and an error if an argument is invalid.
+//
+// Errors:
+//
+//	Returns an error if a size is not positive or the padding is larger than half the kernel.
+func NewMaxPool2D(kernelSize, stride, padding interface{}) (*MaxPool2D, error) {
+	p, err := newPool2d(kernelSize, stride, padding)
+	if err != nil {
+		return nil, err
+	}
+	return &MaxPool2D{p}, nil
+}
+
+// Forward computes the maximum of every window of x.
+//
+// Errors:
+//
+//	Returns an error if x is not 4-D or is smaller than the window.
+func (m *MaxPool2D) Forward(x *numpy.NDArray) (*numpy.NDArray, error) {
+	g, err := m.geometry(x.Shape())
+	if err != nil {
+		return nil, err
+	}
+	xd := x.Data()
+	out := make([]float64, g.n*g.c*g.outH*g.outW)
+	argmax := make([]int, len(out))
+	g.windows(func(o int, in []int) {
+		best := in[0]
+		for _, i := range in[1:] {
+			if xd[i] > xd[best] || math.IsNaN(xd[i]) {
+				best = i
+			}
+		}
+		out[o], argmax[o] = xd[best], best
+	})
+	m.geom, m.shape, m.argmax = g, x.Shape(), argmax
+	return numpy.NewArray(out, g.n, g.c, g.outH, g.outW)
+}
+
+// Backward passes each gradient to the input element that was the maximum of its window.
+//
+// Errors:
+//
+//	Returns an error if Forward has not been called or grad does not match the output of Forward.
+func (m *MaxPool2D) Backward(grad *numpy.NDArray) (*numpy.NDArray, error) {
+	if m.argmax == nil {
+		return nil, fmt.Errorf("backward called before forward")
+	}
+	g := m.geom
+	if err := checkShape(grad, []int{g.n, g.c, g.outH, g.outW}); err != nil {
+		return nil, err
+	}
+	dx := make([]float64, g.n*g.c*g.h*g.w)
+	for o, v := range grad.Data() {
+		dx[m.argmax[o]] += v
+	}
+	return numpy.NewArray(dx, m.shape...)
+}
+
+// Parameters returns nil because MaxPool2D has no learnable parameters.
+func (m *MaxPool2D) Parameters() []*Parameter { return nil }
+
+// AvgPool2D averages sliding windows of inputs of shape (batch, channels, height, width). Padded positions count as
+// zeros in the average, as with PyTorch's default count_include_pad.
+type AvgPool2D struct {
+	pool2d
+}
+
+// NewAvgPool2D creates a 2-D average pooling layer. The parameters are as for NewMaxPool2D.
+//
+// Errors:
+//
+//	Returns an error if a size is not positive or the padding is larger than half the kernel.
+func NewAvgPool2D(kernelSize, stride, padding interface{}) (*AvgPool2D, error) {
+	p, err := newPool2d(kernelSize, stride, padding)
+	if err != nil {
+		return nil, err
+	}
+	return &AvgPool2D{p}, nil
+}
+
+// Forward computes the average of every window of x.
+//
+// Errors:
+//
+//	Returns an error if x is not 4-D or is smaller than the window.
+func (a *AvgPool2D) Forward(x *numpy.NDArray) (*numpy.NDArray, error) {
+	g, err := a.geometry(x.Shape())
+	if err != nil {
+		return nil, err
+	}
+	xd := x.Data()
+	area := float64(g.kh * g.kw)
+	out := make([]float64, g.n*g.c*g.outH*g.outW)
+	g.windows(func(o int, in []int) {
+		for _, i := range in {
+			out[o] += xd[i]
+		}
+		out[o] /= area
+	})
+	a.geom, a.shape = g, x.Shape()
+	return numpy.NewArray(out, g.n, g.c, g.outH, g.outW)
+}
+
+// Backward spreads each gradient evenly over its window.
+//
+// Errors:
+//
+//	Returns an error if Forward has not been called or grad does not match the output of Forward.
+func (a *AvgPool2D) Backward(grad *numpy.NDArray) (*numpy.NDArray, error) {
+	if a.shape == nil {
+		return nil, fmt.Errorf("backward called before forward")
+	}
+	g := a.geom
+	if err := checkShape(grad, []int{g.n, g.c, g.outH, g.outW}); err != nil {
+		return nil, err
+	}
+	gd := grad.Data()
+	area := float64(g.kh * g.kw)
+	dx := make([]float64, g.n*g.c*g.h*g.w)
+	g.windows(func(o int, in []int) {
+		for _, i := range in {
+			dx[i] += gd[o] / area
+		}
+	})
+	return numpy.NewArray(dx, a.shape...)
+}
+
+// Parameters returns nil because AvgPool2D has no learnable parameters.
+func (a *AvgPool2D) Parameters() []*Parameter { return nil }
+
+// pool1d adapts a 2-D pooling layer to inputs of shape (batch, channels, length) by treating them as images of
+// height one.
+type pool1d struct {
+	layer Layer
+}
+
+// Forward pools x, a batch of shape (batch, channels, length).
+func (p *pool1d) Forward(x *numpy.NDArray) (*numpy.NDArray, error) {
+	shape := x.Shape()
+	if len(shape) != 3 {
+		return nil, fmt.Errorf("expected input of shape (batch, channels, length), got %v", shape)
+	}
+	x4, err := x.Reshape(shape[0], shape[1], 1, shape[2])
+	if err != nil {
+		return nil, err
+	}
+	y, err := p.layer.Forward(x4)
+	if err != nil {
+		return nil, err
+	}
+	ys := y.Shape()
+	return y.Reshape(ys[0], ys[1], ys[3])
+}
+
+// Backward returns the gradient with respect to the input.
+func (p *pool1d) Backward(grad *numpy.NDArray) (*numpy.NDArray, error) {
+	shape := grad.Shape()
+	if len(shape) != 3 {
+		return nil, fmt.Errorf("expected gradient of shape (batch, channels, length), got %v", shape)
+	}
+	g4, err := grad.Reshape(shape[0], shape[1], 1, shape[2])
+	if err != nil {
+		return nil, err
+	}
+	dx, err := p.layer.Backward(g4)
+	if err != nil {
+		return nil, err
+	}
+	xs := dx.Shape()
+	return dx.Reshape(xs[0], xs[1], xs[3])
+}
+
+// Parameters returns nil because pooling layers have no learnable parameters.
+func (p *pool1d) Parameters() []*Parameter { return nil }
+
+// MaxPool1D takes the maximum over sliding windows of inputs of shape (batch, channels, length).
+type MaxPool1D struct {
+	pool1d
+}
+
+// NewMaxPool1D creates a 1-D max pooling layer. A stride of 0 uses the kernel size.
+//
+// Errors:
+//
+//	Returns an error if kernelSize is not positive, stride is negative or padding is larger than half the kernel.
+func NewMaxPool1D(kernelSize, stride, padding int) (*MaxPool1D, error) {
+	if stride == 0 {
+		stride = kernelSize
+	}
+	m, err := NewMaxPool2D([]int{1, kernelSize}, []int{1, stride}, []int{0, padding})
+	if err != nil {
+		return nil, err
+	}
+	return &MaxPool1D{pool1d{m}}, nil
+}
+
+// AvgPool1D averages sliding windows of inputs of shape (batch, channels, length).
+type AvgPool1D struct {
+	pool1d
+}
+
+// NewAvgPool1D creates a 1-D average pooling layer. A stride of 0 uses the kernel size.
+//
+// Errors:
+//
+//	Returns an error if kernelSize is not positive, stride is negative or padding is larger than half the kernel.
+func NewAvgPool1D(kernelSize, stride, padding int) (*AvgPool1D, error) {
+	if stride == 0 {
+		stride = kernelSize
+	}
+	a, err := NewAvgPool2D([]int{1, kernelSize}, []int{1, stride}, []int{0, padding})
+	if err != nil {
+		return nil, err
+	}
+	return &AvgPool1D{pool1d{a}}, nil
+}
+
+// globalPool reduces every channel of an input of shape (batch, channels, ...) to one value.
+type globalPool struct {
+	max    bool
+	shape  []int
+	argmax []int
+}
+
+// Forward reduces the spatial axes of x, returning an array of shape (batch, channels). An empty batch gives an
+// empty result.
+//
+// Errors:
+//
+//	Returns an error if x has no spatial axis or a spatial axis is empty.
+func (p *globalPool) Forward(x *numpy.NDArray) (*numpy.NDArray, error) {
+	shape := x.Shape()
+	if len(shape) < 3 {
+		return nil, fmt.Errorf("expected input of shape (batch, channels, ...) with at least one spatial axis, got %v", shape)
+	}
+	planes, area := shape[0]*shape[1], 0
+	if planes > 0 {
+		area = x.Size() / planes
+		if area == 0 {
+			return nil, fmt.Errorf("cannot pool over empty spatial axes, got input of shape %v", shape)
+		}
+	}
+	xd := x.Data()
+	out := make([]float64, planes)
+	p.argmax = make([]int, planes)
+	for i := range out {
+		lane := xd[i*area : (i+1)*area]
+		if p.max {
+			best := 0
+			for j, v := range lane {
+				if v > lane[best] || math.IsNaN(v) {
+					best = j
+				}
+			}
+			out[i], p.argmax[i] = lane[best], i*area+best
+			continue
+		}
+		for _, v := range lane {
+			out[i] += v
+		}
+		out[i] /= float64(area)
+	}
+	p.shape = shape
+	return numpy.NewArray(out, shape[0], shape[1])
+}
+
+// Backward returns the gradient with respect to the input.
+func (p *globalPool) Backward(grad *numpy.NDArray) (*numpy.NDArray, error) {
+	if p.shape == nil {
+		return nil, fmt.Errorf("backward called before forward")
+	}
+	if err := checkShape(grad, p.shape[:2]); err != nil {
+		return nil, err
+	}
+	planes := p.shape[0] * p.shape[1]
+	dx := numpy.ZerosArray(p.shape...)
+	if planes == 0 {
+		return dx, nil
+	}
+	dd := dx.Data()
+	area := len(dd) / planes
+	for i, v := range grad.Data() {
+		if p.max {
+			dd[p.argmax[i]] = v
+			continue
+		}
+		for j := i * area; j < (i+1)*area; j++ {
+			dd[j] = v / float64(area)
+		}
+	}
+	return dx, nil
+}
+
+// Parameters returns nil because pooling layers have no learnable parameters.
+func (p *globalPool) Parameters() []*Parameter { return nil }
+
+// GlobalAvgPool averages every channel of an input of shape (batch, channels, ...) over all of its spatial axes,
+// returning an array of shape (batch, channels). It works for 1-D, 2-D and higher dimensional inputs.
+type GlobalAvgPool struct {
+	globalPool
+}
+
+// NewGlobalAvgPool creates a global average pooling layer.
+func NewGlobalAvgPool() *GlobalAvgPool {
+	return &GlobalAvgPool{}
+}
+
+// GlobalMaxPool takes the maximum of every channel of an input of shape (batch, channels, ...) over all of its
+// spatial axes, returning an array of shape (batch, channels).
+type GlobalMaxPool struct {
+	globalPool
+}
+
+// NewGlobalMaxPool creates a global max pooling layer.
+func NewGlobalMaxPool() *GlobalMaxPool {
+	return &GlobalMaxPool{globalPool{max: true}}
+}
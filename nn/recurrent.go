@@ -0,0 +1,460 @@
+package nn
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/timotewb/gonn/numpy"
+	"github.com/timotewb/gonn/numpy/random"
+)
+
+// recurrentCell computes the element-wise part of one time step of a recurrent layer for a batch of rows.
+//
+// xw holds the input projection x @ W_ih + b_ih and hw the recurrent projection h @ W_hh + b_hh, both with gates()
+// blocks of hidden columns per row. h and c are the previous hidden and cell states (c is only used by LSTM).
+type recurrentCell interface {
+	gates() int
+	step(xw, hw, h, c []float64, rows, hidden int) (hNew, cNew, cache []float64)
+	stepBackward(dh, dc, h, c, cache []float64, rows, hidden int) (dxw, dhw, dhPrev, dcPrev []float64)
+}
+
+// recurrentDirection holds the parameters of one layer in one direction and the values cached by Forward.
+type recurrentDirection struct {
+	weightIH, weightHH, biasIH, biasHH *Parameter
+	reverse                            bool
+
+	input   *numpy.NDArray
+	times   []int
+	hs, cs  [][]float64
+	caches  [][]float64
+	hFinal  []float64
+	cFinal  []float64
+	stateH  []float64
+	stateC  []float64
+	batches int
+}
+
+// recurrent implements the layout handling, stacking, bidirectional processing, masking and backpropagation through
+// time shared by RNN, GRU and LSTM.
+//
+// Fields:
+//
+//	InputSize, HiddenSize, NumLayers (int): The size of the input features and hidden state, and the number of
+//	                                       stacked layers.
+//	Bidirectional (bool): Whether each layer also processes the sequence in reverse. The outputs of both directions
+//	                      are concatenated, giving 2 * HiddenSize features.
+//	BatchFirst (bool): Whether inputs and outputs have shape (batch, time, features) instead of
+//	                   (time, batch, features).
+//	ReturnSequences (bool): Whether Forward returns the output of the last layer at every time step. Otherwise it
+//	                        returns only the final hidden state of the last layer, of shape (batch, features).
+//	Stateful (bool): Whether the final states of one Forward call are used as the initial states of the next, so a
+//	                 long sequence can be fed in consecutive chunks. Gradients do not flow between calls, which gives
+//	                 truncated backpropagation through time. Call ResetState between independent sequences.
+//	BPTTSteps (int): If positive, gradients flow back through at most this many time steps: the gradient with
+//	                 respect to the state is cut every BPTTSteps steps. 0 backpropagates through the whole sequence.
+type recurrent struct {
+	InputSize       int
+	HiddenSize      int
+	NumLayers       int
+	Bidirectional   bool
+	BatchFirst      bool
+	ReturnSequences bool
+	Stateful        bool
+	BPTTSteps       int
+
+	cell    recurrentCell
+	layers  [][]*recurrentDirection
+	lengths []int
+	mask    [][]bool
+	shape   []int
+}
+
+// newRecurrent validates the sizes and creates the parameters of every layer and direction, named as in PyTorch
+// (weight_ih_l0, bias_hh_l1_reverse, ...).
+func newRecurrent(cell recurrentCell, inputSize, hiddenSize, numLayers int, bidirectional, batchFirst bool) (recurrent, error) {
+	if inputSize <= 0 || hiddenSize <= 0 || numLayers <= 0 {
+		return recurrent{}, fmt.Errorf("inputSize, hiddenSize and numLayers must be positive, got %v, %v and %v", inputSize, hiddenSize, numLayers)
+	}
+	r := recurrent{
+		InputSize:       inputSize,
+		HiddenSize:      hiddenSize,
+		NumLayers:       numLayers,
+		Bidirectional:   bidirectional,
+		BatchFirst:      batchFirst,
+		ReturnSequences: true,
+		cell:            cell,
+	}
+	scale := math.Sqrt(1 / float64(hiddenSize))
+	g := cell.gates() * hiddenSize
+	for l := 0; l < numLayers; l++ {
+		in := inputSize
+		if l > 0 {
+			in = hiddenSize * r.directions()
+		}
+		dirs := []*recurrentDirection{}
+		for d := 0; d < r.directions(); d++ {
+			suffix := fmt.Sprintf("_l%v", l)
+			if d == 1 {
+				suffix += "_reverse"
+			}
+			wih, err := numpy.Array(random.Randn(in, g))
+			if err != nil {
+				return recurrent{}, err
+			}
+			whh, err := numpy.Array(random.Randn(hiddenSize, g))
+			if err != nil {
+				return recurrent{}, err
+			}
+			dirs = append(dirs, &recurrentDirection{
+				weightIH: NewParameter("weight_ih"+suffix, wih.Scale(scale)),
+				weightHH: NewParameter("weight_hh"+suffix, whh.Scale(scale)),
+				biasIH:   NewParameter("bias_ih"+suffix, numpy.ZerosArray(g)),
+				biasHH:   NewParameter("bias_hh"+suffix, numpy.ZerosArray(g)),
+				reverse:  d == 1,
+			})
+		}
+		r.layers = append(r.layers, dirs)
+	}
+	return r, nil
+}
+
+// directions returns 2 for bidirectional layers and 1 otherwise.
+func (r *recurrent) directions() int {
+	if r.Bidirectional {
+		return 2
+	}
+	return 1
+}
+
+// SetLengths sets the valid length of every sequence in the batch for the following Forward calls, so batches of
+// padded sequences can be processed together. Time steps at or beyond a sequence's length do not change its state
+// and produce zero outputs, and the reverse direction starts from the last valid step. nil treats every sequence as
+// full length.
+func (r *recurrent) SetLengths(lengths []int) {
+	r.lengths = append([]int{}, lengths...)
+	if lengths == nil {
+		r.lengths = nil
+	}
+}
+
+// ResetState clears the states kept between calls by a stateful layer, so the next sequence starts from zeros.
+func (r *recurrent) ResetState() {
+	for _, dirs := range r.layers {
+		for _, d := range dirs {
+			d.stateH, d.stateC = nil, nil
+		}
+	}
+}
+
+// Parameters returns the weights and biases of every layer and direction.
+func (r *recurrent) Parameters() []*Parameter {
+	params := []*Parameter{}
+	for _, dirs := range r.layers {
+		for _, d := range dirs {
+			params = append(params, d.weightIH, d.weightHH, d.biasIH, d.biasHH)
+		}
+	}
+	return params
+}
+
+// Forward runs the layers over a batch of sequences.
+//
+// x has shape (batch, time, InputSize) if BatchFirst is set and (time, batch, InputSize) otherwise. The output has
+// the same layout with HiddenSize features (twice that if Bidirectional), or shape (batch, features) holding the
+// final hidden state if ReturnSequences is false.
+//
+// Errors:
+//
+//	Returns an error if x does not have three dimensions with InputSize features, the lengths set with SetLengths
+//	do not match the batch, or a stateful layer receives a different batch size.
+func (r *recurrent) Forward(x *numpy.NDArray) (*numpy.NDArray, error) {
+	shape := x.Shape()
+	if len(shape) != 3 || shape[2] != r.InputSize {
+		return nil, fmt.Errorf("expected input of 3 dimensions with %v features, got shape %v", r.InputSize, shape)
+	}
+	if r.BatchFirst {
+		t, err := x.SwapAxes(0, 1)
+		if err != nil {
+			return nil, err
+		}
+		x = t
+	}
+	steps, batches := x.Shape()[0], x.Shape()[1]
+
+	// mask[t][b] is true where the time step is padding
+	r.mask = make([][]bool, steps)
+	for t := range r.mask {
+		r.mask[t] = make([]bool, batches)
+	}
+	if r.lengths != nil {
+		if len(r.lengths) != batches {
+			return nil, fmt.Errorf("got %v sequence lengths for a batch of %v", len(r.lengths), batches)
+		}
+		for b, n := range r.lengths {
+			if n < 0 || n > steps {
+				return nil, fmt.Errorf("sequence length %v is out of range for %v time steps", n, steps)
+			}
+			for t := n; t < steps; t++ {
+				r.mask[t][b] = true
+			}
+		}
+	}
+
+	input, err := x.AsContiguous().Reshape(steps*batches, -1)
+	if err != nil {
+		return nil, err
+	}
+	hidden := r.HiddenSize
+	width := hidden * r.directions()
+	var out []float64
+	for _, dirs := range r.layers {
+		out = make([]float64, steps*batches*width)
+		for di, d := range dirs {
+			h, err := r.forwardDirection(d, input, steps, batches)
+			if err != nil {
+				return nil, err
+			}
+			for row := 0; row < steps*batches; row++ {
+				copy(out[row*width+di*hidden:row*width+(di+1)*hidden], h[row*hidden:(row+1)*hidden])
+			}
+		}
+		input, _ = numpy.NewArray(out, steps*batches, width)
+	}
+	r.shape = shape
+
+	if !r.ReturnSequences {
+		final := make([]float64, batches*width)
+		for di, d := range r.layers[len(r.layers)-1] {
+			for b := 0; b < batches; b++ {
+				copy(final[b*width+di*hidden:b*width+(di+1)*hidden], d.hFinal[b*hidden:(b+1)*hidden])
+			}
+		}
+		return numpy.NewArray(final, batches, width)
+	}
+	y, _ := numpy.NewArray(out, steps, batches, width)
+	if r.BatchFirst {
+		t, _ := y.SwapAxes(0, 1)
+		y = t.AsContiguous()
+	}
+	return y, nil
+}
+
+// forwardDirection runs one layer in one direction over input of shape (time * batch, features), returning the
+// hidden state at every time step with padded steps set to zero.
+func (r *recurrent) forwardDirection(d *recurrentDirection, input *numpy.NDArray, steps, batches int) ([]float64, error) {
+	hidden := r.HiddenSize
+	g := r.cell.gates() * hidden
+	xw, err := numpy.Matmul(input, d.weightIH.Value)
+	if err != nil {
+		return nil, err
+	}
+	if xw, err = xw.Add(d.biasIH.Value); err != nil {
+		return nil, err
+	}
+	xwd := xw.Data()
+
+	h := make([]float64, batches*hidden)
+	c := make([]float64, batches*hidden)
+	if r.Stateful && d.stateH != nil {
+		if d.batches != batches {
+			return nil, fmt.Errorf("stateful layer expects a batch of %v, got %v; call ResetState to start new sequences", d.batches, batches)
+		}
+		copy(h, d.stateH)
+		copy(c, d.stateC)
+	}
+
+	d.input = input
+	d.times = make([]int, steps)
+	d.hs = make([][]float64, steps)
+	d.cs = make([][]float64, steps)
+	d.caches = make([][]float64, steps)
+	out := make([]float64, steps*batches*hidden)
+	for s := 0; s < steps; s++ {
+		t := s
+		if d.reverse {
+			t = steps - 1 - s
+		}
+		hm, _ := numpy.NewArray(h, batches, hidden)
+		hw, err := numpy.Matmul(hm, d.weightHH.Value)
+		if err != nil {
+			return nil, err
+		}
+		if hw, err = hw.Add(d.biasHH.Value); err != nil {
+			return nil, err
+		}
+		hNew, cNew, cache := r.cell.step(xwd[t*batches*g:(t+1)*batches*g], hw.Data(), h, c, batches, hidden)
+		for b := 0; b < batches; b++ {
+			if r.mask[t][b] {
+				copy(hNew[b*hidden:(b+1)*hidden], h[b*hidden:(b+1)*hidden])
+				copy(cNew[b*hidden:(b+1)*hidden], c[b*hidden:(b+1)*hidden])
+				continue
+			}
+			copy(out[(t*batches+b)*hidden:(t*batches+b+1)*hidden], hNew[b*hidden:(b+1)*hidden])
+		}
+		d.times[s], d.hs[s], d.cs[s], d.caches[s] = t, h, c, cache
+		h, c = hNew, cNew
+	}
+	d.hFinal, d.cFinal = h, c
+	if r.Stateful {
+		d.stateH, d.stateC, d.batches = h, c, batches
+	}
+	return out, nil
+}
+
+// Backward backpropagates through time, accumulating the gradients of every parameter and returning the gradient
+// with respect to the input.
+//
+// Errors:
+//
+//	Returns an error if Forward has not been called or grad does not match the output of Forward.
+func (r *recurrent) Backward(grad *numpy.NDArray) (*numpy.NDArray, error) {
+	if r.shape == nil {
+		return nil, fmt.Errorf("backward called before forward")
+	}
+	steps, batches := r.shape[0], r.shape[1]
+	if r.BatchFirst {
+		steps, batches = batches, steps
+	}
+	hidden := r.HiddenSize
+	width := hidden * r.directions()
+
+	// Bring the gradient of the last layer's outputs to time-major layout, or inject it into the final states
+	dOut := make([]float64, steps*batches*width)
+	dFinal := make([]float64, batches*width)
+	if r.ReturnSequences {
+		want := []int{steps, batches, width}
+		if r.BatchFirst {
+			want = []int{batches, steps, width}
+		}
+		if err := checkShape(grad, want); err != nil {
+			return nil, err
+		}
+		if r.BatchFirst {
+			t, _ := grad.SwapAxes(0, 1)
+			grad = t
+		}
+		dOut = grad.Data()
+	} else {
+		if err := checkShape(grad, []int{batches, width}); err != nil {
+			return nil, err
+		}
+		dFinal = grad.Data()
+	}
+
+	for l := len(r.layers) - 1; l >= 0; l-- {
+		var dx []float64
+		for di, d := range r.layers[l] {
+			dh := make([]float64, steps*batches*hidden)
+			for row := 0; row < steps*batches; row++ {
+				copy(dh[row*hidden:(row+1)*hidden], dOut[row*width+di*hidden:row*width+(di+1)*hidden])
+			}
+			dhFinal := make([]float64, batches*hidden)
+			if l == len(r.layers)-1 {
+				for b := 0; b < batches; b++ {
+					copy(dhFinal[b*hidden:(b+1)*hidden], dFinal[b*width+di*hidden:b*width+(di+1)*hidden])
+				}
+			}
+			g, err := r.backwardDirection(d, dh, dhFinal, steps, batches)
+			if err != nil {
+				return nil, err
+			}
+			if dx == nil {
+				dx = g
+			} else {
+				for i := range dx {
+					dx[i] += g[i]
+				}
+			}
+		}
+		dOut = dx
+	}
+
+	out, _ := numpy.NewArray(dOut, steps, batches, r.InputSize)
+	if r.BatchFirst {
+		t, _ := out.SwapAxes(0, 1)
+		out = t.AsContiguous()
+	}
+	return out, nil
+}
+
+// backwardDirection backpropagates one layer in one direction. dOut holds the gradient of the hidden state output at
+// every time step and dhFinal the gradient of the final hidden state. It returns the gradient with respect to the
+// input of shape (time * batch, features).
+func (r *recurrent) backwardDirection(d *recurrentDirection, dOut, dhFinal []float64, steps, batches int) ([]float64, error) {
+	hidden := r.HiddenSize
+	g := r.cell.gates() * hidden
+	dXW := make([]float64, steps*batches*g)
+	dHW := make([]float64, steps*batches*g)
+	hPrev := make([]float64, steps*batches*hidden)
+
+	dh := dhFinal
+	dc := make([]float64, batches*hidden)
+	for s := steps - 1; s >= 0; s-- {
+		t := d.times[s]
+		for b := 0; b < batches; b++ {
+			if r.mask[t][b] {
+				continue
+			}
+			for j := 0; j < hidden; j++ {
+				dh[b*hidden+j] += dOut[(t*batches+b)*hidden+j]
+			}
+		}
+		dxw, dhw, dhp, dcp := r.cell.stepBackward(dh, dc, d.hs[s], d.cs[s], d.caches[s], batches, hidden)
+		for b := 0; b < batches; b++ {
+			if r.mask[t][b] {
+				// Padded steps pass the state through unchanged
+				for j := 0; j < g; j++ {
+					dxw[b*g+j], dhw[b*g+j] = 0, 0
+				}
+				copy(dhp[b*hidden:(b+1)*hidden], dh[b*hidden:(b+1)*hidden])
+				copy(dcp[b*hidden:(b+1)*hidden], dc[b*hidden:(b+1)*hidden])
+			}
+		}
+
+		// The previous state also reaches the output through the recurrent projection h @ W_hh
+		dhwA, _ := numpy.NewArray(dhw, batches, g)
+		rec, err := numpy.Matmul(dhwA, d.weightHH.Value.T())
+		if err != nil {
+			return nil, err
+		}
+		for i, v := range rec.Data() {
+			dhp[i] += v
+		}
+		copy(dXW[t*batches*g:], dxw)
+		copy(dHW[t*batches*g:], dhw)
+		copy(hPrev[t*batches*hidden:], d.hs[s])
+		dh, dc = dhp, dcp
+		if r.BPTTSteps > 0 && s%r.BPTTSteps == 0 {
+			dh = make([]float64, batches*hidden)
+			dc = make([]float64, batches*hidden)
+		}
+	}
+
+	// The parameter gradients for all time steps are single matrix products
+	dxwA, _ := numpy.NewArray(dXW, steps*batches, g)
+	dhwA, _ := numpy.NewArray(dHW, steps*batches, g)
+	hA, _ := numpy.NewArray(hPrev, steps*batches, hidden)
+	gwih, err := numpy.Matmul(d.input.T(), dxwA)
+	if err != nil {
+		return nil, err
+	}
+	gwhh, err := numpy.Matmul(hA.T(), dhwA)
+	if err != nil {
+		return nil, err
+	}
+	gbih, _ := numpy.Sum(dxwA, 0)
+	gbhh, _ := numpy.Sum(dhwA, 0)
+	for _, u := range []struct {
+		p *Parameter
+		g *numpy.NDArray
+	}{{d.weightIH, gwih}, {d.weightHH, gwhh}, {d.biasIH, gbih}, {d.biasHH, gbhh}} {
+		if err := u.p.AccumulateGrad(u.g); err != nil {
+			return nil, err
+		}
+	}
+	dx, err := numpy.Matmul(dxwA, d.weightIH.Value.T())
+	if err != nil {
+		return nil, err
+	}
+	return dx.Data(), nil
+}
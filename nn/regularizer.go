@@ -0,0 +1,85 @@
+package nn
+
+import (
+	"fmt"
+
+	"github.com/timotewb/gonn/numpy"
+)
+
+// Regularizer is a penalty on the values of a parameter that is added to the training loss, like a Keras
+// kernel_regularizer. Penalty returns the penalty and its gradient with respect to the values. The regularizers
+// package provides L1, L2 and elastic net penalties.
+type Regularizer interface {
+	Penalty(w *numpy.NDArray) (float64, *numpy.NDArray, error)
+}
+
+// Constraint restricts the values of a parameter by projecting them after each optimizer step, like a Keras
+// kernel_constraint. Project modifies w in place. The regularizers package provides max-norm, unit-norm and
+// non-negativity constraints.
+type Constraint interface {
+	Project(w *numpy.NDArray) error
+}
+
+// Regularize adds the gradient of the penalty of every parameter of the layer that has a Regularizer to its Grad and
+// returns the sum of the penalties, which the training loop adds to the loss. It is called after Backward and
+// before the optimizer step.
+//
+// Errors:
+//
+//	Returns an error if a regularizer fails.
+func Regularize(l Layer) (float64, error) {
+	total := 0.
+	for _, p := range l.Parameters() {
+		if p.Regularizer == nil {
+			continue
+		}
+		penalty, grad, err := p.Regularizer.Penalty(p.Value)
+		if err != nil {
+			return 0, fmt.Errorf("regularizing %v: %v", p.Name, err)
+		}
+		if err := p.AccumulateGrad(grad); err != nil {
+			return 0, fmt.Errorf("regularizing %v: %v", p.Name, err)
+		}
+		total += penalty
+	}
+	return total, nil
+}
+
+// Penalty returns the sum of the penalties of every parameter of the layer that has a Regularizer, without
+// touching the gradients, so evaluation losses can include it.
+//
+// Errors:
+//
+//	Returns an error if a regularizer fails.
+func Penalty(l Layer) (float64, error) {
+	total := 0.
+	for _, p := range l.Parameters() {
+		if p.Regularizer == nil {
+			continue
+		}
+		penalty, _, err := p.Regularizer.Penalty(p.Value)
+		if err != nil {
+			return 0, fmt.Errorf("regularizing %v: %v", p.Name, err)
+		}
+		total += penalty
+	}
+	return total, nil
+}
+
+// ApplyConstraints projects every parameter of the layer that has a Constraint. It is called after each optimizer
+// step.
+//
+// Errors:
+//
+//	Returns an error if a constraint fails.
+func ApplyConstraints(l Layer) error {
+	for _, p := range l.Parameters() {
+		if p.Constraint == nil {
+			continue
+		}
+		if err := p.Constraint.Project(p.Value); err != nil {
+			return fmt.Errorf("constraining %v: %v", p.Name, err)
+		}
+	}
+	return nil
+}
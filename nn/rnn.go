@@ -0,0 +1,222 @@
+package nn
+
+import (
+	"fmt"
+	"math"
+)
+
+// RNN is a multi-layer Elman recurrent layer computing h_t = act(x_t @ W_ih + b_ih + h_{t-1} @ W_hh + b_hh) with a
+// tanh or ReLU activation.
+//
+// Example usage:
+//
+//	rnn, _ := nn.NewRNN(8, 32, 2, "tanh", false, true)
+//	rnn.SetLengths([]int{10, 7, 4})
+//	y, _ := rnn.Forward(x) // x has shape (3, 10, 8), y has shape (3, 10, 32)
+type RNN struct {
+	recurrent
+}
+
+// NewRNN creates an Elman recurrent layer.
+//
+// The weights are drawn with random.Randn and scaled by sqrt(1 / hiddenSize); the biases start at zero. The layer
+// returns sequences, is not stateful and backpropagates through the whole sequence until the corresponding fields
+// are changed.
+//
+// Parameters:
+//
+//	inputSize (int): The number of features of each time step of the input.
+//	hiddenSize (int): The number of features of the hidden state.
+//	numLayers (int): The number of stacked layers; each layer after the first receives the outputs of the previous.
+//	nonlinearity (string): "tanh" or "relu".
+//	bidirectional (bool): Whether each layer also processes the sequence in reverse.
+//	batchFirst (bool): Whether inputs have shape (batch, time, features) rather than (time, batch, features).
+//
+// Returns:
+//
+//	(*RNN, error): The new layer, or nil and an error if an argument is invalid.
+//
+// Errors:
+//
+//	Returns an error if a size is not positive or the nonlinearity is not recognised.
+func NewRNN(inputSize, hiddenSize, numLayers int, nonlinearity string, bidirectional, batchFirst bool) (*RNN, error) {
+	if nonlinearity != "tanh" && nonlinearity != "relu" {
+		return nil, fmt.Errorf("unknown nonlinearity %q, expected tanh or relu", nonlinearity)
+	}
+	r, err := newRecurrent(&rnnCell{relu: nonlinearity == "relu"}, inputSize, hiddenSize, numLayers, bidirectional, batchFirst)
+	if err != nil {
+		return nil, err
+	}
+	return &RNN{r}, nil
+}
+
+// rnnCell is the step of an Elman network.
+type rnnCell struct {
+	relu bool
+}
+
+func (c *rnnCell) gates() int { return 1 }
+
+func (c *rnnCell) step(xw, hw, h, cs []float64, rows, hidden int) ([]float64, []float64, []float64) {
+	hNew := make([]float64, rows*hidden)
+	for i := range hNew {
+		a := xw[i] + hw[i]
+		if c.relu {
+			hNew[i] = math.Max(a, 0)
+		} else {
+			hNew[i] = math.Tanh(a)
+		}
+	}
+	return hNew, make([]float64, rows*hidden), hNew
+}
+
+func (c *rnnCell) stepBackward(dh, dc, h, cs, cache []float64, rows, hidden int) ([]float64, []float64, []float64, []float64) {
+	da := make([]float64, rows*hidden)
+	for i, y := range cache {
+		if c.relu {
+			if y > 0 {
+				da[i] = dh[i]
+			}
+		} else {
+			da[i] = dh[i] * (1 - y*y)
+		}
+	}
+	dhw := append([]float64{}, da...)
+	return da, dhw, make([]float64, rows*hidden), make([]float64, rows*hidden)
+}
+
+// GRU is a multi-layer gated recurrent unit layer (Cho et al., 2014), following the formulation of torch.nn.GRU:
+//
+//	r = sigmoid(x @ W_ir + b_ir + h @ W_hr + b_hr)
+//	z = sigmoid(x @ W_iz + b_iz + h @ W_hz + b_hz)
+//	n = tanh(x @ W_in + b_in + r * (h @ W_hn + b_hn))
+//	h' = (1 - z) * n + z * h
+//
+// The gates are stored side by side in the columns of the weights in the order r, z, n.
+type GRU struct {
+	recurrent
+}
+
+// NewGRU creates a gated recurrent unit layer. The parameters are as for NewRNN without the nonlinearity.
+//
+// Errors:
+//
+//	Returns an error if a size is not positive.
+func NewGRU(inputSize, hiddenSize, numLayers int, bidirectional, batchFirst bool) (*GRU, error) {
+	r, err := newRecurrent(&gruCell{}, inputSize, hiddenSize, numLayers, bidirectional, batchFirst)
+	if err != nil {
+		return nil, err
+	}
+	return &GRU{r}, nil
+}
+
+// gruCell is the step of a GRU. Its cache holds r, z, n and the recurrent projection of n for every row.
+type gruCell struct{}
+
+func (c *gruCell) gates() int { return 3 }
+
+func (c *gruCell) step(xw, hw, h, cs []float64, rows, hidden int) ([]float64, []float64, []float64) {
+	hNew := make([]float64, rows*hidden)
+	cache := make([]float64, rows*4*hidden)
+	for b := 0; b < rows; b++ {
+		x, u, k := xw[b*3*hidden:], hw[b*3*hidden:], cache[b*4*hidden:]
+		for j := 0; j < hidden; j++ {
+			r := sigmoid(x[j] + u[j])
+			z := sigmoid(x[hidden+j] + u[hidden+j])
+			n := math.Tanh(x[2*hidden+j] + r*u[2*hidden+j])
+			hNew[b*hidden+j] = (1-z)*n + z*h[b*hidden+j]
+			k[j], k[hidden+j], k[2*hidden+j], k[3*hidden+j] = r, z, n, u[2*hidden+j]
+		}
+	}
+	return hNew, make([]float64, rows*hidden), cache
+}
+
+func (c *gruCell) stepBackward(dh, dc, h, cs, cache []float64, rows, hidden int) ([]float64, []float64, []float64, []float64) {
+	dxw := make([]float64, rows*3*hidden)
+	dhw := make([]float64, rows*3*hidden)
+	dhPrev := make([]float64, rows*hidden)
+	for b := 0; b < rows; b++ {
+		k, dx, du := cache[b*4*hidden:], dxw[b*3*hidden:], dhw[b*3*hidden:]
+		for j := 0; j < hidden; j++ {
+			i := b*hidden + j
+			r, z, n, un := k[j], k[hidden+j], k[2*hidden+j], k[3*hidden+j]
+			dn := dh[i] * (1 - z) * (1 - n*n)
+			dz := dh[i] * (h[i] - n) * z * (1 - z)
+			dr := dn * un * r * (1 - r)
+			dx[j], dx[hidden+j], dx[2*hidden+j] = dr, dz, dn
+			du[j], du[hidden+j], du[2*hidden+j] = dr, dz, dn*r
+			dhPrev[i] = dh[i] * z
+		}
+	}
+	return dxw, dhw, dhPrev, make([]float64, rows*hidden)
+}
+
+// LSTM is a multi-layer long short-term memory layer (Hochreiter and Schmidhuber, 1997), following torch.nn.LSTM:
+//
+//	i, f, g, o = sigmoid, sigmoid, tanh, sigmoid of x @ W_ih + b_ih + h @ W_hh + b_hh
+//	c' = f * c + i * g
+//	h' = o * tanh(c')
+//
+// The gates are stored side by side in the columns of the weights in the order i, f, g, o. The outputs are the
+// hidden states; the cell states are internal.
+type LSTM struct {
+	recurrent
+}
+
+// NewLSTM creates a long short-term memory layer. The parameters are as for NewRNN without the nonlinearity.
+//
+// Errors:
+//
+//	Returns an error if a size is not positive.
+func NewLSTM(inputSize, hiddenSize, numLayers int, bidirectional, batchFirst bool) (*LSTM, error) {
+	r, err := newRecurrent(&lstmCell{}, inputSize, hiddenSize, numLayers, bidirectional, batchFirst)
+	if err != nil {
+		return nil, err
+	}
+	return &LSTM{r}, nil
+}
+
+// lstmCell is the step of an LSTM. Its cache holds i, f, g, o and the new cell state for every row.
+type lstmCell struct{}
+
+func (c *lstmCell) gates() int { return 4 }
+
+func (c *lstmCell) step(xw, hw, h, cs []float64, rows, hidden int) ([]float64, []float64, []float64) {
+	hNew := make([]float64, rows*hidden)
+	cNew := make([]float64, rows*hidden)
+	cache := make([]float64, rows*5*hidden)
+	for b := 0; b < rows; b++ {
+		x, u, k := xw[b*4*hidden:], hw[b*4*hidden:], cache[b*5*hidden:]
+		for j := 0; j < hidden; j++ {
+			i := b*hidden + j
+			ig := sigmoid(x[j] + u[j])
+			fg := sigmoid(x[hidden+j] + u[hidden+j])
+			gg := math.Tanh(x[2*hidden+j] + u[2*hidden+j])
+			og := sigmoid(x[3*hidden+j] + u[3*hidden+j])
+			cNew[i] = fg*cs[i] + ig*gg
+			hNew[i] = og * math.Tanh(cNew[i])
+			k[j], k[hidden+j], k[2*hidden+j], k[3*hidden+j], k[4*hidden+j] = ig, fg, gg, og, cNew[i]
+		}
+	}
+	return hNew, cNew, cache
+}
+
+func (c *lstmCell) stepBackward(dh, dc, h, cs, cache []float64, rows, hidden int) ([]float64, []float64, []float64, []float64) {
+	da := make([]float64, rows*4*hidden)
+	dcPrev := make([]float64, rows*hidden)
+	for b := 0; b < rows; b++ {
+		k, d := cache[b*5*hidden:], da[b*4*hidden:]
+		for j := 0; j < hidden; j++ {
+			i := b*hidden + j
+			ig, fg, gg, og, cn := k[j], k[hidden+j], k[2*hidden+j], k[3*hidden+j], k[4*hidden+j]
+			tc := math.Tanh(cn)
+			dct := dc[i] + dh[i]*og*(1-tc*tc)
+			d[j] = dct * gg * ig * (1 - ig)
+			d[hidden+j] = dct * cs[i] * fg * (1 - fg)
+			d[2*hidden+j] = dct * ig * (1 - gg*gg)
+			d[3*hidden+j] = dh[i] * tc * og * (1 - og)
+			dcPrev[i] = dct * fg
+		}
+	}
+	return da, append([]float64{}, da...), make([]float64, rows*hidden), dcPrev
+}
@@ -0,0 +1,101 @@
+package nn
+
+import (
+	"fmt"
+
+	"github.com/timotewb/gonn/numpy"
+	"github.com/timotewb/gonn/numpy/random"
+)
+
+// Sequential chains layers so that the output of each layer is the input of the next.
+//
+// Example usage:
+//
+//	d1, _ := nn.NewDense(784, 128, true)
+//	d2, _ := nn.NewDense(128, 10, true)
+//	model := nn.NewSequential(d1, nn.NewReLU(), d2, nn.NewSoftmax(-1))
+//	probs, _ := model.Forward(x)
+type Sequential struct {
+	Layers []Layer
+}
+
+// NewSequential creates a container running the given layers in order.
+func NewSequential(layers ...Layer) *Sequential {
+	return &Sequential{Layers: layers}
+}
+
+// Add appends a layer to the end of the container.
+func (s *Sequential) Add(l Layer) {
+	s.Layers = append(s.Layers, l)
+}
+
+// Forward runs x through every layer in order.
+//
+// Errors:
+//
+//	Returns the first error reported by a layer, annotated with the layer's position.
+func (s *Sequential) Forward(x *numpy.NDArray) (*numpy.NDArray, error) {
+	var err error
+	for i, l := range s.Layers {
+		x, err = l.Forward(x)
+		if err != nil {
+			return nil, fmt.Errorf("layer %v (%T): %v", i, l, err)
+		}
+	}
+	return x, nil
+}
+
+// Backward propagates the gradient through the layers in reverse order.
+//
+// Errors:
+//
+//	Returns the first error reported by a layer, annotated with the layer's position.
+func (s *Sequential) Backward(grad *numpy.NDArray) (*numpy.NDArray, error) {
+	var err error
+	for i := len(s.Layers) - 1; i >= 0; i-- {
+		grad, err = s.Layers[i].Backward(grad)
+		if err != nil {
+			return nil, fmt.Errorf("layer %v (%T): %v", i, s.Layers[i], err)
+		}
+	}
+	return grad, nil
+}
+
+// Parameters returns the parameters of every layer in order.
+func (s *Sequential) Parameters() []*Parameter {
+	params := []*Parameter{}
+	for _, l := range s.Layers {
+		params = append(params, l.Parameters()...)
+	}
+	return params
+}
+
+// Buffers returns the buffers of every layer that has them, keyed by the layer's position and the buffer name, for
+// example "1.running_mean".
+func (s *Sequential) Buffers() map[string]*numpy.NDArray {
+	buffers := map[string]*numpy.NDArray{}
+	for i, l := range s.Layers {
+		if b, ok := l.(Buffered); ok {
+			for name, a := range b.Buffers() {
+				buffers[fmt.Sprintf("%v.%v", i, name)] = a
+			}
+		}
+	}
+	return buffers
+}
+
+// SetTraining switches every layer that supports it between training and evaluation mode.
+func (s *Sequential) SetTraining(training bool) {
+	for _, l := range s.Layers {
+		if t, ok := l.(Trainable); ok {
+			t.SetTraining(training)
+		}
+	}
+}
+
+// SetRand sets the random source of every layer that draws random numbers.
+func (s *Sequential) SetRand(g *random.Generator) {
+	for _, l := range s.Layers {
+		SetRand(l, g)
+	}
+}
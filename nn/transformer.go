@@ -0,0 +1,338 @@
+package nn
+
+import (
+	"fmt"
+
+	"github.com/timotewb/gonn/numpy"
+	"github.com/timotewb/gonn/numpy/random"
+)
+
+// newFeedForward builds the position-wise feed-forward block Dense -> activation -> Dropout -> Dense.
+func newFeedForward(dModel, dimFeedforward int, dropout float64, activation string) (*Sequential, error) {
+	var act Layer
+	switch activation {
+	case "relu":
+		act = NewReLU()
+	case "gelu":
+		act = NewGELU()
+	default:
+		return nil, fmt.Errorf("unknown activation %q, expected relu or gelu", activation)
+	}
+	d1, err := NewDense(dModel, dimFeedforward, true)
+	if err != nil {
+		return nil, err
+	}
+	drop, err := NewDropout(dropout)
+	if err != nil {
+		return nil, err
+	}
+	d2, err := NewDense(dimFeedforward, dModel, true)
+	if err != nil {
+		return nil, err
+	}
+	return NewSequential(d1, act, drop, d2), nil
+}
+
+// residual is a sub-block of a transformer layer: a sublayer wrapped with dropout, a residual connection and layer
+// normalization, either after the addition (post-norm) or before the sublayer (pre-norm).
+type residual struct {
+	norm      *LayerNorm
+	dropout   *Dropout
+	normFirst bool
+}
+
+// newResidual creates the normalization and dropout of a sub-block.
+func newResidual(dModel int, eps, dropout float64, normFirst bool) (*residual, error) {
+	norm, err := NewLayerNorm([]int{dModel}, eps, true)
+	if err != nil {
+		return nil, err
+	}
+	drop, err := NewDropout(dropout)
+	if err != nil {
+		return nil, err
+	}
+	return &residual{norm: norm, dropout: drop, normFirst: normFirst}, nil
+}
+
+// forward computes x + dropout(f(norm(x))) for pre-norm and norm(x + dropout(f(x))) for post-norm.
+func (r *residual) forward(x *numpy.NDArray, f func(*numpy.NDArray) (*numpy.NDArray, error)) (*numpy.NDArray, error) {
+	in := x
+	if r.normFirst {
+		var err error
+		if in, err = r.norm.Forward(x); err != nil {
+			return nil, err
+		}
+	}
+	y, err := f(in)
+	if err != nil {
+		return nil, err
+	}
+	if y, err = r.dropout.Forward(y); err != nil {
+		return nil, err
+	}
+	if y, err = x.Add(y); err != nil {
+		return nil, err
+	}
+	if r.normFirst {
+		return y, nil
+	}
+	return r.norm.Forward(y)
+}
+
+// backward returns the gradient with respect to x of the sub-block, given the backward function of the sublayer.
+func (r *residual) backward(grad *numpy.NDArray, f func(*numpy.NDArray) (*numpy.NDArray, error)) (*numpy.NDArray, error) {
+	var err error
+	if !r.normFirst {
+		if grad, err = r.norm.Backward(grad); err != nil {
+			return nil, err
+		}
+	}
+	g, err := r.dropout.Backward(grad)
+	if err != nil {
+		return nil, err
+	}
+	if g, err = f(g); err != nil {
+		return nil, err
+	}
+	if r.normFirst {
+		if g, err = r.norm.Backward(g); err != nil {
+			return nil, err
+		}
+	}
+	return grad.Add(g)
+}
+
+// TransformerEncoderLayer is a transformer encoder block made of self-attention and a feed-forward network, each with
+// dropout, a residual connection and layer normalization, like torch.nn.TransformerEncoderLayer with batch-first
+// inputs of shape (batch, time, dModel).
+//
+// Stack several layers with Sequential to build an encoder. Padding positions of the input can be excluded from
+// attention with SelfAttention.SetPaddingMask.
+type TransformerEncoderLayer struct {
+	SelfAttention *MultiHeadAttention
+	FeedForward   *Sequential
+
+	attnBlock *residual
+	ffBlock   *residual
+}
+
+// NewTransformerEncoderLayer creates a transformer encoder block.
+//
+// Parameters:
+//
+//	dModel (int): The number of features of the inputs and outputs.
+//	numHeads (int): The number of attention heads. dModel must be divisible by it.
+//	dimFeedforward (int): The hidden size of the feed-forward network, typically 4 * dModel.
+//	dropout (float64): The dropout probability applied after attention, inside and after the feed-forward network.
+//	activation (string): The activation of the feed-forward network, "relu" or "gelu".
+//	normFirst (bool): Whether to normalize before each sub-block (pre-norm, as in GPT-2) instead of after the
+//	                  residual addition (post-norm, as in the original transformer).
+//
+// Returns:
+//
+//	(*TransformerEncoderLayer, error): The new layer, or nil and an error if an argument is invalid.
+//
+// Errors:
+//
+//	Returns an error if a size is not positive, dModel is not divisible by numHeads, dropout is not in [0, 1) or the
+//	activation is not recognised.
+func NewTransformerEncoderLayer(dModel, numHeads, dimFeedforward int, dropout float64, activation string, normFirst bool) (*TransformerEncoderLayer, error) {
+	attn, err := NewMultiHeadAttention(dModel, numHeads, true)
+	if err != nil {
+		return nil, err
+	}
+	ff, err := newFeedForward(dModel, dimFeedforward, dropout, activation)
+	if err != nil {
+		return nil, err
+	}
+	l := &TransformerEncoderLayer{SelfAttention: attn, FeedForward: ff}
+	if l.attnBlock, err = newResidual(dModel, 1e-5, dropout, normFirst); err != nil {
+		return nil, err
+	}
+	if l.ffBlock, err = newResidual(dModel, 1e-5, dropout, normFirst); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Forward applies the block to x of shape (batch, time, dModel).
+//
+// Errors:
+//
+//	Returns an error if x does not have shape (batch, time, dModel).
+func (l *TransformerEncoderLayer) Forward(x *numpy.NDArray) (*numpy.NDArray, error) {
+	y, err := l.attnBlock.forward(x, l.SelfAttention.Forward)
+	if err != nil {
+		return nil, err
+	}
+	return l.ffBlock.forward(y, l.FeedForward.Forward)
+}
+
+// Backward accumulates the parameter gradients and returns the gradient with respect to the input.
+//
+// Errors:
+//
+//	Returns an error if Forward has not been called or grad does not match the output of Forward.
+func (l *TransformerEncoderLayer) Backward(grad *numpy.NDArray) (*numpy.NDArray, error) {
+	g, err := l.ffBlock.backward(grad, l.FeedForward.Backward)
+	if err != nil {
+		return nil, err
+	}
+	return l.attnBlock.backward(g, l.SelfAttention.Backward)
+}
+
+// Parameters returns the parameters of the attention, feed-forward network and layer normalizations.
+func (l *TransformerEncoderLayer) Parameters() []*Parameter {
+	params := l.SelfAttention.Parameters()
+	params = append(params, l.attnBlock.norm.Parameters()...)
+	params = append(params, l.FeedForward.Parameters()...)
+	return append(params, l.ffBlock.norm.Parameters()...)
+}
+
+// SetTraining switches the dropout layers between training and evaluation mode.
+func (l *TransformerEncoderLayer) SetTraining(training bool) {
+	l.attnBlock.dropout.SetTraining(training)
+	l.ffBlock.dropout.SetTraining(training)
+	l.FeedForward.SetTraining(training)
+}
+
+// SetRand sets the random source of the dropout layers.
+func (l *TransformerEncoderLayer) SetRand(g *random.Generator) {
+	l.attnBlock.dropout.SetRand(g)
+	l.ffBlock.dropout.SetRand(g)
+	l.FeedForward.SetRand(g)
+}
+
+// TransformerDecoderLayer is a transformer decoder block made of causal self-attention, cross-attention over the
+// encoder output (the memory) and a feed-forward network, like torch.nn.TransformerDecoderLayer with batch-first
+// inputs.
+//
+// The memory is set with SetMemory before Forward, and its gradient is available from MemoryGrad after Backward so
+// it can be passed to the encoder. Padding can be masked with SelfAttention.SetPaddingMask and
+// CrossAttention.SetPaddingMask.
+type TransformerDecoderLayer struct {
+	SelfAttention  *MultiHeadAttention
+	CrossAttention *MultiHeadAttention
+	FeedForward    *Sequential
+
+	selfBlock  *residual
+	crossBlock *residual
+	ffBlock    *residual
+	memory     *numpy.NDArray
+	memoryGrad *numpy.NDArray
+}
+
+// NewTransformerDecoderLayer creates a transformer decoder block. The parameters are as for
+// NewTransformerEncoderLayer; the self-attention is causal.
+//
+// Errors:
+//
+//	Returns an error if a size is not positive, dModel is not divisible by numHeads, dropout is not in [0, 1) or the
+//	activation is not recognised.
+func NewTransformerDecoderLayer(dModel, numHeads, dimFeedforward int, dropout float64, activation string, normFirst bool) (*TransformerDecoderLayer, error) {
+	self, err := NewMultiHeadAttention(dModel, numHeads, true)
+	if err != nil {
+		return nil, err
+	}
+	self.Causal = true
+	cross, err := NewMultiHeadAttention(dModel, numHeads, true)
+	if err != nil {
+		return nil, err
+	}
+	ff, err := newFeedForward(dModel, dimFeedforward, dropout, activation)
+	if err != nil {
+		return nil, err
+	}
+	l := &TransformerDecoderLayer{SelfAttention: self, CrossAttention: cross, FeedForward: ff}
+	for _, b := range []**residual{&l.selfBlock, &l.crossBlock, &l.ffBlock} {
+		if *b, err = newResidual(dModel, 1e-5, dropout, normFirst); err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+// SetMemory sets the encoder output of shape (batch, sourceTime, dModel) attended to by the following Forward calls.
+func (l *TransformerDecoderLayer) SetMemory(memory *numpy.NDArray) {
+	l.memory = memory
+}
+
+// MemoryGrad returns the gradient with respect to the memory computed by the last Backward call.
+func (l *TransformerDecoderLayer) MemoryGrad() *numpy.NDArray {
+	return l.memoryGrad
+}
+
+// Forward applies the block to the target sequence x of shape (batch, time, dModel).
+//
+// Errors:
+//
+//	Returns an error if the memory has not been set or the shapes do not match.
+func (l *TransformerDecoderLayer) Forward(x *numpy.NDArray) (*numpy.NDArray, error) {
+	if l.memory == nil {
+		return nil, fmt.Errorf("memory must be set with SetMemory before Forward")
+	}
+	y, err := l.selfBlock.forward(x, l.SelfAttention.Forward)
+	if err != nil {
+		return nil, err
+	}
+	y, err = l.crossBlock.forward(y, func(q *numpy.NDArray) (*numpy.NDArray, error) {
+		return l.CrossAttention.Attend(q, l.memory, l.memory)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return l.ffBlock.forward(y, l.FeedForward.Forward)
+}
+
+// Backward accumulates the parameter gradients, stores the gradient with respect to the memory and returns the
+// gradient with respect to the input.
+//
+// Errors:
+//
+//	Returns an error if Forward has not been called or grad does not match the output of Forward.
+func (l *TransformerDecoderLayer) Backward(grad *numpy.NDArray) (*numpy.NDArray, error) {
+	g, err := l.ffBlock.backward(grad, l.FeedForward.Backward)
+	if err != nil {
+		return nil, err
+	}
+	g, err = l.crossBlock.backward(g, func(g *numpy.NDArray) (*numpy.NDArray, error) {
+		dq, dk, dv, err := l.CrossAttention.BackwardAttend(g)
+		if err != nil {
+			return nil, err
+		}
+		if l.memoryGrad, err = dk.Add(dv); err != nil {
+			return nil, err
+		}
+		return dq, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return l.selfBlock.backward(g, l.SelfAttention.Backward)
+}
+
+// Parameters returns the parameters of the attention layers, feed-forward network and layer normalizations.
+func (l *TransformerDecoderLayer) Parameters() []*Parameter {
+	params := l.SelfAttention.Parameters()
+	params = append(params, l.selfBlock.norm.Parameters()...)
+	params = append(params, l.CrossAttention.Parameters()...)
+	params = append(params, l.crossBlock.norm.Parameters()...)
+	params = append(params, l.FeedForward.Parameters()...)
+	return append(params, l.ffBlock.norm.Parameters()...)
+}
+
+// SetTraining switches the dropout layers between training and evaluation mode.
+func (l *TransformerDecoderLayer) SetTraining(training bool) {
+	for _, b := range []*residual{l.selfBlock, l.crossBlock, l.ffBlock} {
+		b.dropout.SetTraining(training)
+	}
+	l.FeedForward.SetTraining(training)
+}
+
+// SetRand sets the random source of the dropout layers.
+func (l *TransformerDecoderLayer) SetRand(g *random.Generator) {
+	for _, b := range []*residual{l.selfBlock, l.crossBlock, l.ffBlock} {
+		b.dropout.SetRand(g)
+	}
+	l.FeedForward.SetRand(g)
+}
@@ -0,0 +1,405 @@
+package numpy
+
+import (
+	"fmt"
+	"slices"
+)
+
+// CumSum returns the cumulative sum of the elements along an axis, like numpy.cumsum.
+//
+// With no axis the array is flattened first and a 1-D result is returned.
+//
+// Errors:
+//
+//	Returns an error if more than one axis is given or the axis is out of bounds.
+func CumSum(a *NDArray, axis ...int) (*NDArray, error) {
+	return accumulate(a, axis, 0, func(acc, v float64) float64 { return acc + v })
+}
+
+// CumProd returns the cumulative product of the elements along an axis, like numpy.cumprod.
+//
+// With no axis the array is flattened first and a 1-D result is returned.
+//
+// Errors:
+//
+//	Returns an error if more than one axis is given or the axis is out of bounds.
+func CumProd(a *NDArray, axis ...int) (*NDArray, error) {
+	return accumulate(a, axis, 1, func(acc, v float64) float64 { return acc * v })
+}
+
+// accumulate applies a running binary operation along an axis, starting from identity.
+func accumulate(a *NDArray, axis []int, identity float64, op func(acc, v float64) float64) (*NDArray, error) {
+	if len(axis) > 1 {
+		return nil, fmt.Errorf("accumulation takes a single axis, got %v", axis)
+	}
+	ax := 0
+	if len(axis) == 0 || len(a.shape) == 0 {
+		a = a.Ravel()
+	} else {
+		ax = axis[0]
+	}
+	ax, err := normalizeAxis(ax, len(a.shape))
+	if err != nil {
+		return nil, err
+	}
+	return alongAxis(a, ax, a.shape[ax], func(in, out []float64) {
+		acc := identity
+		for i, v := range in {
+			acc = op(acc, v)
+			out[i] = acc
+		}
+	})
+}
+
+// Concatenate joins a sequence of arrays along an existing axis, like numpy.concatenate.
+//
+// Errors:
+//
+//	Returns an error if no arrays are given, the arrays have different numbers of dimensions, or their shapes differ
+//	along any axis other than the concatenation axis.
+func Concatenate(arrays []*NDArray, axis int) (*NDArray, error) {
+	if len(arrays) == 0 {
+		return nil, fmt.Errorf("need at least one array to concatenate")
+	}
+	ndim := len(arrays[0].shape)
+	if ndim == 0 {
+		return nil, fmt.Errorf("zero-dimensional arrays cannot be concatenated")
+	}
+	axis, err := normalizeAxis(axis, ndim)
+	if err != nil {
+		return nil, err
+	}
+
+	// Move the concatenation axis to the front so every array is one contiguous block of the output
+	order := []int{axis}
+	for i := 0; i < ndim; i++ {
+		if i != axis {
+			order = append(order, i)
+		}
+	}
+	data := []float64{}
+	total := 0
+	for _, a := range arrays {
+		if len(a.shape) != ndim {
+			return nil, fmt.Errorf("all the input arrays must have same number of dimensions")
+		}
+		for i := range a.shape {
+			if i != axis && a.shape[i] != arrays[0].shape[i] {
+				return nil, fmt.Errorf("all the input array dimensions except for the concatenation axis must match exactly, got %v and %v", arrays[0].shape, a.shape)
+			}
+		}
+		t, err := a.Transpose(order...)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, t.Data()...)
+		total += a.shape[axis]
+	}
+
+	// Restore the original axis order
+	shape := []int{total}
+	for _, ax := range order[1:] {
+		shape = append(shape, arrays[0].shape[ax])
+	}
+	inverse := make([]int, ndim)
+	for i, ax := range order {
+		inverse[ax] = i
+	}
+	t, err := newArray(data, shape).Transpose(inverse...)
+	if err != nil {
+		return nil, err
+	}
+	return t.AsContiguous(), nil
+}
+
+// Diff calculates the n-th discrete difference along the given axis, like numpy.diff.
+//
+// The first difference is out[i] = a[i+1] - a[i]; higher differences are calculated by applying Diff recursively.
+// Values can be prepended or appended along the axis before the differences are taken, for example prepending 0 to
+// a cumulative sum recovers the original values.
+//
+// Parameters:
+//
+//	a (*NDArray): The input array.
+//	n (int): The number of times values are differenced. Zero returns the input (with prepend and append applied).
+//	axis (int): The axis along which the difference is taken; -1 uses the last axis.
+//	prepend, appendValues (interface{}): Values to add before and after a along axis: nil for none, a float64 which
+//	                                     is broadcast to a single slice, or an *NDArray matching a except along axis.
+//
+// Returns:
+//
+//	(*NDArray, error): The n-th differences, shorter than the input by n along axis.
+//
+// Errors:
+//
+//	Returns an error if n is negative, the axis is out of bounds or prepend and append have incompatible shapes.
+func Diff(a *NDArray, n int, axis int, prepend, appendValues interface{}) (*NDArray, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("order must be non-negative but got %v", n)
+	}
+	if len(a.shape) == 0 {
+		return nil, fmt.Errorf("diff requires input that is at least one dimensional")
+	}
+	axis, err := normalizeAxis(axis, len(a.shape))
+	if err != nil {
+		return nil, err
+	}
+
+	// Join the prepended and appended values onto the input
+	parts := []*NDArray{}
+	for _, extra := range []interface{}{prepend, a, appendValues} {
+		switch v := extra.(type) {
+		case nil:
+		case float64:
+			shape := a.Shape()
+			shape[axis] = 1
+			parts = append(parts, FullArray(v, shape...))
+		case *NDArray:
+			if len(v.shape) == 0 {
+				shape := a.Shape()
+				shape[axis] = 1
+				v, _ = v.BroadcastTo(shape...)
+			}
+			parts = append(parts, v)
+		default:
+			return nil, fmt.Errorf("prepend and append must be nil, a float64 or an *NDArray, got %T", extra)
+		}
+	}
+	if len(parts) > 1 {
+		a, err = Concatenate(parts, axis)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	length := a.shape[axis] - n
+	if length < 0 {
+		length = 0
+	}
+	return alongAxis(a, axis, length, func(in, out []float64) {
+		work := append([]float64{}, in...)
+		for k := 0; k < n && len(work) > 0; k++ {
+			for i := 0; i < len(work)-1; i++ {
+				work[i] = work[i+1] - work[i]
+			}
+			work = work[:len(work)-1]
+		}
+		copy(out, work)
+	})
+}
+
+// Gradient returns the gradient of an N-dimensional array, like numpy.gradient.
+//
+// The gradient is computed using second order accurate central differences in the interior points and either first
+// or second order accurate one-sided differences at the boundaries. Spacing between samples can be uniform or given
+// as coordinates, in which case the non-uniform formulas from numpy are used.
+//
+// Parameters:
+//
+//	f (*NDArray): The sampled values.
+//	spacing ([]interface{}): nil for unit spacing; a single float64 used for every axis; or one entry per axis, each
+//	                         a float64 sample distance or a []float64 / *NDArray of coordinates along that axis.
+//	edgeOrder (int): The accuracy of the differences at the boundaries, 1 or 2.
+//	axis (...int): The axes to differentiate along. With no axes the gradient is computed along every axis.
+//
+// Returns:
+//
+//	([]*NDArray, error): One array per requested axis, each with the same shape as f.
+//
+// Errors:
+//
+//	Returns an error if the spacing does not match the axes, edgeOrder is not 1 or 2, or an axis has fewer than
+//	edgeOrder + 1 elements.
+func Gradient(f *NDArray, spacing []interface{}, edgeOrder int, axis ...int) ([]*NDArray, error) {
+	if edgeOrder != 1 && edgeOrder != 2 {
+		return nil, fmt.Errorf("edgeOrder must be 1 or 2, got %v", edgeOrder)
+	}
+	axes := append([]int{}, axis...)
+	if len(axes) == 0 {
+		axes = make([]int, len(f.shape))
+		for i := range axes {
+			axes[i] = i
+		}
+	}
+	for i := range axes {
+		ax, err := normalizeAxis(axes[i], len(f.shape))
+		if err != nil {
+			return nil, err
+		}
+		axes[i] = ax
+	}
+
+	// Resolve the spacing into one specification per axis
+	specs := make([]interface{}, len(axes))
+	switch {
+	case len(spacing) == 0:
+		for i := range specs {
+			specs[i] = 1.
+		}
+	case len(spacing) == 1:
+		if _, ok := spacing[0].(float64); !ok && len(axes) != 1 {
+			return nil, fmt.Errorf("a single spacing must be a float64 when differentiating along several axes")
+		}
+		for i := range specs {
+			specs[i] = spacing[0]
+		}
+	case len(spacing) == len(axes):
+		copy(specs, spacing)
+	default:
+		return nil, fmt.Errorf("invalid number of spacing arguments: expected 0, 1 or %v, got %v", len(axes), len(spacing))
+	}
+
+	out := make([]*NDArray, len(axes))
+	for k, ax := range axes {
+		n := f.shape[ax]
+		if n < edgeOrder+1 {
+			return nil, fmt.Errorf("shape of array too small to calculate a numerical gradient, at least (edgeOrder + 1) elements are required")
+		}
+
+		// Build the distances between consecutive samples
+		dx := make([]float64, n-1)
+		uniform := true
+		switch s := specs[k].(type) {
+		case float64:
+			for i := range dx {
+				dx[i] = s
+			}
+		case int:
+			for i := range dx {
+				dx[i] = float64(s)
+			}
+		case []float64, *NDArray:
+			coords, ok := s.([]float64)
+			if !ok {
+				coords = s.(*NDArray).Data()
+			}
+			if len(coords) != n {
+				return nil, fmt.Errorf("when spacing is given as coordinates, their length must match the length of the corresponding axis of f")
+			}
+			for i := range dx {
+				dx[i] = coords[i+1] - coords[i]
+				uniform = uniform && dx[i] == dx[0]
+			}
+		default:
+			return nil, fmt.Errorf("spacing must be a float64, []float64 or *NDArray, got %T", specs[k])
+		}
+
+		var err error
+		out[k], err = alongAxis(f, ax, n, func(in, g []float64) {
+			gradientLane(in, g, dx, uniform, edgeOrder)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// gradientLane computes the gradient of one lane of samples with the given distances between them.
+func gradientLane(f, g, dx []float64, uniform bool, edgeOrder int) {
+	n := len(f)
+
+	// Interior points use central differences
+	for i := 1; i < n-1; i++ {
+		if uniform {
+			g[i] = (f[i+1] - f[i-1]) / (2 * dx[0])
+			continue
+		}
+		dx1, dx2 := dx[i-1], dx[i]
+		a := -dx2 / (dx1 * (dx1 + dx2))
+		b := (dx2 - dx1) / (dx1 * dx2)
+		c := dx1 / (dx2 * (dx1 + dx2))
+		g[i] = a*f[i-1] + b*f[i] + c*f[i+1]
+	}
+
+	// Boundaries use one-sided differences
+	if edgeOrder == 1 {
+		g[0] = (f[1] - f[0]) / dx[0]
+		g[n-1] = (f[n-1] - f[n-2]) / dx[n-2]
+		return
+	}
+	if uniform {
+		g[0] = (-1.5*f[0] + 2*f[1] - 0.5*f[2]) / dx[0]
+		g[n-1] = (0.5*f[n-3] - 2*f[n-2] + 1.5*f[n-1]) / dx[0]
+		return
+	}
+	dx1, dx2 := dx[0], dx[1]
+	a := -(2*dx1 + dx2) / (dx1 * (dx1 + dx2))
+	b := (dx1 + dx2) / (dx1 * dx2)
+	c := -dx1 / (dx2 * (dx1 + dx2))
+	g[0] = a*f[0] + b*f[1] + c*f[2]
+	dx1, dx2 = dx[n-3], dx[n-2]
+	a = dx2 / (dx1 * (dx1 + dx2))
+	b = -(dx2 + dx1) / (dx1 * dx2)
+	c = (2*dx2 + dx1) / (dx2 * (dx1 + dx2))
+	g[n-1] = a*f[n-3] + b*f[n-2] + c*f[n-1]
+}
+
+// Trapz integrates along the given axis using the composite trapezoidal rule, like numpy.trapz.
+//
+// Parameters:
+//
+//	y (*NDArray): The values to integrate.
+//	x (*NDArray): The sample points, either 1-D with the length of y along axis or the same shape as y. If nil, the
+//	              sample points are assumed to be evenly spaced dx apart.
+//	dx (float64): The spacing between sample points when x is nil.
+//	axis (int): The axis along which to integrate; -1 uses the last axis.
+//
+// Returns:
+//
+//	(*NDArray, error): The integral with the axis removed, a 0-d array for 1-D input.
+//
+// Errors:
+//
+//	Returns an error if the axis is out of bounds or x does not match y.
+func Trapz(y, x *NDArray, dx float64, axis int) (*NDArray, error) {
+	if len(y.shape) == 0 {
+		return nil, fmt.Errorf("trapz requires input that is at least one dimensional")
+	}
+	axis, err := normalizeAxis(axis, len(y.shape))
+	if err != nil {
+		return nil, err
+	}
+	n := y.shape[axis]
+	trapezoids := func(values []float64, width func(i int) float64) float64 {
+		s := 0.
+		for i := 0; i < len(values)-1; i++ {
+			s += width(i) * (values[i] + values[i+1]) / 2
+		}
+		return s
+	}
+
+	switch {
+	case x == nil:
+		return reduce(y, []int{axis}, func(lane []float64) float64 {
+			return trapezoids(lane, func(int) float64 { return dx })
+		})
+	case len(x.shape) == 1:
+		if x.shape[0] != n {
+			return nil, fmt.Errorf("x must have the same length as y along axis %v", axis)
+		}
+		coords := x.Data()
+		return reduce(y, []int{axis}, func(lane []float64) float64 {
+			return trapezoids(lane, func(i int) float64 { return coords[i+1] - coords[i] })
+		})
+	default:
+		if !slices.Equal(x.shape, y.shape) {
+			return nil, fmt.Errorf("x must be 1-D or have the same shape as y, got %v and %v", x.shape, y.shape)
+		}
+		yData, inner, outShape, err := laneMatrix(y, []int{axis})
+		if err != nil {
+			return nil, err
+		}
+		xData, _, _, err := laneMatrix(x, []int{axis})
+		if err != nil {
+			return nil, err
+		}
+		lanes, _ := shapeSize(outShape)
+		out := make([]float64, lanes)
+		for l := range out {
+			coords := xData[l*inner : (l+1)*inner]
+			out[l] = trapezoids(yData[l*inner:(l+1)*inner], func(i int) float64 { return coords[i+1] - coords[i] })
+		}
+		return newArray(out, outShape), nil
+	}
+}
@@ -0,0 +1,42 @@
+package numpy
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// arrayJSON is the JSON representation of an NDArray: its shape and its values in row-major order.
+type arrayJSON struct {
+	Shape []int     `json:"shape"`
+	Data  []float64 `json:"data"`
+}
+
+// MarshalJSON encodes the array as an object holding its shape and its values in row-major order, for example
+// {"shape":[2,2],"data":[1,2,3,4]}. Views are encoded with their own values only.
+func (a *NDArray) MarshalJSON() ([]byte, error) {
+	return json.Marshal(arrayJSON{Shape: a.Shape(), Data: a.Data()})
+}
+
+// UnmarshalJSON decodes an array encoded by MarshalJSON, replacing the contents of a.
+//
+// Errors:
+//
+//	Returns an error if the JSON is malformed or the number of values does not match the shape.
+func (a *NDArray) UnmarshalJSON(b []byte) error {
+	var v arrayJSON
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	if v.Data == nil {
+		v.Data = []float64{}
+	}
+	size, err := shapeSize(v.Shape)
+	if err != nil {
+		return err
+	}
+	if size != len(v.Data) {
+		return fmt.Errorf("cannot create array of shape %v from %v values", v.Shape, len(v.Data))
+	}
+	*a = *newArray(v.Data, v.Shape)
+	return nil
+}
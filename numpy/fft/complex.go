@@ -0,0 +1,146 @@
+package fft
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"slices"
+
+	"github.com/timotewb/gonn/numpy"
+)
+
+// ComplexArray is an n-dimensional array of complex128 values in row-major order, the result type of the complex
+// transforms. numpy.NDArray only holds float64 values, so use Real, Imag, Abs and Angle to get back to it.
+//
+// Fields:
+//
+//	Data ([]complex128): The values in row-major order.
+//	Shape ([]int): The length of every dimension.
+type ComplexArray struct {
+	Data  []complex128
+	Shape []int
+}
+
+// NewComplexArray creates a complex array from its values in row-major order, which are used without copying.
+//
+// Errors:
+//
+//	Returns an error if a dimension is negative or the number of values does not match the shape.
+func NewComplexArray(data []complex128, shape ...int) (*ComplexArray, error) {
+	size := 1
+	for _, s := range shape {
+		if s < 0 {
+			return nil, fmt.Errorf("negative dimensions are not allowed, got %v", shape)
+		}
+		size *= s
+	}
+	if size != len(data) {
+		return nil, fmt.Errorf("cannot create an array of shape %v from %v values", shape, len(data))
+	}
+	return &ComplexArray{Data: data, Shape: append([]int{}, shape...)}, nil
+}
+
+// FromReal returns a complex array with the values of a and zero imaginary parts.
+func FromReal(a *numpy.NDArray) *ComplexArray {
+	data := make([]complex128, a.Size())
+	for i, v := range a.Data() {
+		data[i] = complex(v, 0)
+	}
+	return &ComplexArray{Data: data, Shape: a.Shape()}
+}
+
+// FromParts returns a complex array with real parts re and imaginary parts im.
+//
+// Errors:
+//
+//	Returns an error if the shapes of re and im differ.
+func FromParts(re, im *numpy.NDArray) (*ComplexArray, error) {
+	if !slices.Equal(re.Shape(), im.Shape()) {
+		return nil, fmt.Errorf("real and imaginary parts have different shapes %v and %v", re.Shape(), im.Shape())
+	}
+	out := FromReal(re)
+	for i, v := range im.Data() {
+		out.Data[i] += complex(0, v)
+	}
+	return out, nil
+}
+
+// asComplex converts the input of a transform into a new complex array. It accepts a *ComplexArray, []complex128,
+// or anything numpy.Array accepts.
+func asComplex(x interface{}) (*ComplexArray, error) {
+	switch v := x.(type) {
+	case *ComplexArray:
+		return v.Copy(), nil
+	case []complex128:
+		return NewComplexArray(append([]complex128{}, v...), len(v))
+	case *numpy.NDArray:
+		return FromReal(v), nil
+	}
+	a, err := numpy.Array(x)
+	if err != nil {
+		return nil, err
+	}
+	return FromReal(a), nil
+}
+
+// asReal converts the input of a real transform into an array. It accepts anything numpy.Array accepts.
+func asReal(x interface{}) (*numpy.NDArray, error) {
+	if a, ok := x.(*numpy.NDArray); ok {
+		return a, nil
+	}
+	return numpy.Array(x)
+}
+
+// Ndim returns the number of dimensions.
+func (c *ComplexArray) Ndim() int {
+	return len(c.Shape)
+}
+
+// Size returns the number of elements.
+func (c *ComplexArray) Size() int {
+	return len(c.Data)
+}
+
+// Copy returns a deep copy of the array.
+func (c *ComplexArray) Copy() *ComplexArray {
+	return &ComplexArray{Data: append([]complex128{}, c.Data...), Shape: append([]int{}, c.Shape...)}
+}
+
+// apply returns a real array with fn applied to every value.
+func (c *ComplexArray) apply(fn func(complex128) float64) *numpy.NDArray {
+	out := make([]float64, len(c.Data))
+	for i, v := range c.Data {
+		out[i] = fn(v)
+	}
+	a, _ := numpy.NewArray(out, c.Shape...)
+	return a
+}
+
+// Real returns the real parts.
+func (c *ComplexArray) Real() *numpy.NDArray {
+	return c.apply(func(v complex128) float64 { return real(v) })
+}
+
+// Imag returns the imaginary parts.
+func (c *ComplexArray) Imag() *numpy.NDArray {
+	return c.apply(func(v complex128) float64 { return imag(v) })
+}
+
+// Abs returns the magnitudes, such as the amplitude spectrum of a transform.
+func (c *ComplexArray) Abs() *numpy.NDArray {
+	return c.apply(cmplx.Abs)
+}
+
+// Angle returns the phases in radians, in (-pi, pi].
+func (c *ComplexArray) Angle() *numpy.NDArray {
+	return c.apply(func(v complex128) float64 { return math.Atan2(imag(v), real(v)) })
+}
+
+// Conj returns the complex conjugate.
+func (c *ComplexArray) Conj() *ComplexArray {
+	out := c.Copy()
+	for i, v := range out.Data {
+		out.Data[i] = cmplx.Conj(v)
+	}
+	return out
+}
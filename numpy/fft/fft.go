@@ -0,0 +1,425 @@
+package fft
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+
+	"github.com/timotewb/gonn/numpy"
+)
+
+// normalizeAxis converts a possibly negative axis into the range [0, ndim).
+func normalizeAxis(axis, ndim int) (int, error) {
+	if axis < -ndim || axis >= ndim {
+		return 0, fmt.Errorf("axis %v is out of bounds for array of dimension %v", axis, ndim)
+	}
+	if axis < 0 {
+		axis += ndim
+	}
+	return axis, nil
+}
+
+// scaleFactor returns the factor applied to a transform of length n for a normalization mode:
+//
+//	"backward" (or ""): no scaling of the forward transform and 1/n for the inverse, the numpy default.
+//	"ortho": 1/sqrt(n) for both, making the transforms unitary.
+//	"forward": 1/n for the forward transform and no scaling of the inverse.
+func scaleFactor(norm string, n int, inverse bool) (float64, error) {
+	switch norm {
+	case "", "backward":
+		if inverse {
+			return 1 / float64(n), nil
+		}
+		return 1, nil
+	case "ortho":
+		return 1 / math.Sqrt(float64(n)), nil
+	case "forward":
+		if inverse {
+			return 1, nil
+		}
+		return 1 / float64(n), nil
+	}
+	return 0, fmt.Errorf("invalid norm value %q; should be \"backward\", \"ortho\" or \"forward\"", norm)
+}
+
+// alongAxis applies fn to every 1-D lane of c along axis. fn receives the input lane and an output lane of length
+// outLen to fill, and the result has the shape of c with outLen along axis.
+func alongAxis(c *ComplexArray, axis, outLen int, fn func(in, out []complex128)) *ComplexArray {
+	outer, inner := 1, 1
+	for _, s := range c.Shape[:axis] {
+		outer *= s
+	}
+	for _, s := range c.Shape[axis+1:] {
+		inner *= s
+	}
+	n := c.Shape[axis]
+	shape := append([]int{}, c.Shape...)
+	shape[axis] = outLen
+	out := make([]complex128, outer*outLen*inner)
+	in, lane := make([]complex128, n), make([]complex128, outLen)
+	for o := 0; o < outer; o++ {
+		for i := 0; i < inner; i++ {
+			for k := range in {
+				in[k] = c.Data[(o*n+k)*inner+i]
+			}
+			fn(in, lane)
+			for k, v := range lane {
+				out[(o*outLen+k)*inner+i] = v
+			}
+		}
+	}
+	return &ComplexArray{Data: out, Shape: shape}
+}
+
+// points resolves the number of points n of a transform along axis, where 0 selects the length of the axis.
+func points(c *ComplexArray, n, axis int) (int, int, error) {
+	axis, err := normalizeAxis(axis, c.Ndim())
+	if err != nil {
+		return 0, 0, err
+	}
+	if n == 0 {
+		n = c.Shape[axis]
+	}
+	if n < 1 {
+		return 0, 0, fmt.Errorf("invalid number of data points (%v) specified", n)
+	}
+	return n, axis, nil
+}
+
+// transform computes the complex transform of length n along axis, cropping or zero-padding the input to n points.
+func transform(c *ComplexArray, n, axis int, inverse bool, norm string) (*ComplexArray, error) {
+	n, axis, err := points(c, n, axis)
+	if err != nil {
+		return nil, err
+	}
+	scale, err := scaleFactor(norm, n, inverse)
+	if err != nil {
+		return nil, err
+	}
+	p := planFor(n)
+	buf := make([]complex128, n)
+	return alongAxis(c, axis, n, func(in, out []complex128) {
+		for k := range buf {
+			buf[k] = 0
+		}
+		copy(buf, in)
+		var y []complex128
+		if inverse {
+			y = p.inverse(buf)
+		} else {
+			y = p.forward(buf)
+		}
+		for k, v := range y {
+			out[k] = v * complex(scale, 0)
+		}
+	}), nil
+}
+
+// FFT computes the 1-D discrete Fourier transform along an axis, like numpy.fft.fft:
+//
+//	X[k] = sum over j of x[j] * exp(-2 pi i j k / n)
+//
+// Any length is supported: lengths whose prime factors are at most 31 use a mixed-radix Cooley-Tukey transform and
+// others Bluestein's algorithm, both in O(n log n).
+//
+// Parameters:
+//
+//	x (interface{}): The input, a *ComplexArray, []complex128, *numpy.NDArray or anything numpy.Array accepts.
+//	n (int): The length of the transform. The input is cropped or zero-padded to n points along the axis; 0 uses
+//	         the length of the axis.
+//	axis (int): The axis to transform; negative values count from the last axis.
+//	norm (string): The normalization mode, "backward" (or ""), "ortho" or "forward".
+//
+// Returns:
+//
+//	(*ComplexArray, error): The transform, with the shape of x and n points along the axis.
+//
+// Errors:
+//
+//	Returns an error if x cannot be converted, the axis is out of bounds, n is negative or resolves to zero, or
+//	norm is not valid.
+//
+// Example usage:
+//
+//	spectrum, _ := fft.FFT([]float64{1, 2, 3, 4}, 0, -1, "")
+//	// spectrum.Data is [10+0i -2+2i -2+0i -2-2i]
+func FFT(x interface{}, n, axis int, norm string) (*ComplexArray, error) {
+	c, err := asComplex(x)
+	if err != nil {
+		return nil, err
+	}
+	return transform(c, n, axis, false, norm)
+}
+
+// IFFT computes the 1-D inverse discrete Fourier transform along an axis, like numpy.fft.ifft, so that
+// IFFT(FFT(x)) equals x for the same norm. The parameters are as for FFT.
+func IFFT(x interface{}, n, axis int, norm string) (*ComplexArray, error) {
+	c, err := asComplex(x)
+	if err != nil {
+		return nil, err
+	}
+	return transform(c, n, axis, true, norm)
+}
+
+// RFFT computes the 1-D discrete Fourier transform of real input along an axis, like numpy.fft.rfft. The spectrum
+// of a real signal is Hermitian-symmetric, so only the n/2 + 1 non-negative frequency terms are returned.
+//
+// Parameters:
+//
+//	x (interface{}): The real input, a *numpy.NDArray or anything numpy.Array accepts.
+//	n (int): The length of the transform, or 0 for the length of the axis.
+//	axis (int): The axis to transform.
+//	norm (string): The normalization mode, "backward" (or ""), "ortho" or "forward".
+//
+// Returns:
+//
+//	(*ComplexArray, error): The transform, with n/2 + 1 points along the axis.
+//
+// Errors:
+//
+//	Returns an error if x cannot be converted, the axis is out of bounds, n is not valid or norm is not valid.
+func RFFT(x interface{}, n, axis int, norm string) (*ComplexArray, error) {
+	a, err := asReal(x)
+	if err != nil {
+		return nil, err
+	}
+	c := FromReal(a)
+	n, axis, err = points(c, n, axis)
+	if err != nil {
+		return nil, err
+	}
+	full, err := transform(c, n, axis, false, norm)
+	if err != nil {
+		return nil, err
+	}
+	return alongAxis(full, axis, n/2+1, func(in, out []complex128) { copy(out, in) }), nil
+}
+
+// IRFFT computes the inverse of RFFT along an axis, like numpy.fft.irfft, returning a real signal of n points.
+//
+// The input holds the non-negative frequency terms of a Hermitian-symmetric spectrum; it is cropped or zero-padded
+// to n/2 + 1 terms and the negative frequencies are filled in by symmetry. The imaginary parts of the zero frequency
+// term, and of the Nyquist term for even n, are ignored.
+//
+// Parameters:
+//
+//	x (interface{}): The spectrum, a *ComplexArray, []complex128, *numpy.NDArray or anything numpy.Array accepts.
+//	n (int): The length of the output, or 0 for 2 * (m - 1) where m is the length of the axis.
+//	axis (int): The axis to transform.
+//	norm (string): The normalization mode, "backward" (or ""), "ortho" or "forward".
+//
+// Returns:
+//
+//	(*numpy.NDArray, error): The real signal, with n points along the axis.
+//
+// Errors:
+//
+//	Returns an error if x cannot be converted, the axis is out of bounds, n is not valid or norm is not valid.
+func IRFFT(x interface{}, n, axis int, norm string) (*numpy.NDArray, error) {
+	c, err := asComplex(x)
+	if err != nil {
+		return nil, err
+	}
+	out, err := irfft(c, n, axis, norm)
+	if err != nil {
+		return nil, err
+	}
+	return out.Real(), nil
+}
+
+// irfft computes the inverse real transform along an axis, returning the complex result with zero imaginary parts.
+func irfft(c *ComplexArray, n, axis int, norm string) (*ComplexArray, error) {
+	axis, err := normalizeAxis(axis, c.Ndim())
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		n = 2 * (c.Shape[axis] - 1)
+	}
+	if n < 1 {
+		return nil, fmt.Errorf("invalid number of data points (%v) specified", n)
+	}
+	half := alongAxis(c, axis, n, func(in, out []complex128) {
+		for k := range out {
+			out[k] = 0
+		}
+		for k := 0; k <= n/2 && k < len(in); k++ {
+			out[k] = in[k]
+		}
+		for k := n/2 + 1; k < n; k++ {
+			out[k] = cmplx.Conj(out[n-k])
+		}
+	})
+	full, err := transform(half, n, axis, true, norm)
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range full.Data {
+		full.Data[i] = complex(real(v), 0)
+	}
+	return full, nil
+}
+
+// cookAxes resolves the shape and axes of an N-D transform like numpy: with no axes, the last len(s) axes are
+// transformed, or every axis if s is also nil; with no s, the lengths of the axes are used.
+func cookAxes(c *ComplexArray, s, axes []int) ([]int, []int, error) {
+	if axes == nil {
+		k := c.Ndim()
+		if s != nil {
+			k = len(s)
+		}
+		if k > c.Ndim() {
+			return nil, nil, fmt.Errorf("shape %v has more dimensions than the input of shape %v", s, c.Shape)
+		}
+		for i := c.Ndim() - k; i < c.Ndim(); i++ {
+			axes = append(axes, i)
+		}
+	}
+	seen := map[int]bool{}
+	out := make([]int, len(axes))
+	for i, ax := range axes {
+		ax, err := normalizeAxis(ax, c.Ndim())
+		if err != nil {
+			return nil, nil, err
+		}
+		if seen[ax] {
+			return nil, nil, fmt.Errorf("repeated axis %v", ax)
+		}
+		seen[ax] = true
+		out[i] = ax
+	}
+	if s == nil {
+		s = make([]int, len(out))
+	}
+	if len(s) != len(out) {
+		return nil, nil, fmt.Errorf("shape %v and axes %v have different lengths", s, axes)
+	}
+	return s, out, nil
+}
+
+// transformN applies the complex transform along every axis in turn.
+func transformN(x interface{}, s, axes []int, inverse bool, norm string) (*ComplexArray, error) {
+	c, err := asComplex(x)
+	if err != nil {
+		return nil, err
+	}
+	s, axes, err = cookAxes(c, s, axes)
+	if err != nil {
+		return nil, err
+	}
+	for i, ax := range axes {
+		if c, err = transform(c, s[i], ax, inverse, norm); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// FFTN computes the N-dimensional discrete Fourier transform over the given axes, like numpy.fft.fftn, as 1-D
+// transforms along each axis in turn.
+//
+// Parameters:
+//
+//	x (interface{}): The input, a *ComplexArray, []complex128, *numpy.NDArray or anything numpy.Array accepts.
+//	s ([]int): The length of the transform along each axis, with 0 for the length of the axis, or nil for the
+//	           lengths of the axes.
+//	axes ([]int): The axes to transform, or nil for the last len(s) axes, or every axis if s is also nil.
+//	norm (string): The normalization mode, "backward" (or ""), "ortho" or "forward".
+//
+// Returns:
+//
+//	(*ComplexArray, error): The transform.
+//
+// Errors:
+//
+//	Returns an error if x cannot be converted, an axis is out of bounds or repeated, s and axes have different
+//	lengths, a length is not valid or norm is not valid.
+func FFTN(x interface{}, s, axes []int, norm string) (*ComplexArray, error) {
+	return transformN(x, s, axes, false, norm)
+}
+
+// IFFTN computes the N-dimensional inverse discrete Fourier transform, like numpy.fft.ifftn. The parameters are as
+// for FFTN.
+func IFFTN(x interface{}, s, axes []int, norm string) (*ComplexArray, error) {
+	return transformN(x, s, axes, true, norm)
+}
+
+// lastTwo returns the default axes of the 2-D transforms.
+func lastTwo(axes []int) []int {
+	if axes == nil {
+		return []int{-2, -1}
+	}
+	return axes
+}
+
+// FFT2 computes the 2-D discrete Fourier transform, like numpy.fft.fft2. It is FFTN over the last two axes unless
+// axes is given.
+func FFT2(x interface{}, s, axes []int, norm string) (*ComplexArray, error) {
+	return FFTN(x, s, lastTwo(axes), norm)
+}
+
+// IFFT2 computes the 2-D inverse discrete Fourier transform, like numpy.fft.ifft2. It is IFFTN over the last two
+// axes unless axes is given.
+func IFFT2(x interface{}, s, axes []int, norm string) (*ComplexArray, error) {
+	return IFFTN(x, s, lastTwo(axes), norm)
+}
+
+// RFFTN computes the N-dimensional discrete Fourier transform of real input, like numpy.fft.rfftn: a real
+// transform over the last of the axes, which keeps s[-1]/2 + 1 points, followed by complex transforms over the
+// others. The parameters are as for FFTN, with x real.
+func RFFTN(x interface{}, s, axes []int, norm string) (*ComplexArray, error) {
+	a, err := asReal(x)
+	if err != nil {
+		return nil, err
+	}
+	s, axes, err = cookAxes(FromReal(a), s, axes)
+	if err != nil {
+		return nil, err
+	}
+	if len(axes) == 0 {
+		return FromReal(a), nil
+	}
+	last := len(axes) - 1
+	c, err := RFFT(a, s[last], axes[last], norm)
+	if err != nil {
+		return nil, err
+	}
+	return FFTN(c, s[:last], axes[:last], norm)
+}
+
+// IRFFTN computes the inverse of RFFTN, like numpy.fft.irfftn: complex inverse transforms over all but the last of
+// the axes, followed by a real inverse transform over the last. s gives the lengths of the output, where 0 or a nil
+// s selects 2 * (m - 1) along the last axis as in IRFFT.
+func IRFFTN(x interface{}, s, axes []int, norm string) (*numpy.NDArray, error) {
+	c, err := asComplex(x)
+	if err != nil {
+		return nil, err
+	}
+	s, axes, err = cookAxes(c, s, axes)
+	if err != nil {
+		return nil, err
+	}
+	if len(axes) == 0 {
+		return c.Real(), nil
+	}
+	last := len(axes) - 1
+	for i, ax := range axes[:last] {
+		if c, err = transform(c, s[i], ax, true, norm); err != nil {
+			return nil, err
+		}
+	}
+	if c, err = irfft(c, s[last], axes[last], norm); err != nil {
+		return nil, err
+	}
+	return c.Real(), nil
+}
+
+// RFFT2 computes the 2-D discrete Fourier transform of real input, like numpy.fft.rfft2.
+func RFFT2(x interface{}, s, axes []int, norm string) (*ComplexArray, error) {
+	return RFFTN(x, s, lastTwo(axes), norm)
+}
+
+// IRFFT2 computes the inverse of RFFT2, like numpy.fft.irfft2.
+func IRFFT2(x interface{}, s, axes []int, norm string) (*numpy.NDArray, error) {
+	return IRFFTN(x, s, lastTwo(axes), norm)
+}
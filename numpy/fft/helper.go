@@ -0,0 +1,153 @@
+package fft
+
+import (
+	"fmt"
+
+	"github.com/timotewb/gonn/numpy"
+)
+
+// FFTFreq returns the sample frequencies of a transform of length n with sample spacing d, like numpy.fft.fftfreq:
+//
+//	[0, 1, ..., (n-1)/2, -(n/2), ..., -1] / (d * n)
+//
+// in the order of the FFT output, so sampling at 100 Hz gives d = 0.01 and frequencies in Hz.
+//
+// Errors:
+//
+//	Returns an error if n is not positive.
+func FFTFreq(n int, d float64) (*numpy.NDArray, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("n must be positive, got %v", n)
+	}
+	out := make([]float64, n)
+	for k := range out {
+		f := k
+		if k > (n-1)/2 {
+			f = k - n
+		}
+		out[k] = float64(f) / (d * float64(n))
+	}
+	return numpy.NewArray(out, n)
+}
+
+// RFFTFreq returns the sample frequencies [0, 1, ..., n/2] / (d * n) of the output of RFFT, like
+// numpy.fft.rfftfreq.
+//
+// Errors:
+//
+//	Returns an error if n is not positive.
+func RFFTFreq(n int, d float64) (*numpy.NDArray, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("n must be positive, got %v", n)
+	}
+	out := make([]float64, n/2+1)
+	for k := range out {
+		out[k] = float64(k) / (d * float64(n))
+	}
+	return numpy.NewArray(out, len(out))
+}
+
+// shiftIndices returns, for every element of an array of the given shape, the flat index of the element moved to
+// it when the given axes (every axis if none) are rolled by half their length: n/2 for FFTShift and -(n/2) for
+// IFFTShift.
+func shiftIndices(shape []int, inverse bool, axes []int) ([]int, error) {
+	roll := make([]int, len(shape))
+	if len(axes) == 0 {
+		for i := range shape {
+			axes = append(axes, i)
+		}
+	}
+	for _, ax := range axes {
+		ax, err := normalizeAxis(ax, len(shape))
+		if err != nil {
+			return nil, err
+		}
+		roll[ax] = shape[ax] / 2
+		if inverse {
+			roll[ax] = shape[ax] - shape[ax]/2
+		}
+	}
+	size := 1
+	for _, s := range shape {
+		size *= s
+	}
+	src := make([]int, size)
+	index := make([]int, len(shape))
+	for i := range src {
+		// Map the destination index back to its source, walking the axes from the last
+		flat, rem, stride := 0, i, 1
+		for ax := len(shape) - 1; ax >= 0; ax-- {
+			index[ax] = rem % shape[ax]
+			rem /= shape[ax]
+			flat += ((index[ax] - roll[ax] + shape[ax]) % shape[ax]) * stride
+			stride *= shape[ax]
+		}
+		src[i] = flat
+	}
+	return src, nil
+}
+
+// FFTShift moves the zero frequency term to the centre of the spectrum by rolling each of the given axes (every
+// axis if none) by half its length, like numpy.fft.fftshift. It is usually applied to FFTFreq or Abs of a transform
+// before plotting.
+//
+// Errors:
+//
+//	Returns an error if an axis is out of bounds.
+func FFTShift(x *numpy.NDArray, axes ...int) (*numpy.NDArray, error) {
+	return shiftReal(x, false, axes)
+}
+
+// IFFTShift undoes FFTShift, like numpy.fft.ifftshift. The two differ for odd lengths.
+//
+// Errors:
+//
+//	Returns an error if an axis is out of bounds.
+func IFFTShift(x *numpy.NDArray, axes ...int) (*numpy.NDArray, error) {
+	return shiftReal(x, true, axes)
+}
+
+// shiftReal applies FFTShift or IFFTShift to a real array.
+func shiftReal(x *numpy.NDArray, inverse bool, axes []int) (*numpy.NDArray, error) {
+	src, err := shiftIndices(x.Shape(), inverse, axes)
+	if err != nil {
+		return nil, err
+	}
+	data := x.Data()
+	out := make([]float64, len(src))
+	for i, j := range src {
+		out[i] = data[j]
+	}
+	return numpy.NewArray(out, x.Shape()...)
+}
+
+// FFTShift moves the zero frequency term to the centre of the spectrum, like the package-level FFTShift.
+//
+// Errors:
+//
+//	Returns an error if an axis is out of bounds.
+func (c *ComplexArray) FFTShift(axes ...int) (*ComplexArray, error) {
+	return c.shift(false, axes)
+}
+
+// IFFTShift undoes FFTShift, like the package-level IFFTShift.
+//
+// Errors:
+//
+//	Returns an error if an axis is out of bounds.
+func (c *ComplexArray) IFFTShift(axes ...int) (*ComplexArray, error) {
+	return c.shift(true, axes)
+}
+
+// shift applies FFTShift or IFFTShift to a complex array.
+func (c *ComplexArray) shift(inverse bool, axes []int) (*ComplexArray, error) {
+	src, err := shiftIndices(c.Shape, inverse, axes)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]complex128, len(src))
+	for i, j := range src {
+		out[i] = c.Data[j]
+	}
+	return &ComplexArray{Data: out, Shape: append([]int{}, c.Shape...)}, nil
+}
@@ -0,0 +1,193 @@
+package fft
+
+import (
+	"math"
+	"math/cmplx"
+	"sync"
+)
+
+// maxRadix is the largest prime factor handled by the mixed-radix transform. The butterfly of a radix r costs r^2
+// operations, so lengths with a larger prime factor use Bluestein's algorithm instead.
+const maxRadix = 31
+
+// maxPlans bounds the number of cached plans; the cache is cleared when it is full.
+const maxPlans = 64
+
+// plan holds the precomputed tables of a forward transform of length n.
+//
+// Lengths whose prime factors are at most maxRadix use a recursive mixed-radix Cooley-Tukey decimation in time, as
+// in KISS FFT. Other lengths use Bluestein's algorithm, which rewrites the transform as a convolution with a chirp
+// and computes it with a power of two transform of length m >= 2n - 1.
+type plan struct {
+	n        int
+	factors  []int
+	twiddles []complex128
+
+	// Bluestein's algorithm
+	chirp  []complex128
+	filter []complex128
+	sub    *plan
+}
+
+var (
+	plansMu sync.Mutex
+	plans   = map[int]*plan{}
+)
+
+// planFor returns the cached plan of length n, creating it if needed.
+func planFor(n int) *plan {
+	plansMu.Lock()
+	p, ok := plans[n]
+	plansMu.Unlock()
+	if ok {
+		return p
+	}
+	p = newPlan(n)
+	plansMu.Lock()
+	if len(plans) >= maxPlans {
+		plans = map[int]*plan{}
+	}
+	plans[n] = p
+	plansMu.Unlock()
+	return p
+}
+
+// factorize returns the prime factors of n in increasing order, or nil for n = 1.
+func factorize(n int) []int {
+	var factors []int
+	for f := 2; f*f <= n; f++ {
+		for n%f == 0 {
+			factors = append(factors, f)
+			n /= f
+		}
+	}
+	if n > 1 {
+		factors = append(factors, n)
+	}
+	return factors
+}
+
+// newPlan computes the tables of a transform of length n.
+func newPlan(n int) *plan {
+	p := &plan{n: n, factors: factorize(n)}
+	if len(p.factors) > 0 && p.factors[len(p.factors)-1] > maxRadix {
+		p.bluesteinTables()
+		return p
+	}
+	p.twiddles = make([]complex128, n)
+	for k := range p.twiddles {
+		s, c := math.Sincos(-2 * math.Pi * float64(k) / float64(n))
+		p.twiddles[k] = complex(c, s)
+	}
+	return p
+}
+
+// bluesteinTables computes the chirp exp(-i pi k^2 / n) and the transform of its conjugate, wrapped around a
+// power of two length. k^2 is reduced modulo 2n first so the angles stay accurate for large k.
+func (p *plan) bluesteinTables() {
+	m := 1
+	for m < 2*p.n-1 {
+		m *= 2
+	}
+	p.sub = planFor(m)
+	p.chirp = make([]complex128, p.n)
+	b := make([]complex128, m)
+	for k := range p.chirp {
+		s, c := math.Sincos(-math.Pi * float64((k*k)%(2*p.n)) / float64(p.n))
+		p.chirp[k] = complex(c, s)
+		b[k] = cmplx.Conj(p.chirp[k])
+		if k > 0 {
+			b[m-k] = b[k]
+		}
+	}
+	p.filter = p.sub.forward(b)
+}
+
+// forward returns the unnormalized discrete Fourier transform of x, which must have length n.
+func (p *plan) forward(x []complex128) []complex128 {
+	out := make([]complex128, p.n)
+	switch {
+	case p.n == 1:
+		out[0] = x[0]
+	case p.sub != nil:
+		p.bluestein(x, out)
+	default:
+		p.work(out, x, 1, p.factors)
+	}
+	return out
+}
+
+// inverse returns the unnormalized inverse transform of x, using ifft(x) = conj(fft(conj(x))).
+func (p *plan) inverse(x []complex128) []complex128 {
+	in := make([]complex128, len(x))
+	for i, v := range x {
+		in[i] = cmplx.Conj(v)
+	}
+	out := p.forward(in)
+	for i, v := range out {
+		out[i] = cmplx.Conj(v)
+	}
+	return out
+}
+
+// work transforms the elements of in at multiples of stride into out, whose length is n / stride. The first factor
+// splits the input into that many interleaved subsequences, which are transformed recursively into consecutive
+// blocks of out and then combined by a butterfly.
+func (p *plan) work(out, in []complex128, stride int, factors []int) {
+	r := factors[0]
+	m := len(out) / r
+	if m == 1 {
+		for q := 0; q < r; q++ {
+			out[q] = in[q*stride]
+		}
+	} else {
+		for q := 0; q < r; q++ {
+			p.work(out[q*m:(q+1)*m], in[q*stride:], stride*r, factors[1:])
+		}
+	}
+	p.butterfly(out, stride, r, m)
+}
+
+// butterfly combines r transforms of length m, stored in consecutive blocks of out, into one transform of length
+// r * m:
+//
+//	X[k + u*m] = sum over q of W^(q*(k + u*m)) * Y_q[k]
+//
+// where W = exp(-2 pi i / (r * m)) is the twiddle table entry at stride.
+func (p *plan) butterfly(out []complex128, stride, r, m int) {
+	t := make([]complex128, r)
+	for k := 0; k < m; k++ {
+		for q := 0; q < r; q++ {
+			t[q] = out[q*m+k] * p.twiddles[q*k*stride]
+		}
+		if r == 2 {
+			out[k], out[m+k] = t[0]+t[1], t[0]-t[1]
+			continue
+		}
+		for u := 0; u < r; u++ {
+			var s complex128
+			for q := 0; q < r; q++ {
+				s += t[q] * p.twiddles[(q*u%r)*m*stride]
+			}
+			out[u*m+k] = s
+		}
+	}
+}
+
+// bluestein computes the transform as chirp * (conv(x * chirp, conj(chirp))), with the convolution computed by the
+// power of two sub-plan.
+func (p *plan) bluestein(x, out []complex128) {
+	a := make([]complex128, p.sub.n)
+	for k, c := range p.chirp {
+		a[k] = x[k] * c
+	}
+	a = p.sub.forward(a)
+	for i := range a {
+		a[i] *= p.filter[i]
+	}
+	a = p.sub.inverse(a)
+	scale := complex(1/float64(p.sub.n), 0)
+	for k, c := range p.chirp {
+		out[k] = a[k] * c * scale
+	}
+}
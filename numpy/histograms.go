@@ -0,0 +1,331 @@
+package numpy
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Histogram computes the histogram of the values in an array, like numpy.histogram.
+//
+// The bins are either a number of equal-width bins spanning valueRange, or a monotonically increasing slice of bin
+// edges. Every bin is half-open, [edge_i, edge_i+1), except the last which also includes its right edge. Values
+// outside the range of the bins are ignored.
+//
+// Parameters:
+//
+//	a (*NDArray): The input data. The histogram is computed over the flattened array.
+//	bins (interface{}): An int number of bins, or a []float64 or *NDArray of bin edges.
+//	valueRange ([]float64): The lower and upper range of the bins when bins is an int, or nil to use the minimum and
+//	                        maximum of a.
+//	density (bool): If true, return the value of the probability density function at each bin, normalised so that
+//	                the integral over the range is 1, instead of the counts.
+//	weights (*NDArray): An optional array of the same shape as a weighting each value, or nil.
+//
+// Returns:
+//
+//	(*NDArray, *NDArray, error): The values of the histogram and the bin edges (one more than the number of bins).
+//
+// Errors:
+//
+//	Returns an error if bins or valueRange are invalid or weights does not match the shape of a.
+func Histogram(a *NDArray, bins interface{}, valueRange []float64, density bool, weights *NDArray) (*NDArray, *NDArray, error) {
+	values := a.Data()
+	w, err := histogramWeights(weights, a)
+	if err != nil {
+		return nil, nil, err
+	}
+	edges, err := binEdges(values, bins, valueRange)
+	if err != nil {
+		return nil, nil, err
+	}
+	nBins := len(edges) - 1
+	hist := make([]float64, nBins)
+	for i, v := range values {
+		if b := binIndex(edges, v); b >= 0 {
+			hist[b] += w(i)
+		}
+	}
+	if density {
+		normaliseDensity(hist, [][]float64{edges})
+	}
+	return newArray(hist, []int{nBins}), newArray(edges, []int{len(edges)}), nil
+}
+
+// Histogram2d computes the bi-dimensional histogram of two data samples, like numpy.histogram2d.
+//
+// Parameters:
+//
+//	x, y (*NDArray): The coordinates of the points. Both are flattened and must have the same number of elements.
+//	bins (interface{}): An int number of bins for both dimensions, a []int with the number of bins for each dimension,
+//	                    or a [][]float64 with the bin edges for each dimension.
+//	valueRange ([][]float64): The [min, max] range for each dimension when the number of bins is given, or nil.
+//	density (bool): If true, return the probability density function at each bin instead of the counts.
+//	weights (*NDArray): An optional array weighting each point, or nil.
+//
+// Returns:
+//
+//	(*NDArray, *NDArray, *NDArray, error): The 2-D histogram of shape (nx, ny) and the bin edges along x and y.
+//
+// Errors:
+//
+//	Returns an error if x and y differ in size, or bins, valueRange or weights are invalid.
+func Histogram2d(x, y *NDArray, bins interface{}, valueRange [][]float64, density bool, weights *NDArray) (*NDArray, *NDArray, *NDArray, error) {
+	if x.Size() != y.Size() {
+		return nil, nil, nil, fmt.Errorf("x and y must have the same length")
+	}
+	w, err := histogramWeights(weights, x)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Split the bins and range specifications into one per dimension
+	var binsX, binsY interface{}
+	switch b := bins.(type) {
+	case int:
+		binsX, binsY = b, b
+	case []int:
+		if len(b) != 2 {
+			return nil, nil, nil, fmt.Errorf("bins must have one entry per dimension")
+		}
+		binsX, binsY = b[0], b[1]
+	case [][]float64:
+		if len(b) != 2 {
+			return nil, nil, nil, fmt.Errorf("bins must have one entry per dimension")
+		}
+		binsX, binsY = b[0], b[1]
+	default:
+		return nil, nil, nil, fmt.Errorf("bins must be an int, []int or [][]float64, got %T", bins)
+	}
+	var rangeX, rangeY []float64
+	if valueRange != nil {
+		if len(valueRange) != 2 {
+			return nil, nil, nil, fmt.Errorf("range must have one entry per dimension")
+		}
+		rangeX, rangeY = valueRange[0], valueRange[1]
+	}
+
+	xs, ys := x.Data(), y.Data()
+	edgesX, err := binEdges(xs, binsX, rangeX)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	edgesY, err := binEdges(ys, binsY, rangeY)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	nx, ny := len(edgesX)-1, len(edgesY)-1
+	hist := make([]float64, nx*ny)
+	for i := range xs {
+		bx, by := binIndex(edgesX, xs[i]), binIndex(edgesY, ys[i])
+		if bx >= 0 && by >= 0 {
+			hist[bx*ny+by] += w(i)
+		}
+	}
+	if density {
+		normaliseDensity(hist, [][]float64{edgesX, edgesY})
+	}
+	return newArray(hist, []int{nx, ny}), newArray(edgesX, []int{len(edgesX)}), newArray(edgesY, []int{len(edgesY)}), nil
+}
+
+// histogramWeights returns a function giving the weight of the i-th value of a, which is 1 when weights is nil.
+func histogramWeights(weights, a *NDArray) (func(i int) float64, error) {
+	if weights == nil {
+		return func(int) float64 { return 1 }, nil
+	}
+	if weights.Size() != a.Size() {
+		return nil, fmt.Errorf("weights should have the same shape as a")
+	}
+	w := weights.Data()
+	return func(i int) float64 { return w[i] }, nil
+}
+
+// binEdges returns the bin edges described by bins and valueRange for the given values.
+func binEdges(values []float64, bins interface{}, valueRange []float64) ([]float64, error) {
+	switch b := bins.(type) {
+	case int:
+		if b < 1 {
+			return nil, fmt.Errorf("bins must be a positive integer")
+		}
+		lo, hi, err := histogramRange(values, valueRange)
+		if err != nil {
+			return nil, err
+		}
+		edges := make([]float64, b+1)
+		for i := range edges {
+			edges[i] = lo + (hi-lo)*float64(i)/float64(b)
+		}
+		edges[b] = hi
+		return edges, nil
+	case []float64:
+		return checkEdges(append([]float64{}, b...))
+	case *NDArray:
+		return checkEdges(append([]float64{}, b.Data()...))
+	default:
+		return nil, fmt.Errorf("bins must be an int, []float64 or *NDArray, got %T", bins)
+	}
+}
+
+// checkEdges verifies that bin edges are monotonically increasing.
+func checkEdges(edges []float64) ([]float64, error) {
+	if len(edges) < 2 {
+		return nil, fmt.Errorf("bins must contain at least two edges")
+	}
+	for i := 1; i < len(edges); i++ {
+		if edges[i] < edges[i-1] {
+			return nil, fmt.Errorf("bins must increase monotonically")
+		}
+	}
+	return edges, nil
+}
+
+// histogramRange returns the lower and upper range of the bins, widening an empty range by 0.5 on each side.
+func histogramRange(values []float64, valueRange []float64) (float64, float64, error) {
+	var lo, hi float64
+	if valueRange != nil {
+		if len(valueRange) != 2 || valueRange[0] > valueRange[1] {
+			return 0, 0, fmt.Errorf("max must be larger than min in range parameter")
+		}
+		lo, hi = valueRange[0], valueRange[1]
+	} else if len(values) == 0 {
+		lo, hi = 0, 1
+	} else {
+		lo, hi = math.Inf(1), math.Inf(-1)
+		for _, v := range values {
+			lo = math.Min(lo, v)
+			hi = math.Max(hi, v)
+		}
+	}
+	if math.IsNaN(lo) || math.IsNaN(hi) || math.IsInf(lo, 0) || math.IsInf(hi, 0) {
+		return 0, 0, fmt.Errorf("autodetected range of [%v, %v] is not finite", lo, hi)
+	}
+	if lo == hi {
+		lo, hi = lo-0.5, hi+0.5
+	}
+	return lo, hi, nil
+}
+
+// binIndex returns the bin containing v, or -1 if v is outside the edges. The last bin includes its right edge.
+func binIndex(edges []float64, v float64) int {
+	last := len(edges) - 1
+	if math.IsNaN(v) || v < edges[0] || v > edges[last] {
+		return -1
+	}
+	if v == edges[last] {
+		return last - 1
+	}
+	return sort.Search(last, func(i int) bool { return edges[i+1] > v })
+}
+
+// normaliseDensity divides the histogram by the total count and the bin volumes so that it integrates to one.
+func normaliseDensity(hist []float64, edges [][]float64) {
+	total := 0.
+	for _, v := range hist {
+		total += v
+	}
+	for i := range hist {
+		volume := 1.
+		rest := i
+		for d := len(edges) - 1; d >= 0; d-- {
+			n := len(edges[d]) - 1
+			k := rest % n
+			rest /= n
+			volume *= edges[d][k+1] - edges[d][k]
+		}
+		hist[i] /= total * volume
+	}
+}
+
+// Bincount counts the number of occurrences of each value in an array of non-negative integers, like numpy.bincount.
+//
+// Parameters:
+//
+//	x (*NDArray): A 1-D array of non-negative integer values.
+//	weights (*NDArray): An optional array of the same shape as x; if given, out[n] += weights[i] for every x[i] == n.
+//	minLength (int): A minimum number of bins for the output.
+//
+// Returns:
+//
+//	(*NDArray, error): An array of length max(max(x) + 1, minLength) holding the counts (or summed weights).
+//
+// Errors:
+//
+//	Returns an error if x is not 1-D, contains negative, non-integer or infinite values, or weights does not match x.
+func Bincount(x *NDArray, weights *NDArray, minLength int) (*NDArray, error) {
+	if len(x.shape) != 1 {
+		return nil, fmt.Errorf("object too deep for desired array")
+	}
+	if minLength < 0 {
+		return nil, fmt.Errorf("minLength must not be negative")
+	}
+	w, err := histogramWeights(weights, x)
+	if err != nil {
+		return nil, err
+	}
+	values := x.Data()
+	n := minLength
+	for _, v := range values {
+		if v < 0 {
+			return nil, fmt.Errorf("x must contain only non-negative values")
+		}
+		if v != math.Trunc(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("x must contain only finite integer values")
+		}
+		if v >= float64(math.MaxInt) {
+			return nil, fmt.Errorf("value %v is too large to count", v)
+		}
+		n = max(n, int(v)+1)
+	}
+	out := make([]float64, n)
+	for i, v := range values {
+		out[int(v)] += w(i)
+	}
+	return newArray(out, []int{n}), nil
+}
+
+// Digitize returns the indices of the bins to which each value in x belongs, like numpy.digitize.
+//
+// For increasing bins the index i satisfies bins[i-1] <= x < bins[i] (or bins[i-1] < x <= bins[i] when right is
+// true). For decreasing bins the inequalities are reversed. Values beyond the bins get 0 or len(bins).
+//
+// Parameters:
+//
+//	x (*NDArray): The values to be binned.
+//	bins ([]float64): A monotonically increasing or decreasing slice of bin edges.
+//	right (bool): Whether the intervals include the right edge instead of the left.
+//
+// Returns:
+//
+//	(*NDArray, error): An array of the same shape as x holding the bin indices.
+//
+// Errors:
+//
+//	Returns an error if bins is not monotonic.
+func Digitize(x *NDArray, bins []float64, right bool) (*NDArray, error) {
+	increasing, decreasing := true, true
+	for i := 1; i < len(bins); i++ {
+		increasing = increasing && bins[i] >= bins[i-1]
+		decreasing = decreasing && bins[i] <= bins[i-1]
+	}
+	if !increasing && !decreasing {
+		return nil, fmt.Errorf("bins must be monotonically increasing or decreasing")
+	}
+	values := x.Data()
+	out := make([]float64, len(values))
+	n := len(bins)
+	for i, v := range values {
+		var k int
+		switch {
+		case increasing && right:
+			k = sort.Search(n, func(j int) bool { return bins[j] >= v })
+		case increasing:
+			k = sort.Search(n, func(j int) bool { return bins[j] > v })
+		case right:
+			k = n - sort.Search(n, func(j int) bool { return bins[n-1-j] >= v })
+		default:
+			k = n - sort.Search(n, func(j int) bool { return bins[n-1-j] > v })
+		}
+		out[i] = float64(k)
+	}
+	return newArray(out, x.Shape()), nil
+}
@@ -0,0 +1,18 @@
+package numpy
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBincountRejectsNonFinite(t *testing.T) {
+	for _, v := range []float64{math.Inf(1), math.Inf(-1), math.NaN(), 1e300} {
+		x, err := NewArray([]float64{1, v}, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Bincount(x, nil, 0); err == nil {
+			t.Errorf("Bincount accepted %v", v)
+		}
+	}
+}
@@ -0,0 +1,457 @@
+package numpy
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// NDArray is an n-dimensional array of float64 values modelled on numpy's ndarray.
+//
+// The values are held in a flat buffer and located through a shape, a set of strides and an offset, exactly as numpy does.
+// Because of this, slicing, transposing and broadcasting can return views that share the buffer of the original array
+// instead of copying nested slices. Strides are counted in elements (not bytes), can be negative (reversed views) and
+// can be zero (broadcast views).
+//
+// Example usage:
+//
+//	a, _ := numpy.Array([][]float64{{1, 2, 3}, {4, 5, 6}})
+//	col, _ := a.View(numpy.S(), 1) // view of the second column, no data is copied
+//	t := a.T()                      // transposed view
+//	c := t.Copy()                   // materialised C-contiguous copy
+type NDArray struct {
+	data    []float64
+	shape   []int
+	strides []int
+	offset  int
+	base    *NDArray
+	flags   Flags
+}
+
+// Flags mirrors the most commonly used entries of numpy's ndarray.flags.
+//
+// Fields:
+//
+//	CContiguous (bool): The elements are laid out in row-major (C) order with no gaps.
+//	FContiguous (bool): The elements are laid out in column-major (Fortran) order with no gaps.
+//	OwnData (bool): The array owns its buffer; false for views.
+//	Writeable (bool): The elements can be modified. Broadcast views are read-only, as in numpy.
+type Flags struct {
+	CContiguous bool
+	FContiguous bool
+	OwnData     bool
+	Writeable   bool
+}
+
+// NewArray wraps a flat slice of float64 values in an NDArray of the given shape.
+//
+// The data is not copied; the returned array uses data as its buffer in row-major order. If no shape is provided the
+// array is one dimensional with the same length as data.
+//
+// Parameters:
+//
+//	data ([]float64): The values of the array in row-major order.
+//	shape (...int): The dimensions of the array. Their product must equal len(data).
+//
+// Returns:
+//
+//	(*NDArray, error): The new array, or nil and an error if the shape does not match the data.
+//
+// Errors:
+//
+//	Returns an error if a dimension is negative or if the product of the dimensions does not equal len(data).
+func NewArray(data []float64, shape ...int) (*NDArray, error) {
+	if len(shape) == 0 {
+		shape = []int{len(data)}
+	}
+	size, err := shapeSize(shape)
+	if err != nil {
+		return nil, err
+	}
+	if size != len(data) {
+		return nil, fmt.Errorf("cannot create array of shape %v from %v values", shape, len(data))
+	}
+	return newArray(data, shape), nil
+}
+
+// Array converts a float64, a slice of float64 or a nested slice structure into an NDArray.
+//
+// The input can be any of the representations used elsewhere in this package, for example the []interface{} results
+// returned by Zeros or Dot, or typed slices such as [][]float64. Integer and float32 elements are converted to float64.
+// The values are copied, so the resulting array does not share memory with the input.
+//
+// Parameters:
+//
+//	x (interface{}): A scalar or a (possibly nested) slice with a regular, non-ragged shape.
+//
+// Returns:
+//
+//	(*NDArray, error): The new C-contiguous array, or nil and an error if the input cannot be converted.
+//
+// Errors:
+//
+//	Returns an error if the input is ragged or contains elements that are not numbers.
+func Array(x interface{}) (*NDArray, error) {
+	if a, ok := x.(*NDArray); ok {
+		return a.Copy(), nil
+	}
+	xVal := reflect.ValueOf(x)
+	if !xVal.IsValid() {
+		return nil, fmt.Errorf("x must not be nil")
+	}
+
+	// Determine the shape by following the first element of every level
+	shape := []int{}
+	for v := xVal; ; {
+		if v.Kind() == reflect.Interface {
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Slice {
+			break
+		}
+		shape = append(shape, v.Len())
+		if v.Len() == 0 {
+			break
+		}
+		v = v.Index(0)
+	}
+
+	// Walk the structure again, checking it is regular and collecting the values
+	size, _ := shapeSize(shape)
+	data := make([]float64, 0, size)
+	if err := collectValues(xVal, shape, &data); err != nil {
+		return nil, err
+	}
+	return newArray(data, shape), nil
+}
+
+// collectValues appends the leaf values of v to data, checking that v matches shape at every level.
+func collectValues(v reflect.Value, shape []int, data *[]float64) error {
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if len(shape) == 0 {
+		switch v.Kind() {
+		case reflect.Float32, reflect.Float64:
+			*data = append(*data, v.Float())
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			*data = append(*data, float64(v.Int()))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			*data = append(*data, float64(v.Uint()))
+		default:
+			return fmt.Errorf("unsupported element type: %v", v.Kind())
+		}
+		return nil
+	}
+	if v.Kind() != reflect.Slice || v.Len() != shape[0] {
+		return fmt.Errorf("input is ragged, expected a slice of length %v at this level", shape[0])
+	}
+	for i := 0; i < v.Len(); i++ {
+		if err := collectValues(v.Index(i), shape[1:], data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ZerosArray returns a new C-contiguous NDArray of the given shape filled with zeros.
+// Like Zeros, it returns nil if the shape is invalid (a dimension is negative).
+func ZerosArray(shape ...int) *NDArray {
+	size, err := shapeSize(shape)
+	if err != nil {
+		return nil
+	}
+	return newArray(make([]float64, size), shape)
+}
+
+// OnesArray returns a new C-contiguous NDArray of the given shape filled with ones.
+func OnesArray(shape ...int) *NDArray {
+	return FullArray(1, shape...)
+}
+
+// FullArray returns a new C-contiguous NDArray of the given shape filled with value.
+func FullArray(value float64, shape ...int) *NDArray {
+	a := ZerosArray(shape...)
+	if a == nil {
+		return nil
+	}
+	for i := range a.data {
+		a.data[i] = value
+	}
+	return a
+}
+
+// newArray creates a C-contiguous array that owns data. The caller guarantees len(data) matches shape.
+func newArray(data []float64, shape []int) *NDArray {
+	a := &NDArray{
+		data:    data,
+		shape:   append([]int{}, shape...),
+		strides: cStrides(shape),
+	}
+	a.flags.OwnData = true
+	a.flags.Writeable = true
+	a.updateFlags()
+	return a
+}
+
+// newView creates an array sharing the buffer of a with the given layout.
+func (a *NDArray) newView(shape, strides []int, offset int) *NDArray {
+	v := &NDArray{
+		data:    a.data,
+		shape:   shape,
+		strides: strides,
+		offset:  offset,
+		base:    a.owner(),
+	}
+	v.flags.Writeable = a.flags.Writeable
+	v.updateFlags()
+	return v
+}
+
+// owner returns the array that owns the buffer of a.
+func (a *NDArray) owner() *NDArray {
+	if a.base != nil {
+		return a.base
+	}
+	return a
+}
+
+// updateFlags recomputes the contiguity flags from the shape and strides.
+func (a *NDArray) updateFlags() {
+	a.flags.CContiguous = isContiguous(a.shape, a.strides, false)
+	a.flags.FContiguous = isContiguous(a.shape, a.strides, true)
+}
+
+// isContiguous reports whether the layout has no gaps in C order (or Fortran order if fortran is true).
+// Dimensions of length one are ignored, and arrays with no elements are always contiguous, as in numpy.
+func isContiguous(shape, strides []int, fortran bool) bool {
+	for _, n := range shape {
+		if n == 0 {
+			return true
+		}
+	}
+	expected := 1
+	for k := range shape {
+		i := len(shape) - 1 - k
+		if fortran {
+			i = k
+		}
+		if shape[i] == 1 {
+			continue
+		}
+		if strides[i] != expected {
+			return false
+		}
+		expected *= shape[i]
+	}
+	return true
+}
+
+// cStrides returns the strides of a C-contiguous array of the given shape.
+func cStrides(shape []int) []int {
+	strides := make([]int, len(shape))
+	s := 1
+	for i := len(shape) - 1; i >= 0; i-- {
+		strides[i] = s
+		s *= shape[i]
+	}
+	return strides
+}
+
+// shapeSize returns the number of elements in an array of the given shape.
+func shapeSize(shape []int) (int, error) {
+	size := 1
+	for _, n := range shape {
+		if n < 0 {
+			return 0, fmt.Errorf("negative dimensions are not allowed: %v", shape)
+		}
+		size *= n
+	}
+	return size, nil
+}
+
+// Shape returns a copy of the dimensions of the array.
+func (a *NDArray) Shape() []int {
+	return append([]int{}, a.shape...)
+}
+
+// Strides returns a copy of the strides of the array, counted in elements.
+func (a *NDArray) Strides() []int {
+	return append([]int{}, a.strides...)
+}
+
+// Ndim returns the number of dimensions of the array.
+func (a *NDArray) Ndim() int {
+	return len(a.shape)
+}
+
+// Size returns the number of elements in the array.
+func (a *NDArray) Size() int {
+	size, _ := shapeSize(a.shape)
+	return size
+}
+
+// Flags returns the memory layout flags of the array.
+func (a *NDArray) Flags() Flags {
+	return a.flags
+}
+
+// Base returns the array owning the memory of a view, or nil if the array owns its data.
+func (a *NDArray) Base() *NDArray {
+	return a.base
+}
+
+// IsContiguous reports whether the array is C-contiguous (row-major with no gaps).
+func (a *NDArray) IsContiguous() bool {
+	return a.flags.CContiguous
+}
+
+// IsFContiguous reports whether the array is Fortran-contiguous (column-major with no gaps).
+func (a *NDArray) IsFContiguous() bool {
+	return a.flags.FContiguous
+}
+
+// flatIndex converts a multi-dimensional index into a position in the buffer, checking the bounds.
+func (a *NDArray) flatIndex(index []int) (int, error) {
+	if len(index) != len(a.shape) {
+		return 0, fmt.Errorf("expected %v indices, got %v", len(a.shape), len(index))
+	}
+	pos := a.offset
+	for i, idx := range index {
+		if idx < 0 {
+			idx += a.shape[i]
+		}
+		if idx < 0 || idx >= a.shape[i] {
+			return 0, fmt.Errorf("index %v is out of bounds for axis %v with size %v", index[i], i, a.shape[i])
+		}
+		pos += idx * a.strides[i]
+	}
+	return pos, nil
+}
+
+// At returns the element at the given index. Negative indices count from the end of an axis.
+//
+// Errors:
+//
+//	Returns an error if the number of indices does not match the number of dimensions or an index is out of bounds.
+func (a *NDArray) At(index ...int) (float64, error) {
+	pos, err := a.flatIndex(index)
+	if err != nil {
+		return 0, err
+	}
+	return a.data[pos], nil
+}
+
+// SetAt sets the element at the given index. Negative indices count from the end of an axis.
+//
+// Errors:
+//
+//	Returns an error if the array is read-only, the number of indices does not match the number of dimensions or an
+//	index is out of bounds.
+func (a *NDArray) SetAt(value float64, index ...int) error {
+	if !a.flags.Writeable {
+		return fmt.Errorf("assignment destination is read-only")
+	}
+	pos, err := a.flatIndex(index)
+	if err != nil {
+		return err
+	}
+	a.data[pos] = value
+	return nil
+}
+
+// offsets returns the buffer position of every element in row-major order.
+func (a *NDArray) offsets() []int {
+	size := a.Size()
+	out := make([]int, size)
+	if size == 0 {
+		return out
+	}
+	if a.flags.CContiguous {
+		for i := range out {
+			out[i] = a.offset + i
+		}
+		return out
+	}
+	index := make([]int, len(a.shape))
+	pos := a.offset
+	for i := 0; i < size; i++ {
+		out[i] = pos
+		// Advance the index like an odometer, updating the position incrementally
+		for d := len(index) - 1; d >= 0; d-- {
+			index[d]++
+			pos += a.strides[d]
+			if index[d] < a.shape[d] {
+				break
+			}
+			pos -= index[d] * a.strides[d]
+			index[d] = 0
+		}
+	}
+	return out
+}
+
+// Data returns the elements of the array in row-major order.
+//
+// For C-contiguous arrays the returned slice shares memory with the array, so writing to it modifies the array.
+// For any other layout a copy is returned, mirroring the view-or-copy behaviour of numpy.ravel.
+func (a *NDArray) Data() []float64 {
+	if a.flags.CContiguous {
+		return a.data[a.offset : a.offset+a.Size()]
+	}
+	out := make([]float64, a.Size())
+	for i, pos := range a.offsets() {
+		out[i] = a.data[pos]
+	}
+	return out
+}
+
+// Copy returns a new C-contiguous array holding a copy of the elements of a.
+func (a *NDArray) Copy() *NDArray {
+	out := make([]float64, a.Size())
+	if a.flags.CContiguous {
+		copy(out, a.data[a.offset:a.offset+len(out)])
+	} else {
+		for i, pos := range a.offsets() {
+			out[i] = a.data[pos]
+		}
+	}
+	return newArray(out, a.shape)
+}
+
+// AsContiguous returns a C-contiguous array with the elements of a, like numpy.ascontiguousarray.
+// If a is already C-contiguous it is returned unchanged, otherwise a copy is made.
+func (a *NDArray) AsContiguous() *NDArray {
+	if a.flags.CContiguous {
+		return a
+	}
+	return a.Copy()
+}
+
+// ToSlice converts the array back to the nested slice representation used by the other functions in this package.
+//
+// A 0-d array is returned as a float64, a 1-d array as []float64 and arrays with more dimensions as nested
+// []interface{} values whose innermost elements are []float64, matching the output of Zeros.
+func (a *NDArray) ToSlice() interface{} {
+	values := a.Data()
+	if len(a.shape) == 0 {
+		return values[0]
+	}
+	var build func(dim, start int) interface{}
+	build = func(dim, start int) interface{} {
+		if dim == len(a.shape)-1 {
+			row := make([]float64, a.shape[dim])
+			copy(row, values[start:start+a.shape[dim]])
+			return row
+		}
+		stride := 1
+		for _, n := range a.shape[dim+1:] {
+			stride *= n
+		}
+		out := make([]interface{}, a.shape[dim])
+		for i := range out {
+			out[i] = build(dim+1, start+i*stride)
+		}
+		return out
+	}
+	return build(0, 0)
+}
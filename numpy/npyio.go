@@ -0,0 +1,309 @@
+package numpy
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	bin "encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// npyMagic starts every file in the NumPy .npy format.
+const npyMagic = "\x93NUMPY"
+
+// WriteNpy writes a in the NumPy .npy format (version 1.0) as little-endian float64 values in C order, so it can be
+// read by numpy.load.
+//
+// Errors:
+//
+//	Returns an error if writing fails.
+func WriteNpy(w io.Writer, a *NDArray) error {
+	shape := make([]string, len(a.Shape()))
+	for i, n := range a.Shape() {
+		shape[i] = strconv.Itoa(n)
+	}
+	shapeStr := strings.Join(shape, ", ")
+	if len(shape) == 1 {
+		shapeStr += ","
+	}
+	header := fmt.Sprintf("{'descr': '<f8', 'fortran_order': False, 'shape': (%v), }", shapeStr)
+	// The magic, version and length take 10 bytes; the header is padded with spaces and a newline to a multiple of 64
+	pad := 64 - (10+len(header)+1)%64
+	if pad == 64 {
+		pad = 0
+	}
+	header += strings.Repeat(" ", pad) + "\n"
+
+	buf := bufio.NewWriter(w)
+	buf.WriteString(npyMagic)
+	buf.Write([]byte{1, 0})
+	bin.Write(buf, bin.LittleEndian, uint16(len(header)))
+	buf.WriteString(header)
+	b := make([]byte, 8)
+	for _, v := range a.Data() {
+		bin.LittleEndian.PutUint64(b, math.Float64bits(v))
+		buf.Write(b)
+	}
+	return buf.Flush()
+}
+
+// npyDescr, npyFortran and npyShape match the fields of a .npy header dictionary.
+var (
+	npyDescr   = regexp.MustCompile(`'descr':\s*'([<>|=])([a-z])(\d+)'`)
+	npyFortran = regexp.MustCompile(`'fortran_order':\s*(True|False)`)
+	npyShape   = regexp.MustCompile(`'shape':\s*\(([\d,\s]*)\)`)
+)
+
+// ReadNpy reads an array in the NumPy .npy format, as written by numpy.save.
+//
+// Boolean, integer and floating point arrays of either byte order and in C or Fortran order are supported; their
+// values are converted to float64.
+//
+// Errors:
+//
+//	Returns an error if the data is not a .npy file, its dtype is not supported (such as complex or object arrays),
+//	or it is truncated.
+func ReadNpy(r io.Reader) (*NDArray, error) {
+	magic := make([]byte, 8)
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return nil, err
+	}
+	if string(magic[:6]) != npyMagic {
+		return nil, fmt.Errorf("not a .npy file")
+	}
+	var headerLen int
+	switch magic[6] {
+	case 1:
+		var n uint16
+		if err := bin.Read(r, bin.LittleEndian, &n); err != nil {
+			return nil, err
+		}
+		headerLen = int(n)
+	case 2, 3:
+		var n uint32
+		if err := bin.Read(r, bin.LittleEndian, &n); err != nil {
+			return nil, err
+		}
+		headerLen = int(n)
+	default:
+		return nil, fmt.Errorf("unsupported .npy format version %v.%v", magic[6], magic[7])
+	}
+	header := make([]byte, headerLen)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+
+	descr := npyDescr.FindSubmatch(header)
+	fortran := npyFortran.FindSubmatch(header)
+	shapeMatch := npyShape.FindSubmatch(header)
+	if descr == nil || fortran == nil || shapeMatch == nil {
+		return nil, fmt.Errorf("malformed .npy header %q", header)
+	}
+	shape := []int{}
+	for _, s := range strings.Split(string(shapeMatch[1]), ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				return nil, err
+			}
+			shape = append(shape, n)
+		}
+	}
+	size, err := shapeSize(shape)
+	if err != nil {
+		return nil, err
+	}
+
+	var order bin.ByteOrder = bin.LittleEndian
+	if descr[1][0] == '>' {
+		order = bin.BigEndian
+	}
+	itemSize, _ := strconv.Atoi(string(descr[3]))
+	decode, err := npyDecoder(descr[2][0], itemSize, order)
+	if err != nil {
+		return nil, err
+	}
+	raw := make([]byte, size*itemSize)
+	if _, err := io.ReadFull(r, raw); err != nil {
+		return nil, fmt.Errorf("reading .npy data: %v", err)
+	}
+	data := make([]float64, size)
+	for i := range data {
+		data[i] = decode(raw[i*itemSize : (i+1)*itemSize])
+	}
+
+	if len(shape) == 0 {
+		return Scalar(data[0]), nil
+	}
+	if string(fortran[1]) == "False" || len(shape) < 2 {
+		return NewArray(data, shape...)
+	}
+	// Fortran order stores the transpose of the array in C order
+	reversed := make([]int, len(shape))
+	for i, n := range shape {
+		reversed[len(shape)-1-i] = n
+	}
+	a, err := NewArray(data, reversed...)
+	if err != nil {
+		return nil, err
+	}
+	return a.T().Copy(), nil
+}
+
+// npyDecoder returns a function converting one element of the given dtype kind and size to float64.
+func npyDecoder(kind byte, size int, order bin.ByteOrder) (func(b []byte) float64, error) {
+	switch {
+	case kind == 'f' && size == 8:
+		return func(b []byte) float64 { return math.Float64frombits(order.Uint64(b)) }, nil
+	case kind == 'f' && size == 4:
+		return func(b []byte) float64 { return float64(math.Float32frombits(order.Uint32(b))) }, nil
+	case (kind == 'i' || kind == 'u' || kind == 'b') && size == 1:
+		if kind == 'i' {
+			return func(b []byte) float64 { return float64(int8(b[0])) }, nil
+		}
+		return func(b []byte) float64 { return float64(b[0]) }, nil
+	case kind == 'i' && size == 2:
+		return func(b []byte) float64 { return float64(int16(order.Uint16(b))) }, nil
+	case kind == 'u' && size == 2:
+		return func(b []byte) float64 { return float64(order.Uint16(b)) }, nil
+	case kind == 'i' && size == 4:
+		return func(b []byte) float64 { return float64(int32(order.Uint32(b))) }, nil
+	case kind == 'u' && size == 4:
+		return func(b []byte) float64 { return float64(order.Uint32(b)) }, nil
+	case kind == 'i' && size == 8:
+		return func(b []byte) float64 { return float64(int64(order.Uint64(b))) }, nil
+	case kind == 'u' && size == 8:
+		return func(b []byte) float64 { return float64(order.Uint64(b)) }, nil
+	}
+	return nil, fmt.Errorf("unsupported dtype %c%v", kind, size)
+}
+
+// Save writes a to a file in the NumPy .npy format, like numpy.save.
+//
+// Errors:
+//
+//	Returns an error if the file cannot be written.
+func Save(path string, a *NDArray) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := WriteNpy(f, a); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Load reads an array from a .npy file, like numpy.load. See ReadNpy for the supported dtypes.
+//
+// Errors:
+//
+//	Returns an error if the file cannot be read or is not a supported .npy file.
+func Load(path string) (*NDArray, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadNpy(bufio.NewReader(f))
+}
+
+// Savez writes several arrays to a file in the NumPy .npz format, like numpy.savez, or numpy.savez_compressed if
+// compress is set. Each array is stored under its name with a ".npy" suffix.
+//
+// Errors:
+//
+//	Returns an error if the file cannot be written.
+func Savez(path string, arrays map[string]*NDArray, compress bool) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	z := zip.NewWriter(f)
+	names := make([]string, 0, len(arrays))
+	for name := range arrays {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := WriteNpzEntry(z, name, arrays[name], compress); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := z.Close(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// WriteNpzEntry adds an array named name to a zip archive in the .npz layout, so archives holding other files as
+// well can be built with archive/zip.
+//
+// Errors:
+//
+//	Returns an error if writing fails.
+func WriteNpzEntry(z *zip.Writer, name string, a *NDArray, compress bool) error {
+	method := zip.Store
+	if compress {
+		method = zip.Deflate
+	}
+	w, err := z.CreateHeader(&zip.FileHeader{Name: name + ".npy", Method: method})
+	if err != nil {
+		return err
+	}
+	return WriteNpy(w, a)
+}
+
+// LoadNpz reads every array of a .npz file, like numpy.load, keyed by name without the ".npy" suffix. Files in the
+// archive without the suffix are ignored.
+//
+// Errors:
+//
+//	Returns an error if the file cannot be read or an array is not a supported .npy file.
+func LoadNpz(path string) (map[string]*NDArray, error) {
+	z, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer z.Close()
+	return ReadNpzEntries(&z.Reader)
+}
+
+// ReadNpzEntries reads the ".npy" files of an opened zip archive, keyed by name without the suffix.
+//
+// Errors:
+//
+//	Returns an error if an array is not a supported .npy file.
+func ReadNpzEntries(z *zip.Reader) (map[string]*NDArray, error) {
+	arrays := map[string]*NDArray{}
+	for _, file := range z.File {
+		if !strings.HasSuffix(file.Name, ".npy") {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		a, err := ReadNpy(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", file.Name, err)
+		}
+		arrays[strings.TrimSuffix(file.Name, ".npy")] = a
+	}
+	return arrays, nil
+}
@@ -0,0 +1,419 @@
+package numpy
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// PrintOptions holds the settings that control how arrays are converted to strings, mirroring numpy.set_printoptions.
+//
+// Fields:
+//
+//	Precision (int): Maximum number of digits printed after the decimal point.
+//	Threshold (int): Arrays with more elements than this are summarised with "..." instead of printed in full.
+//	EdgeItems (int): Number of items shown at the start and end of each axis when an array is summarised.
+//	LineWidth (int): Number of characters per line after which lines are wrapped.
+//	Suppress (bool): If true, always use fixed point notation, printing numbers too small for the precision as zero.
+type PrintOptions struct {
+	Precision int
+	Threshold int
+	EdgeItems int
+	LineWidth int
+	Suppress  bool
+}
+
+// printOptions holds the current settings used by String, Repr and ArrayToString. The defaults match numpy.
+var printOptions = PrintOptions{
+	Precision: 8,
+	Threshold: 1000,
+	EdgeItems: 3,
+	LineWidth: 75,
+	Suppress:  false,
+}
+
+// SetPrintOptions changes how arrays are printed, like numpy.set_printoptions.
+//
+// Parameters:
+//
+//	precision (int): Maximum number of digits printed after the decimal point (default 8).
+//	threshold (int): Total number of elements above which arrays are summarised (default 1000).
+//	edgeItems (int): Number of items shown at the start and end of each summarised axis (default 3).
+//	lineWidth (int): Number of characters per line for the purpose of inserting line breaks (default 75).
+//	suppress (bool): If true, always use fixed point notation and print very small numbers as zero (default false).
+//
+// Errors:
+//
+//	Returns an error if any of the integer options are negative, or if lineWidth is zero. The current options are left
+//	unchanged in that case.
+func SetPrintOptions(precision, threshold, edgeItems, lineWidth int, suppress bool) error {
+	if precision < 0 || threshold < 0 || edgeItems < 0 || lineWidth <= 0 {
+		return fmt.Errorf("print options must be non-negative and lineWidth must be positive")
+	}
+	printOptions = PrintOptions{
+		Precision: precision,
+		Threshold: threshold,
+		EdgeItems: edgeItems,
+		LineWidth: lineWidth,
+		Suppress:  suppress,
+	}
+	return nil
+}
+
+// GetPrintOptions returns the current print options, like numpy.get_printoptions.
+func GetPrintOptions() PrintOptions {
+	return printOptions
+}
+
+// String returns the array formatted like numpy's str(), with nested brackets and aligned columns.
+//
+// Example output for a 2x3 array:
+//
+//	[[1.  2.  3. ]
+//	 [4.  5.  6.5]]
+func (a *NDArray) String() string {
+	return a.toString(printOptions, " ", "")
+}
+
+// Repr returns the array formatted like numpy's repr(), for example "array([1., 2.])".
+func (a *NDArray) Repr() string {
+	return a.repr(printOptions)
+}
+
+// repr formats the array like numpy's repr() using the given options.
+func (a *NDArray) repr(opts PrintOptions) string {
+	if a.Size() == 0 {
+		if len(a.shape) == 1 {
+			return "array([], dtype=float64)"
+		}
+		return fmt.Sprintf("array([], shape=%v, dtype=float64)", formatShape(a.shape))
+	}
+	opts.LineWidth -= len(")")
+	return "array(" + a.toString(opts, ", ", "array(") + ")"
+}
+
+// Format implements fmt.Formatter so arrays print in numpy's layout with the fmt package.
+//
+// The %v and %s verbs produce the same output as String, and %#v produces the output of Repr. A precision given in
+// the verb, such as %.3v, overrides the Precision print option for that call.
+func (a *NDArray) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 'v', 's':
+		opts := printOptions
+		if p, ok := f.Precision(); ok {
+			opts.Precision = p
+		}
+		if verb == 'v' && f.Flag('#') {
+			fmt.Fprint(f, a.repr(opts))
+			return
+		}
+		fmt.Fprint(f, a.toString(opts, " ", ""))
+	default:
+		fmt.Fprintf(f, "%%!%c(*numpy.NDArray=%v)", verb, formatShape(a.shape))
+	}
+}
+
+// ArrayToString formats a scalar, nested slice or NDArray using numpy's layout, like numpy.array2string.
+//
+// This makes the []interface{} results returned by functions such as Add and Dot readable when printed.
+//
+// Parameters:
+//
+//	x (interface{}): A float64, a (possibly nested) slice or an *NDArray.
+//
+// Returns:
+//
+//	(string, error): The formatted array, or an empty string and an error if x cannot be converted to an array.
+//
+// Errors:
+//
+//	Returns an error if x is ragged or contains elements that are not numbers.
+func ArrayToString(x interface{}) (string, error) {
+	a, ok := x.(*NDArray)
+	if !ok {
+		var err error
+		a, err = Array(x)
+		if err != nil {
+			return "", err
+		}
+	}
+	return a.String(), nil
+}
+
+// formatShape formats a shape as a Python tuple, for example "(2, 3)" or "(4,)".
+func formatShape(shape []int) string {
+	parts := make([]string, len(shape))
+	for i, n := range shape {
+		parts[i] = strconv.Itoa(n)
+	}
+	if len(parts) == 1 {
+		return "(" + parts[0] + ",)"
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+// toString formats the array with the given options, element separator and first line prefix.
+func (a *NDArray) toString(opts PrintOptions, separator, prefix string) string {
+	if len(a.shape) == 0 {
+		v := a.data[a.offset]
+		return newFloatFormat([]float64{v}, opts).format(v)
+	}
+	if a.Size() == 0 {
+		return "[]"
+	}
+
+	// Summarise large arrays, formatting only the values that will be displayed
+	summarise := a.Size() > opts.Threshold
+	format := newFloatFormat(a.displayedValues(summarise, opts.EdgeItems), opts)
+
+	hanging := " " + strings.Repeat(" ", len(prefix))
+	return a.formatArray(format, opts, separator, summarise, []int{}, hanging, opts.LineWidth)
+}
+
+// displayedValues returns the values that appear in the printed output of the array.
+func (a *NDArray) displayedValues(summarise bool, edgeItems int) []float64 {
+	if !summarise {
+		return a.Data()
+	}
+	values := []float64{}
+	var walk func(index []int)
+	walk = func(index []int) {
+		axis := len(index)
+		if axis == len(a.shape) {
+			pos, _ := a.flatIndex(index)
+			values = append(values, a.data[pos])
+			return
+		}
+		for _, i := range summaryIndices(a.shape[axis], true, edgeItems) {
+			walk(append(index, i))
+		}
+	}
+	walk([]int{})
+	return values
+}
+
+// summaryIndices returns the positions displayed along an axis of length n.
+func summaryIndices(n int, summarise bool, edgeItems int) []int {
+	if !summarise || 2*edgeItems >= n {
+		out := make([]int, n)
+		for i := range out {
+			out[i] = i
+		}
+		return out
+	}
+	out := make([]int, 0, 2*edgeItems)
+	for i := 0; i < edgeItems; i++ {
+		out = append(out, i)
+	}
+	for i := n - edgeItems; i < n; i++ {
+		out = append(out, i)
+	}
+	return out
+}
+
+// formatArray recursively formats the sub-array at index, following numpy's _formatArray.
+//
+// hanging is the indentation used for continuation lines and width is the space available on each line.
+func (a *NDArray) formatArray(format *floatFormat, opts PrintOptions, separator string, summarise bool, index []int, hanging string, width int) string {
+	axis := len(index)
+	axesLeft := len(a.shape) - axis
+	if axesLeft == 0 {
+		pos, _ := a.flatIndex(index)
+		return format.format(a.data[pos])
+	}
+
+	// When recursing, add a space to align with the added "[" and reduce the line length by one for the "]"
+	nextHanging := hanging + " "
+	nextWidth := width - len("]")
+
+	n := a.shape[axis]
+	showSummary := summarise && 2*opts.EdgeItems < n
+	leading, trailing := 0, n
+	if showSummary {
+		leading, trailing = opts.EdgeItems, opts.EdgeItems
+	}
+	child := func(i int) string {
+		return a.formatArray(format, opts, separator, summarise, append(append([]int{}, index...), i), nextHanging, nextWidth)
+	}
+
+	var s strings.Builder
+	trimmedSep := strings.TrimRight(separator, " ")
+	if axesLeft == 1 {
+		// Last axis: wrap elements when they would not fit on one line
+		elemWidth := width - max(len(trimmedSep), len("]"))
+		line := hanging
+		for i := 0; i < leading; i++ {
+			line = extendLine(&s, line, child(i), elemWidth, hanging)
+			line += separator
+		}
+		if showSummary {
+			line = extendLine(&s, line, "...", elemWidth, hanging)
+			line += separator
+		}
+		for i := trailing; i > 1; i-- {
+			line = extendLine(&s, line, child(n-i), elemWidth, hanging)
+			line += separator
+		}
+		line = extendLine(&s, line, child(n-1), elemWidth, hanging)
+		s.WriteString(line)
+	} else {
+		// Other axes: insert newlines between rows, and blank lines between blocks of higher dimensions
+		lineSep := trimmedSep + strings.Repeat("\n", axesLeft-1)
+		for i := 0; i < leading; i++ {
+			s.WriteString(hanging + child(i) + lineSep)
+		}
+		if showSummary {
+			s.WriteString(hanging + "..." + lineSep)
+		}
+		for i := trailing; i > 1; i-- {
+			s.WriteString(hanging + child(n-i) + lineSep)
+		}
+		s.WriteString(hanging + child(n-1))
+	}
+
+	// Remove the hanging indent from the first line and wrap in brackets
+	return "[" + s.String()[len(hanging):] + "]"
+}
+
+// extendLine appends word to line, first moving line into s and starting a new line if the word would not fit.
+func extendLine(s *strings.Builder, line, word string, width int, hanging string) string {
+	if len(line)+len(word) > width && len(line) > len(hanging) {
+		s.WriteString(strings.TrimRight(line, " ") + "\n")
+		line = hanging
+	}
+	return line + word
+}
+
+// floatFormat formats the elements of one array so that they line up, following numpy's FloatingFormat.
+type floatFormat struct {
+	precision int
+	expFormat bool
+	padLeft   int
+	padRight  int
+	expSize   int
+}
+
+// newFloatFormat chooses between positional and scientific notation and computes the padding for the given values.
+func newFloatFormat(values []float64, opts PrintOptions) *floatFormat {
+	f := &floatFormat{precision: opts.Precision, expSize: -1}
+
+	finite := []float64{}
+	hasNonFinite := false
+	hasNegInf := false
+	for _, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			hasNonFinite = true
+			hasNegInf = hasNegInf || math.IsInf(v, -1)
+			continue
+		}
+		finite = append(finite, v)
+	}
+
+	// Use scientific notation when the magnitudes are very large or span a wide range
+	maxVal, minVal := 0., math.Inf(1)
+	for _, v := range finite {
+		if v == 0 {
+			continue
+		}
+		maxVal = math.Max(maxVal, math.Abs(v))
+		minVal = math.Min(minVal, math.Abs(v))
+	}
+	if maxVal > 0 {
+		if maxVal >= 1e8 || (!opts.Suppress && (minVal < 0.0001 || maxVal/minVal > 1000)) {
+			f.expFormat = true
+		}
+	}
+
+	if len(finite) > 0 {
+		if f.expFormat {
+			// Find the largest number of mantissa digits needed and the widest exponent
+			maxFrac := 0
+			for _, v := range finite {
+				mantissa, exponent, _ := strings.Cut(uniqueScientific(v, opts.Precision), "e")
+				intPart, fracPart, _ := strings.Cut(mantissa, ".")
+				maxFrac = max(maxFrac, len(fracPart))
+				f.padLeft = max(f.padLeft, len(intPart))
+				f.expSize = max(f.expSize, len(exponent)-1)
+			}
+			f.precision = maxFrac
+			f.padRight = f.expSize + 2 + f.precision
+		} else {
+			for _, v := range finite {
+				intPart, fracPart, _ := strings.Cut(uniquePositional(v, opts.Precision), ".")
+				f.padLeft = max(f.padLeft, len(intPart))
+				f.padRight = max(f.padRight, len(fracPart))
+			}
+		}
+	}
+
+	// Leave room for nan and inf
+	if hasNonFinite {
+		infLen := len("inf")
+		if hasNegInf {
+			infLen++
+		}
+		offset := f.padRight + 1
+		f.padLeft = max(f.padLeft, len("nan")-offset, infLen-offset)
+	}
+	return f
+}
+
+// format formats a single value using the padding and notation chosen for the array.
+func (f *floatFormat) format(v float64) string {
+	width := f.padLeft + f.padRight + 1
+	switch {
+	case math.IsNaN(v):
+		return fmt.Sprintf("%*s", width, "nan")
+	case math.IsInf(v, 1):
+		return fmt.Sprintf("%*s", width, "inf")
+	case math.IsInf(v, -1):
+		return fmt.Sprintf("%*s", width, "-inf")
+	}
+	if f.expFormat {
+		// Every mantissa gets the same number of digits and the exponent is zero padded
+		mantissa, exponent, _ := strings.Cut(strconv.FormatFloat(v, 'e', f.precision, 64), "e")
+		if f.precision == 0 {
+			mantissa += "."
+		}
+		sign, digits := exponent[:1], exponent[1:]
+		for len(digits) < f.expSize {
+			digits = "0" + digits
+		}
+		return fmt.Sprintf("%*s", f.padLeft+1+f.precision, mantissa) + "e" + sign + digits
+	}
+	s := uniquePositional(v, f.precision)
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	return fmt.Sprintf("%*s", f.padLeft, intPart) + "." + fracPart + strings.Repeat(" ", f.padRight-len(fracPart))
+}
+
+// uniquePositional returns the shortest positional representation of v that round-trips, limited to precision
+// fractional digits, with trailing zeros removed but the decimal point kept (for example "1." or "0.25").
+func uniquePositional(v float64, precision int) string {
+	s := strconv.FormatFloat(v, 'f', -1, 64)
+	if _, frac, ok := strings.Cut(s, "."); ok && len(frac) > precision {
+		s = strconv.FormatFloat(v, 'f', precision, 64)
+	}
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(s, "0")
+	} else {
+		s += "."
+	}
+	return s
+}
+
+// uniqueScientific returns the shortest scientific representation of v that round-trips, limited to precision
+// mantissa digits after the decimal point, with trailing zeros removed (for example "1.e+10" or "1.5e-05").
+func uniqueScientific(v float64, precision int) string {
+	s := strconv.FormatFloat(v, 'e', -1, 64)
+	mantissa, exponent, _ := strings.Cut(s, "e")
+	if _, frac, ok := strings.Cut(mantissa, "."); ok && len(frac) > precision {
+		mantissa, exponent, _ = strings.Cut(strconv.FormatFloat(v, 'e', precision, 64), "e")
+	}
+	if strings.Contains(mantissa, ".") {
+		mantissa = strings.TrimRight(mantissa, "0")
+	} else {
+		mantissa += "."
+	}
+	return mantissa + "e" + exponent
+}
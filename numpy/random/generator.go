@@ -0,0 +1,121 @@
+package random
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/timotewb/gonn/app"
+)
+
+// Generator is a seedable source of random numbers, similar to numpy.random.Generator.
+//
+// Two generators created with the same seed produce the same sequence of values, which makes weight initialization
+// and data shuffling reproducible. A nil *Generator is valid and draws from the package-level functions (Randn and
+// math/rand), so functions accepting a generator can be passed nil when reproducibility is not needed.
+//
+// Example usage:
+//
+//	g := random.NewGenerator(42)
+//	w := g.Randn(3, 4) // [][]float64, the same on every run
+type Generator struct {
+	rng *rand.Rand
+}
+
+// NewGenerator creates a generator seeded with seed.
+func NewGenerator(seed int64) *Generator {
+	return &Generator{rng: rand.New(rand.NewSource(seed))}
+}
+
+// NormFloat64 returns a value drawn from the standard normal distribution.
+func (g *Generator) NormFloat64() float64 {
+	if g == nil {
+		return app.GaussianNoise(0, 1)
+	}
+	return g.rng.NormFloat64()
+}
+
+// Float64 returns a value drawn uniformly from [0, 1).
+func (g *Generator) Float64() float64 {
+	if g == nil {
+		return rand.Float64()
+	}
+	return g.rng.Float64()
+}
+
+// Intn returns a value drawn uniformly from [0, n). It panics if n is not positive, like rand.Intn.
+func (g *Generator) Intn(n int) int {
+	if g == nil {
+		return rand.Intn(n)
+	}
+	return g.rng.Intn(n)
+}
+
+// Randn returns standard normal samples with the same layout as the package-level Randn: a float64 for no
+// arguments, and nested slices with the given dimensions otherwise.
+func (g *Generator) Randn(args ...int) RandnResult {
+	return sample(args, g.NormFloat64)
+}
+
+// Rand returns samples drawn uniformly from [0, 1), like numpy.random.rand, with the same layout as Randn.
+func (g *Generator) Rand(args ...int) RandnResult {
+	return sample(args, g.Float64)
+}
+
+// Shuffle pseudo-randomizes the order of n elements using the Fisher-Yates algorithm, calling swap to exchange the
+// elements with indices i and j, like rand.Shuffle.
+func (g *Generator) Shuffle(n int, swap func(i, j int)) {
+	for i := n - 1; i > 0; i-- {
+		swap(i, g.Intn(i+1))
+	}
+}
+
+// Permutation returns a random permutation of the integers [0, n), like numpy.random.permutation.
+func (g *Generator) Permutation(n int) []int {
+	p := make([]int, n)
+	for i := range p {
+		p[i] = i
+	}
+	g.Shuffle(n, func(i, j int) { p[i], p[j] = p[j], p[i] })
+	return p
+}
+
+// Gamma returns a value drawn from the gamma distribution with the given shape and unit scale, using the method of
+// Marsaglia and Tsang (2000). It panics if shape is not positive.
+func (g *Generator) Gamma(shape float64) float64 {
+	if shape <= 0 {
+		panic("random: gamma shape must be positive")
+	}
+	if shape < 1 {
+		// Boost to shape + 1 and scale back by U^(1 / shape)
+		return g.Gamma(shape+1) * math.Pow(g.Float64(), 1/shape)
+	}
+	d := shape - 1./3
+	c := 1 / math.Sqrt(9*d)
+	for {
+		x := g.NormFloat64()
+		v := 1 + c*x
+		if v <= 0 {
+			continue
+		}
+		v = v * v * v
+		u := g.Float64()
+		if u < 1-0.0331*x*x*x*x || math.Log(u) < 0.5*x*x+d*(1-v+math.Log(v)) {
+			return d * v
+		}
+	}
+}
+
+// Beta returns a value drawn from the beta distribution with parameters a and b, like numpy.random.beta. It panics
+// if a or b is not positive.
+func (g *Generator) Beta(a, b float64) float64 {
+	x := g.Gamma(a)
+	y := g.Gamma(b)
+	if x+y == 0 {
+		// Both draws underflowed, which happens for tiny a and b where the distribution is concentrated at 0 and 1
+		if g.Float64() < a/(a+b) {
+			return 1
+		}
+		return 0
+	}
+	return x / (x + y)
+}
@@ -1,7 +1,7 @@
 package random
 
 import (
-	"fmt"
+	"reflect"
 
 	"github.com/timotewb/gonn/app"
 )
@@ -15,12 +15,14 @@ type RandnResult interface{}
 // - A scalar value (single float) if no arguments are provided.
 // - A 1D vector (slice of floats) if one argument is provided, specifying the length of the vector.
 // - A 2D matrix (slice of slices of floats) if two arguments are provided, specifying the number of rows and columns.
+// - An N-D array of nested slices if more arguments are provided, such as the 4-D kernels of convolutional layers.
 // The generated values are normalized to have zero mean and unit variance.
 //
 // Parameters:
 //
 //	args (...int): Variable number of integer arguments specifying the dimensions of the matrix to generate.
-//	               No arguments generate a scalar, one argument generates a 1D vector, and two arguments generate a 2D matrix.
+//	               No arguments generate a scalar, one argument generates a 1D vector, two arguments generate a 2D matrix,
+//	               and more arguments generate deeper nested slices.
 //
 // Returns:
 //
@@ -28,34 +30,37 @@ type RandnResult interface{}
 //	            - Scalar float64 if no arguments are provided.
 //	            - Slice of float64 if one argument is provided.
 //	            - Slice of slices of float64 if two arguments are provided.
-//	            If an unsupported number of arguments is provided, nil is returned.
+//	            - Nested slices of float64 ([][][]float64, [][][][]float64, ...) if more arguments are provided.
 //
 // Errors:
 //
 //	None documented.
 func Randn(args ...int) RandnResult {
-	switch len(args) {
-	case 0:
-		return app.GaussianNoise(0, 1)
-	case 1:
-		size := args[0]
-		result := make([]float64, size)
-		for i := range result {
-			result[i] = app.GaussianNoise(0, 1)
-		}
-		return result
-	case 2:
-		rows, cols := args[0], args[1]
-		result := make([][]float64, rows)
-		for i := range result {
-			result[i] = make([]float64, cols)
-			for j := range result[i] {
-				result[i][j] = app.GaussianNoise(0, 1)
-			}
+	return sample(args, func() float64 { return app.GaussianNoise(0, 1) })
+}
+
+// sample returns a single value from next if shape is empty, and otherwise a nested slice with the dimensions in
+// shape ([]float64, [][]float64, ...) filled with values from next.
+func sample(shape []int, next func() float64) interface{} {
+	if len(shape) == 0 {
+		return next()
+	}
+	t := reflect.TypeOf(float64(0))
+	for range shape {
+		t = reflect.SliceOf(t)
+	}
+	return nested(t, shape, next).Interface()
+}
+
+// nested builds a nested slice of type t with the dimensions in shape, filled with values from next.
+func nested(t reflect.Type, shape []int, next func() float64) reflect.Value {
+	v := reflect.MakeSlice(t, shape[0], shape[0])
+	for i := 0; i < shape[0]; i++ {
+		if len(shape) == 1 {
+			v.Index(i).SetFloat(next())
+		} else {
+			v.Index(i).Set(nested(t.Elem(), shape[1:], next))
 		}
-		return result
-	default:
-		fmt.Printf("%d integers were passed: %v\n", len(args), args)
-		return nil
 	}
+	return v
 }
@@ -0,0 +1,19 @@
+package random
+
+// Shuffle pseudo-randomizes the order of n elements by calling swap to exchange the elements with indices i and j,
+// like numpy.random.shuffle. Use Generator.Shuffle for a reproducible order.
+//
+// Example usage:
+//
+//	names := []string{"a", "b", "c"}
+//	random.Shuffle(len(names), func(i, j int) { names[i], names[j] = names[j], names[i] })
+func Shuffle(n int, swap func(i, j int)) {
+	var g *Generator
+	g.Shuffle(n, swap)
+}
+
+// Permutation returns a random permutation of the integers [0, n), like numpy.random.permutation.
+func Permutation(n int) []int {
+	var g *Generator
+	return g.Permutation(n)
+}
@@ -0,0 +1,173 @@
+package numpy
+
+import (
+	"fmt"
+	"sort"
+)
+
+// normalizeAxes converts a list of possibly negative axes into sorted, unique axis numbers in the range [0, ndim).
+// An empty list selects every axis, which is how reductions express numpy's axis=None.
+func normalizeAxes(axes []int, ndim int) ([]int, error) {
+	if len(axes) == 0 {
+		all := make([]int, ndim)
+		for i := range all {
+			all[i] = i
+		}
+		return all, nil
+	}
+	seen := make([]bool, ndim)
+	out := make([]int, 0, len(axes))
+	for _, ax := range axes {
+		ax, err := normalizeAxis(ax, ndim)
+		if err != nil {
+			return nil, err
+		}
+		if seen[ax] {
+			return nil, fmt.Errorf("duplicate value in axis")
+		}
+		seen[ax] = true
+		out = append(out, ax)
+	}
+	sort.Ints(out)
+	return out, nil
+}
+
+// laneMatrix rearranges the array so that the given axes are last and returns the elements as a row-major matrix
+// with one row (lane) per position of the remaining axes.
+//
+// Returns:
+//
+//	data ([]float64): A contiguous buffer of outer*inner values; row i holds the elements reduced into output i.
+//	inner (int): The number of elements in each lane.
+//	outShape ([]int): The shape of the remaining axes, which is the shape of the reduction result.
+func laneMatrix(a *NDArray, axes []int) ([]float64, int, []int, error) {
+	axes, err := normalizeAxes(axes, len(a.shape))
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	reduced := make([]bool, len(a.shape))
+	for _, ax := range axes {
+		reduced[ax] = true
+	}
+	order := []int{}
+	outShape := []int{}
+	for i := range a.shape {
+		if !reduced[i] {
+			order = append(order, i)
+			outShape = append(outShape, a.shape[i])
+		}
+	}
+	inner := 1
+	for _, ax := range axes {
+		order = append(order, ax)
+		inner *= a.shape[ax]
+	}
+	t, err := a.Transpose(order...)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	return t.Data(), inner, outShape, nil
+}
+
+// reduce applies fn to every lane of the array along the given axes and returns the results in an array of the
+// remaining shape. With no axes the whole array is reduced to a 0-d array.
+func reduce(a *NDArray, axes []int, fn func(lane []float64) float64) (*NDArray, error) {
+	data, inner, outShape, err := laneMatrix(a, axes)
+	if err != nil {
+		return nil, err
+	}
+	size, _ := shapeSize(outShape)
+	out := make([]float64, size)
+	for i := range out {
+		out[i] = fn(data[i*inner : (i+1)*inner])
+	}
+	return newArray(out, outShape), nil
+}
+
+// Sum returns the sum of the array elements over the given axes, like numpy.sum.
+//
+// With no axes every element is summed and a 0-d array is returned; use Item to read its value.
+//
+// Errors:
+//
+//	Returns an error if an axis is out of bounds or repeated.
+func Sum(a *NDArray, axis ...int) (*NDArray, error) {
+	return reduce(a, axis, func(lane []float64) float64 {
+		s := 0.
+		for _, v := range lane {
+			s += v
+		}
+		return s
+	})
+}
+
+// Mean returns the arithmetic mean of the array elements over the given axes, like numpy.mean.
+//
+// With no axes the mean of every element is returned as a 0-d array. The mean of an empty lane is NaN.
+//
+// Errors:
+//
+//	Returns an error if an axis is out of bounds or repeated.
+func Mean(a *NDArray, axis ...int) (*NDArray, error) {
+	return reduce(a, axis, mean)
+}
+
+// mean returns the arithmetic mean of values, or NaN if there are none.
+func mean(values []float64) float64 {
+	s := 0.
+	for _, v := range values {
+		s += v
+	}
+	return s / float64(len(values))
+}
+
+// Item returns the only element of an array of size one, like ndarray.item.
+//
+// Errors:
+//
+//	Returns an error if the array does not have exactly one element.
+func (a *NDArray) Item() (float64, error) {
+	if a.Size() != 1 {
+		return 0, fmt.Errorf("can only convert an array of size 1 to a float64, got size %v", a.Size())
+	}
+	return a.Data()[0], nil
+}
+
+// alongAxis applies fn to every 1-D lane of the array along axis and assembles the results into a new array.
+//
+// fn receives the input lane and an output lane of length outLen to fill. The result has the same shape as a except
+// along axis, which has length outLen.
+func alongAxis(a *NDArray, axis, outLen int, fn func(in, out []float64)) (*NDArray, error) {
+	axis, err := normalizeAxis(axis, len(a.shape))
+	if err != nil {
+		return nil, err
+	}
+	data, inner, outShape, err := laneMatrix(a, []int{axis})
+	if err != nil {
+		return nil, err
+	}
+	lanes, _ := shapeSize(outShape)
+	out := make([]float64, lanes*outLen)
+	for i := 0; i < lanes; i++ {
+		fn(data[i*inner:(i+1)*inner], out[i*outLen:(i+1)*outLen])
+	}
+
+	// The processed axis is last in the result, so move it back to its original position
+	r := newArray(out, append(outShape, outLen))
+	order := make([]int, len(a.shape))
+	for i := range order {
+		switch {
+		case i < axis:
+			order[i] = i
+		case i == axis:
+			order[i] = len(a.shape) - 1
+		default:
+			order[i] = i - 1
+		}
+	}
+	t, err := r.Transpose(order...)
+	if err != nil {
+		return nil, err
+	}
+	return t.AsContiguous(), nil
+}
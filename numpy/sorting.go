@@ -0,0 +1,421 @@
+package numpy
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// lessNaN orders float64 values like numpy, placing NaN after every other value.
+func lessNaN(x, y float64) bool {
+	return x < y || (math.IsNaN(y) && !math.IsNaN(x))
+}
+
+// checkSortKind returns an error if kind is not one of the sorting algorithms accepted by Sort.
+func checkSortKind(kind string) error {
+	switch kind {
+	case "", "quicksort", "mergesort", "stable", "heapsort":
+		return nil
+	}
+	return fmt.Errorf("sort kind must be one of 'quicksort', 'mergesort', 'heapsort' or 'stable', got %q", kind)
+}
+
+// argSortLane fills idx with the positions of values in sorted order using the given sorting algorithm.
+func argSortLane(values []float64, idx []int, kind string) error {
+	for i := range idx {
+		idx[i] = i
+	}
+	less := func(i, j int) bool { return lessNaN(values[idx[i]], values[idx[j]]) }
+	switch kind {
+	case "", "quicksort":
+		sort.Slice(idx, less)
+	case "mergesort", "stable":
+		sort.SliceStable(idx, less)
+	case "heapsort":
+		heapSort(idx, func(i, j int) bool { return lessNaN(values[i], values[j]) })
+	default:
+		return checkSortKind(kind)
+	}
+	return nil
+}
+
+// heapSort sorts the indices in place with an in-place binary heap, ordering them with less applied to the indices.
+func heapSort(idx []int, less func(i, j int) bool) {
+	siftDown := func(root, end int) {
+		for {
+			child := 2*root + 1
+			if child >= end {
+				return
+			}
+			if child+1 < end && less(idx[child], idx[child+1]) {
+				child++
+			}
+			if !less(idx[root], idx[child]) {
+				return
+			}
+			idx[root], idx[child] = idx[child], idx[root]
+			root = child
+		}
+	}
+	for i := len(idx)/2 - 1; i >= 0; i-- {
+		siftDown(i, len(idx))
+	}
+	for end := len(idx) - 1; end > 0; end-- {
+		idx[0], idx[end] = idx[end], idx[0]
+		siftDown(0, end)
+	}
+}
+
+// Sort returns a sorted copy of the array along the given axis, like numpy.sort.
+//
+// NaN values are sorted to the end. To sort the flattened array, pass a.Ravel() with axis 0.
+//
+// Parameters:
+//
+//	a (*NDArray): The array to sort.
+//	axis (int): The axis along which to sort; -1 sorts along the last axis.
+//	kind (string): The algorithm: "quicksort" (the default, also used when kind is empty), "mergesort", "heapsort" or
+//	               "stable". "mergesort" and "stable" keep equal elements in their original order.
+//
+// Returns:
+//
+//	(*NDArray, error): A sorted array of the same shape as a.
+//
+// Errors:
+//
+//	Returns an error if the axis is out of bounds or the kind is unknown.
+func Sort(a *NDArray, axis int, kind string) (*NDArray, error) {
+	if len(a.shape) == 0 {
+		return nil, fmt.Errorf("cannot sort a 0-d array")
+	}
+	axis, err := normalizeAxis(axis, len(a.shape))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSortKind(kind); err != nil {
+		return nil, err
+	}
+	return alongAxis(a, axis, a.shape[axis], func(in, out []float64) {
+		idx := make([]int, len(in))
+		argSortLane(in, idx, kind)
+		for i, k := range idx {
+			out[i] = in[k]
+		}
+	})
+}
+
+// ArgSort returns the indices that would sort the array along the given axis, like numpy.argsort.
+//
+// The indices are returned as float64 values in an array of the same shape as a. With "mergesort" or "stable" the
+// relative order of equal elements is preserved.
+//
+// Errors:
+//
+//	Returns an error if the axis is out of bounds or the kind is unknown.
+func ArgSort(a *NDArray, axis int, kind string) (*NDArray, error) {
+	if len(a.shape) == 0 {
+		return nil, fmt.Errorf("cannot sort a 0-d array")
+	}
+	axis, err := normalizeAxis(axis, len(a.shape))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSortKind(kind); err != nil {
+		return nil, err
+	}
+	return alongAxis(a, axis, a.shape[axis], func(in, out []float64) {
+		idx := make([]int, len(in))
+		argSortLane(in, idx, kind)
+		for i, k := range idx {
+			out[i] = float64(k)
+		}
+	})
+}
+
+// Partition returns a partitioned copy of the array, like numpy.partition.
+//
+// Along the given axis, the element at each kth position is the one that would be there in a sorted array; all
+// smaller elements are moved before it and all equal or greater elements behind it. The order within the two
+// partitions is undefined. This is the efficient way to find the k smallest (or, with negative kth, largest) values.
+//
+// Parameters:
+//
+//	a (*NDArray): The array to partition.
+//	kth (interface{}): An int or []int of positions to partition by. Negative positions count from the end.
+//	axis (int): The axis along which to partition; -1 uses the last axis.
+//
+// Returns:
+//
+//	(*NDArray, error): The partitioned array of the same shape as a.
+//
+// Errors:
+//
+//	Returns an error if kth or axis are out of bounds.
+func Partition(a *NDArray, kth interface{}, axis int) (*NDArray, error) {
+	return partition(a, kth, axis, false)
+}
+
+// ArgPartition returns the indices that would partition the array, like numpy.argpartition.
+//
+// Selecting the first k entries of ArgPartition(a, k, -1) gives the indices of the k smallest values, which is how
+// top-k accuracy is usually evaluated.
+//
+// Errors:
+//
+//	Returns an error if kth or axis are out of bounds.
+func ArgPartition(a *NDArray, kth interface{}, axis int) (*NDArray, error) {
+	return partition(a, kth, axis, true)
+}
+
+// partition implements Partition and ArgPartition.
+func partition(a *NDArray, kth interface{}, axis int, returnIndices bool) (*NDArray, error) {
+	if len(a.shape) == 0 {
+		return nil, fmt.Errorf("cannot partition a 0-d array")
+	}
+	axis, err := normalizeAxis(axis, len(a.shape))
+	if err != nil {
+		return nil, err
+	}
+	var ks []int
+	switch k := kth.(type) {
+	case int:
+		ks = []int{k}
+	case []int:
+		ks = append([]int{}, k...)
+	default:
+		return nil, fmt.Errorf("kth must be an int or []int, got %T", kth)
+	}
+	n := a.shape[axis]
+	for i, k := range ks {
+		if k < 0 {
+			k += n
+		}
+		if k < 0 || k >= n {
+			return nil, fmt.Errorf("kth(=%v) out of bounds (%v)", ks[i], n)
+		}
+		ks[i] = k
+	}
+	sort.Ints(ks)
+
+	return alongAxis(a, axis, n, func(in, out []float64) {
+		idx := make([]int, len(in))
+		for i := range idx {
+			idx[i] = i
+		}
+		// Select each kth element in turn, only searching the part after the previous one
+		lo := 0
+		for _, k := range ks {
+			quickSelect(in, idx, lo, n-1, k)
+			lo = k + 1
+		}
+		for i, j := range idx {
+			if returnIndices {
+				out[i] = float64(j)
+			} else {
+				out[i] = in[j]
+			}
+		}
+	})
+}
+
+// quickSelect rearranges idx[lo:hi+1] so that idx[k] refers to the k-th smallest value and the positions before and
+// after it refer to smaller and not smaller values respectively.
+func quickSelect(values []float64, idx []int, lo, hi, k int) {
+	for lo < hi {
+		// Median of three pivot selection to avoid quadratic behaviour on sorted input
+		mid := lo + (hi-lo)/2
+		if lessNaN(values[idx[mid]], values[idx[lo]]) {
+			idx[mid], idx[lo] = idx[lo], idx[mid]
+		}
+		if lessNaN(values[idx[hi]], values[idx[lo]]) {
+			idx[hi], idx[lo] = idx[lo], idx[hi]
+		}
+		if lessNaN(values[idx[hi]], values[idx[mid]]) {
+			idx[hi], idx[mid] = idx[mid], idx[hi]
+		}
+		pivot := values[idx[mid]]
+		idx[mid], idx[hi] = idx[hi], idx[mid]
+
+		// Lomuto partition around the pivot
+		store := lo
+		for i := lo; i < hi; i++ {
+			if lessNaN(values[idx[i]], pivot) {
+				idx[i], idx[store] = idx[store], idx[i]
+				store++
+			}
+		}
+		idx[store], idx[hi] = idx[hi], idx[store]
+
+		switch {
+		case k == store:
+			return
+		case k < store:
+			hi = store - 1
+		default:
+			lo = store + 1
+		}
+	}
+}
+
+// SearchSorted finds the indices where elements of v should be inserted into the sorted 1-D array a to maintain
+// order, like numpy.searchsorted.
+//
+// Parameters:
+//
+//	a (*NDArray): A 1-D array sorted in ascending order.
+//	v (*NDArray): The values to insert.
+//	side (string): "left" (the default, also used when side is empty) gives the first suitable index and "right"
+//	               gives the last.
+//
+// Returns:
+//
+//	(*NDArray, error): An array of the same shape as v holding the insertion indices.
+//
+// Errors:
+//
+//	Returns an error if a is not 1-D or side is unknown.
+func SearchSorted(a, v *NDArray, side string) (*NDArray, error) {
+	if len(a.shape) != 1 {
+		return nil, fmt.Errorf("a must be 1-D")
+	}
+	if side != "" && side != "left" && side != "right" {
+		return nil, fmt.Errorf("side must be 'left' or 'right', got %q", side)
+	}
+	sorted := a.Data()
+	values := v.Data()
+	out := make([]float64, len(values))
+	for i, x := range values {
+		if side == "right" {
+			out[i] = float64(sort.Search(len(sorted), func(j int) bool { return lessNaN(x, sorted[j]) }))
+		} else {
+			out[i] = float64(sort.Search(len(sorted), func(j int) bool { return !lessNaN(sorted[j], x) }))
+		}
+	}
+	return newArray(out, v.Shape()), nil
+}
+
+// UniqueResult holds the outputs of Unique. Fields that were not requested are nil.
+//
+// Fields:
+//
+//	Values (*NDArray): The sorted unique values.
+//	Indices (*NDArray): The index of the first occurrence of each unique value in the flattened input.
+//	Inverse (*NDArray): For every input element, the index of its value in Values, so Values[Inverse] rebuilds the
+//	                    flattened input. This is the label encoding of the input.
+//	Counts (*NDArray): The number of times each unique value occurs.
+type UniqueResult struct {
+	Values  *NDArray
+	Indices *NDArray
+	Inverse *NDArray
+	Counts  *NDArray
+}
+
+// Unique finds the sorted unique elements of the flattened array, like numpy.unique. All NaN values are treated as
+// equal and reported once at the end.
+//
+// Parameters:
+//
+//	a (*NDArray): The input array.
+//	returnIndex (bool): If true, also return the index of the first occurrence of each unique value.
+//	returnInverse (bool): If true, also return the indices that reconstruct the input from the unique values.
+//	returnCounts (bool): If true, also return the number of occurrences of each unique value.
+//
+// Returns:
+//
+//	(*UniqueResult, error): The unique values and the requested extra outputs.
+func Unique(a *NDArray, returnIndex, returnInverse, returnCounts bool) (*UniqueResult, error) {
+	values := a.Data()
+	idx := make([]int, len(values))
+	if err := argSortLane(values, idx, "stable"); err != nil {
+		return nil, err
+	}
+
+	// Walk the sorted positions, starting a new group whenever the value changes
+	unique := []float64{}
+	first := []float64{}
+	counts := []float64{}
+	inverse := make([]float64, len(values))
+	for i, k := range idx {
+		v := values[k]
+		if i == 0 || !(v == unique[len(unique)-1] || (math.IsNaN(v) && math.IsNaN(unique[len(unique)-1]))) {
+			unique = append(unique, v)
+			first = append(first, float64(k))
+			counts = append(counts, 0)
+		}
+		counts[len(counts)-1]++
+		inverse[k] = float64(len(unique) - 1)
+	}
+
+	r := &UniqueResult{Values: newArray(unique, []int{len(unique)})}
+	if returnIndex {
+		r.Indices = newArray(first, []int{len(first)})
+	}
+	if returnInverse {
+		r.Inverse = newArray(inverse, []int{len(inverse)})
+	}
+	if returnCounts {
+		r.Counts = newArray(counts, []int{len(counts)})
+	}
+	return r, nil
+}
+
+// Nonzero returns the indices of the elements that are non-zero, like numpy.nonzero.
+//
+// One array is returned per dimension, holding the indices of the non-zero elements along that dimension in
+// row-major order, so the i-th non-zero element is at (out[0][i], out[1][i], ...).
+func Nonzero(a *NDArray) []*NDArray {
+	found := argWhere(a)
+	ndim := len(a.shape)
+	count := 0
+	if ndim > 0 {
+		count = len(found) / ndim
+	}
+	out := make([]*NDArray, ndim)
+	for d := range out {
+		values := make([]float64, count)
+		for i := range values {
+			values[i] = float64(found[i*ndim+d])
+		}
+		out[d] = newArray(values, []int{count})
+	}
+	return out
+}
+
+// ArgWhere returns the indices of the non-zero elements grouped by element, like numpy.argwhere.
+//
+// The result has shape (N, ndim) where N is the number of non-zero elements; each row is the index of one element.
+func ArgWhere(a *NDArray) *NDArray {
+	found := argWhere(a)
+	ndim := len(a.shape)
+	values := make([]float64, len(found))
+	for i, v := range found {
+		values[i] = float64(v)
+	}
+	if ndim == 0 {
+		// A 0-d array has one empty index, present if its single element is non-zero
+		rows := 0
+		if a.Data()[0] != 0 {
+			rows = 1
+		}
+		return newArray(values, []int{rows, 0})
+	}
+	return newArray(values, []int{len(found) / ndim, ndim})
+}
+
+// argWhere returns the multi-dimensional indices of the non-zero elements, concatenated in row-major order.
+func argWhere(a *NDArray) []int {
+	found := []int{}
+	index := make([]int, len(a.shape))
+	for i, v := range a.Data() {
+		if v != 0 {
+			// Convert the flat position into a multi-dimensional index
+			rest := i
+			for d := len(a.shape) - 1; d >= 0; d-- {
+				index[d] = rest % a.shape[d]
+				rest /= a.shape[d]
+			}
+			found = append(found, index...)
+		}
+	}
+	return found
+}
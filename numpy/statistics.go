@@ -0,0 +1,384 @@
+package numpy
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Var returns the variance of the array elements over the given axes, like numpy.var.
+//
+// The variance is the average of the squared deviations from the mean, sum((x - mean)^2) / (N - ddof), where N is
+// the number of elements in each lane. Use ddof=0 for the population variance and ddof=1 for the sample variance.
+//
+// Parameters:
+//
+//	a (*NDArray): The input array.
+//	ddof (int): Delta degrees of freedom subtracted from N in the divisor.
+//	axis (...int): The axes to reduce. With no axes the variance of every element is returned as a 0-d array.
+//
+// Returns:
+//
+//	(*NDArray, error): The variances with the reduced axes removed.
+//
+// Errors:
+//
+//	Returns an error if an axis is out of bounds or repeated.
+func Var(a *NDArray, ddof int, axis ...int) (*NDArray, error) {
+	return reduce(a, axis, func(lane []float64) float64 {
+		return variance(lane, ddof)
+	})
+}
+
+// Std returns the standard deviation of the array elements over the given axes, like numpy.std.
+//
+// The standard deviation is the square root of Var with the same ddof.
+//
+// Errors:
+//
+//	Returns an error if an axis is out of bounds or repeated.
+func Std(a *NDArray, ddof int, axis ...int) (*NDArray, error) {
+	return reduce(a, axis, func(lane []float64) float64 {
+		return math.Sqrt(variance(lane, ddof))
+	})
+}
+
+// variance returns sum((x - mean)^2) / (len(values) - ddof).
+func variance(values []float64, ddof int) float64 {
+	m := mean(values)
+	s := 0.
+	for _, v := range values {
+		s += (v - m) * (v - m)
+	}
+	return s / float64(len(values)-ddof)
+}
+
+// Median returns the median of the array elements over the given axes, like numpy.median.
+//
+// Errors:
+//
+//	Returns an error if an axis is out of bounds or repeated.
+func Median(a *NDArray, axis ...int) (*NDArray, error) {
+	return Quantile(a, 0.5, "linear", axis...)
+}
+
+// Percentile returns the q-th percentiles of the array elements over the given axes, like numpy.percentile.
+//
+// This is Quantile with q expressed in percent (0 to 100) instead of as a fraction.
+//
+// Errors:
+//
+//	Returns an error if q is outside [0, 100], the method is unknown or an axis is invalid.
+func Percentile(a *NDArray, q interface{}, method string, axis ...int) (*NDArray, error) {
+	qs, scalar, err := quantileList(q)
+	if err != nil {
+		return nil, err
+	}
+	for i := range qs {
+		if qs[i] < 0 || qs[i] > 100 {
+			return nil, fmt.Errorf("percentiles must be in the range [0, 100]")
+		}
+		qs[i] /= 100
+	}
+	return quantile(a, qs, scalar, method, axis)
+}
+
+// Quantile returns the q-th quantiles of the array elements over the given axes, like numpy.quantile.
+//
+// The supported methods are the ones offered by numpy: "inverted_cdf", "averaged_inverted_cdf",
+// "closest_observation", "interpolated_inverted_cdf", "hazen", "weibull", "linear" (the default, also used when
+// method is empty), "median_unbiased", "normal_unbiased", "lower", "higher", "midpoint" and "nearest".
+//
+// Parameters:
+//
+//	a (*NDArray): The input array.
+//	q (interface{}): A float64 or []float64 of quantiles in the range [0, 1].
+//	method (string): The estimation method, as listed above.
+//	axis (...int): The axes to reduce. With no axes the quantiles of every element are computed.
+//
+// Returns:
+//
+//	(*NDArray, error): For a scalar q, the quantiles with the reduced axes removed. For a slice of quantiles, the
+//	                   result has an extra first axis with one entry per quantile.
+//
+// Errors:
+//
+//	Returns an error if q is not a float64 or []float64, a quantile is outside [0, 1], the method is unknown or an
+//	axis is invalid.
+func Quantile(a *NDArray, q interface{}, method string, axis ...int) (*NDArray, error) {
+	qs, scalar, err := quantileList(q)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range qs {
+		if v < 0 || v > 1 {
+			return nil, fmt.Errorf("quantiles must be in the range [0, 1]")
+		}
+	}
+	return quantile(a, qs, scalar, method, axis)
+}
+
+// quantileList converts the q argument of Quantile and Percentile into a slice, reporting whether it was a scalar.
+func quantileList(q interface{}) ([]float64, bool, error) {
+	switch v := q.(type) {
+	case float64:
+		return []float64{v}, true, nil
+	case int:
+		return []float64{float64(v)}, true, nil
+	case []float64:
+		return append([]float64{}, v...), false, nil
+	case *NDArray:
+		return append([]float64{}, v.Data()...), false, nil
+	default:
+		return nil, false, fmt.Errorf("q must be a float64 or []float64, got %T", q)
+	}
+}
+
+// quantile computes the quantiles qs of every lane of a along axes.
+func quantile(a *NDArray, qs []float64, scalar bool, method string, axes []int) (*NDArray, error) {
+	if method == "" {
+		method = "linear"
+	}
+	if _, err := quantileOfSorted([]float64{0}, 0.5, method); err != nil {
+		return nil, err
+	}
+	data, inner, outShape, err := laneMatrix(a, axes)
+	if err != nil {
+		return nil, err
+	}
+	lanes, _ := shapeSize(outShape)
+	out := make([]float64, len(qs)*lanes)
+	sorted := make([]float64, inner)
+	for i := 0; i < lanes; i++ {
+		copy(sorted, data[i*inner:(i+1)*inner])
+		sort.Float64s(sorted)
+		for j, q := range qs {
+			out[j*lanes+i], _ = quantileOfSorted(sorted, q, method)
+		}
+	}
+	if scalar {
+		return newArray(out, outShape), nil
+	}
+	return newArray(out, append([]int{len(qs)}, outShape...)), nil
+}
+
+// quantileOfSorted returns the q-th quantile of the sorted values using one of numpy's estimation methods.
+//
+// The continuous methods follow Hyndman and Fan: the virtual index n*q + alpha + q*(1 - alpha - beta) - 1 is
+// computed and the neighbouring order statistics are interpolated linearly.
+func quantileOfSorted(sorted []float64, q float64, method string) (float64, error) {
+	n := len(sorted)
+	if n == 0 {
+		return math.NaN(), nil
+	}
+	if math.IsNaN(sorted[0]) {
+		// sort.Float64s places NaN values first, and any NaN makes the result NaN as in numpy
+		return math.NaN(), nil
+	}
+	fn := float64(n)
+
+	// take returns the element at a clipped integer index
+	take := func(i float64) float64 {
+		k := int(i)
+		if k < 0 {
+			k = 0
+		}
+		if k > n-1 {
+			k = n - 1
+		}
+		return sorted[k]
+	}
+	// interpolate returns the value at a virtual index, using fixGamma to adjust the interpolation weight
+	interpolate := func(virtual float64, fixGamma func(gamma, virtual float64) float64) float64 {
+		if virtual >= fn-1 {
+			return sorted[n-1]
+		}
+		if virtual < 0 {
+			return sorted[0]
+		}
+		prev := math.Floor(virtual)
+		gamma := fixGamma(virtual-prev, virtual)
+		lo, hi := sorted[int(prev)], sorted[int(prev)+1]
+		return lerp(lo, hi, gamma)
+	}
+	continuous := func(alpha, beta float64) float64 {
+		return interpolate(fn*q+alpha+q*(1-alpha-beta)-1, func(gamma, _ float64) float64 { return gamma })
+	}
+
+	switch method {
+	case "inverted_cdf":
+		virtual := fn*q - 1
+		prev := math.Floor(virtual)
+		if virtual-prev == 0 {
+			return take(prev), nil
+		}
+		return take(prev + 1), nil
+	case "averaged_inverted_cdf":
+		return interpolate(fn*q-1, func(gamma, _ float64) float64 {
+			if gamma == 0 {
+				return 0.5
+			}
+			return 1
+		}), nil
+	case "closest_observation":
+		virtual := fn*q - 1 - 0.5
+		prev := math.Floor(virtual)
+		// At an exact order statistic numpy keeps the lower one when its zero-based index is odd, which is the
+		// nearest even order statistic in the one-based numbering of Hyndman and Fan
+		if virtual-prev == 0 && math.Mod(math.Abs(prev), 2) == 1 {
+			return take(prev), nil
+		}
+		return take(prev + 1), nil
+	case "interpolated_inverted_cdf":
+		return continuous(0, 1), nil
+	case "hazen":
+		return continuous(0.5, 0.5), nil
+	case "weibull":
+		return continuous(0, 0), nil
+	case "linear":
+		return continuous(1, 1), nil
+	case "median_unbiased":
+		return continuous(1./3, 1./3), nil
+	case "normal_unbiased":
+		return continuous(3./8, 3./8), nil
+	case "lower":
+		return take(math.Floor((fn - 1) * q)), nil
+	case "higher":
+		return take(math.Ceil((fn - 1) * q)), nil
+	case "midpoint":
+		return interpolate((fn-1)*q, func(gamma, virtual float64) float64 {
+			if math.Trunc(virtual) == virtual {
+				return 0
+			}
+			return 0.5
+		}), nil
+	case "nearest":
+		return take(math.RoundToEven((fn - 1) * q)), nil
+	default:
+		return 0, fmt.Errorf("%q is not a valid method", method)
+	}
+}
+
+// lerp interpolates linearly between a and b, in the numerically stable form used by numpy.
+func lerp(a, b, t float64) float64 {
+	diff := b - a
+	if t >= 0.5 {
+		return b - diff*(1-t)
+	}
+	return a + diff*t
+}
+
+// Cov estimates the covariance matrix of a set of variables, like numpy.cov.
+//
+// Each row of m is a variable and each column an observation when rowVar is true; when rowVar is false the roles are
+// swapped. An optional second set of variables y is stacked onto m before the covariance is computed.
+//
+// Parameters:
+//
+//	m (*NDArray): A 1-D or 2-D array of variables and observations.
+//	y (*NDArray): An optional additional set of variables with the same layout as m, or nil.
+//	rowVar (bool): If true rows are variables, otherwise columns are variables.
+//	ddof (int): Delta degrees of freedom; 1 gives the unbiased estimate (numpy's default) and 0 the biased one.
+//
+// Returns:
+//
+//	(*NDArray, error): The covariance matrix of shape (variables, variables), or a 0-d array for a single variable.
+//
+// Errors:
+//
+//	Returns an error if m or y have more than two dimensions or a different number of observations.
+func Cov(m, y *NDArray, rowVar bool, ddof int) (*NDArray, error) {
+	x, err := observations(m, rowVar)
+	if err != nil {
+		return nil, err
+	}
+	if y != nil {
+		yObs, err := observations(y, rowVar)
+		if err != nil {
+			return nil, err
+		}
+		if yObs.shape[1] != x.shape[1] {
+			return nil, fmt.Errorf("m and y must have the same number of observations")
+		}
+		x, err = NewArray(append(append([]float64{}, x.Data()...), yObs.Data()...), x.shape[0]+yObs.shape[0], x.shape[1])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Centre every variable and compute the scaled inner products of the rows
+	vars, obs := x.shape[0], x.shape[1]
+	centred := x.Copy().data
+	for i := 0; i < vars; i++ {
+		row := centred[i*obs : (i+1)*obs]
+		m := mean(row)
+		for j := range row {
+			row[j] -= m
+		}
+	}
+	norm := float64(obs - ddof)
+	out := make([]float64, vars*vars)
+	for i := 0; i < vars; i++ {
+		for j := i; j < vars; j++ {
+			s := 0.
+			for k := 0; k < obs; k++ {
+				s += centred[i*obs+k] * centred[j*obs+k]
+			}
+			out[i*vars+j] = s / norm
+			out[j*vars+i] = s / norm
+		}
+	}
+	if vars == 1 {
+		return newArray(out, []int{}), nil
+	}
+	return newArray(out, []int{vars, vars}), nil
+}
+
+// observations returns m as a 2-D array with one row per variable.
+func observations(m *NDArray, rowVar bool) (*NDArray, error) {
+	switch len(m.shape) {
+	case 0:
+		return m.Reshape(1, 1)
+	case 1:
+		return m.Reshape(1, -1)
+	case 2:
+		if rowVar {
+			return m, nil
+		}
+		return m.T(), nil
+	default:
+		return nil, fmt.Errorf("m has more than 2 dimensions")
+	}
+}
+
+// CorrCoef returns the Pearson correlation coefficients of a set of variables, like numpy.corrcoef.
+//
+// The coefficients are the covariance matrix normalised by the standard deviations, R[i][j] = C[i][j] /
+// sqrt(C[i][i] * C[j][j]), with values clipped to [-1, 1] to guard against rounding errors.
+//
+// Errors:
+//
+//	Returns an error under the same conditions as Cov.
+func CorrCoef(x, y *NDArray, rowVar bool) (*NDArray, error) {
+	c, err := Cov(x, y, rowVar, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(c.shape) == 0 {
+		// A single variable is perfectly correlated with itself (or NaN if it is constant)
+		v := c.data[0]
+		return newArray([]float64{v / v}, []int{}), nil
+	}
+	n := c.shape[0]
+	d := make([]float64, n)
+	for i := range d {
+		d[i] = math.Sqrt(c.data[i*n+i])
+	}
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			r := c.data[i*n+j] / d[i] / d[j]
+			c.data[i*n+j] = math.Max(-1, math.Min(1, r))
+		}
+	}
+	return c, nil
+}
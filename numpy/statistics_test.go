@@ -0,0 +1,32 @@
+package numpy
+
+import "testing"
+
+func TestQuantileClosestObservation(t *testing.T) {
+	cases := []struct {
+		data []float64
+		q    float64
+		want float64
+	}{
+		{[]float64{0.1, 2.5, -3}, 0.5, 0.1},
+		{[]float64{1, 2, 3, 4}, 0, 1},
+		{[]float64{1, 2, 3, 4}, 0.375, 2},
+		{[]float64{1, 2, 3, 4}, 0.5, 2},
+		{[]float64{1, 2, 3, 4}, 0.625, 2},
+		{[]float64{1, 2, 3, 4}, 0.875, 4},
+		{[]float64{1, 2, 3, 4}, 1, 4},
+	}
+	for _, c := range cases {
+		a, err := NewArray(c.data, len(c.data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := Quantile(a, c.q, "closest_observation")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v, _ := got.Item(); v != c.want {
+			t.Errorf("Quantile(%v, %v, closest_observation) = %v, want %v", c.data, c.q, v, c.want)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package numpy
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// Scalar returns a 0-d array holding v. It broadcasts against arrays of any shape in the element-wise operations.
+func Scalar(v float64) *NDArray {
+	return newArray([]float64{v}, []int{})
+}
+
+// binary applies op element-wise to two arrays after broadcasting them to a common shape.
+func binary(a, b *NDArray, op func(x, y float64) float64) (*NDArray, error) {
+	// Fast path for contiguous arrays of the same shape
+	if a.flags.CContiguous && b.flags.CContiguous && slices.Equal(a.shape, b.shape) {
+		x, y := a.Data(), b.Data()
+		out := make([]float64, len(x))
+		for i := range out {
+			out[i] = op(x[i], y[i])
+		}
+		return newArray(out, a.shape), nil
+	}
+	views, err := BroadcastArrays(a, b)
+	if err != nil {
+		return nil, err
+	}
+	xPos, yPos := views[0].offsets(), views[1].offsets()
+	out := make([]float64, len(xPos))
+	for i := range out {
+		out[i] = op(a.data[xPos[i]], b.data[yPos[i]])
+	}
+	return newArray(out, views[0].shape), nil
+}
+
+// Add returns the element-wise sum a + b, broadcasting the arrays against each other like numpy's + operator.
+//
+// Errors:
+//
+//	Returns an error if the shapes cannot be broadcast together.
+func (a *NDArray) Add(b *NDArray) (*NDArray, error) {
+	return binary(a, b, func(x, y float64) float64 { return x + y })
+}
+
+// Sub returns the element-wise difference a - b with broadcasting.
+//
+// Errors:
+//
+//	Returns an error if the shapes cannot be broadcast together.
+func (a *NDArray) Sub(b *NDArray) (*NDArray, error) {
+	return binary(a, b, func(x, y float64) float64 { return x - y })
+}
+
+// Mul returns the element-wise product a * b with broadcasting.
+//
+// Errors:
+//
+//	Returns an error if the shapes cannot be broadcast together.
+func (a *NDArray) Mul(b *NDArray) (*NDArray, error) {
+	return binary(a, b, func(x, y float64) float64 { return x * y })
+}
+
+// Div returns the element-wise quotient a / b with broadcasting.
+//
+// Errors:
+//
+//	Returns an error if the shapes cannot be broadcast together.
+func (a *NDArray) Div(b *NDArray) (*NDArray, error) {
+	return binary(a, b, func(x, y float64) float64 { return x / y })
+}
+
+// Maximum returns the element-wise maximum of two arrays with broadcasting, like numpy.maximum. NaN values propagate.
+//
+// Errors:
+//
+//	Returns an error if the shapes cannot be broadcast together.
+func Maximum(a, b *NDArray) (*NDArray, error) {
+	return binary(a, b, func(x, y float64) float64 {
+		if math.IsNaN(x) || math.IsNaN(y) {
+			return math.NaN()
+		}
+		return math.Max(x, y)
+	})
+}
+
+// Apply returns a new C-contiguous array holding fn applied to every element of a.
+func (a *NDArray) Apply(fn func(float64) float64) *NDArray {
+	x := a.Data()
+	out := make([]float64, len(x))
+	for i, v := range x {
+		out[i] = fn(v)
+	}
+	return newArray(out, a.shape)
+}
+
+// Scale returns a new array holding every element of a multiplied by s.
+func (a *NDArray) Scale(s float64) *NDArray {
+	return a.Apply(func(v float64) float64 { return v * s })
+}
+
+// Exp returns the exponential of every element, like numpy.exp.
+func Exp(a *NDArray) *NDArray {
+	return a.Apply(math.Exp)
+}
+
+// Log returns the natural logarithm of every element, like numpy.log.
+func Log(a *NDArray) *NDArray {
+	return a.Apply(math.Log)
+}
+
+// Sqrt returns the non-negative square root of every element, like numpy.sqrt.
+func Sqrt(a *NDArray) *NDArray {
+	return a.Apply(math.Sqrt)
+}
+
+// Power returns every element raised to the power p, like numpy.power with a scalar exponent.
+func Power(a *NDArray, p float64) *NDArray {
+	return a.Apply(func(v float64) float64 { return math.Pow(v, p) })
+}
+
+// Matmul returns the matrix product of two arrays, like numpy.matmul (the @ operator).
+//
+// 2-D arrays are multiplied as conventional matrices. Arrays with more dimensions are treated as stacks of matrices
+// in the last two axes, and the leading axes are broadcast against each other, so a batch of matrices can be
+// multiplied by a single matrix. A 1-D first argument is treated as a row vector and a 1-D second argument as a
+// column vector; the added dimension is removed from the result.
+//
+// Parameters:
+//
+//	a, b (*NDArray): The arrays to multiply. Neither can be 0-d.
+//
+// Returns:
+//
+//	(*NDArray, error): The matrix product.
+//
+// Errors:
+//
+//	Returns an error if either input is 0-d, the inner dimensions do not match or the batch dimensions cannot be
+//	broadcast together.
+func Matmul(a, b *NDArray) (*NDArray, error) {
+	if len(a.shape) == 0 || len(b.shape) == 0 {
+		return nil, fmt.Errorf("matmul: input operand does not have enough dimensions")
+	}
+
+	// Promote vectors to matrices, remembering which axes to drop from the result
+	aVec, bVec := len(a.shape) == 1, len(b.shape) == 1
+	if aVec {
+		a, _ = a.Reshape(1, a.shape[0])
+	}
+	if bVec {
+		b, _ = b.Reshape(b.shape[0], 1)
+	}
+	n, k := a.shape[len(a.shape)-2], a.shape[len(a.shape)-1]
+	k2, m := b.shape[len(b.shape)-2], b.shape[len(b.shape)-1]
+	if k != k2 {
+		return nil, fmt.Errorf("matmul: input operand 1 has a mismatch in its core dimension 0 (size %v is different from %v)", k2, k)
+	}
+
+	// Broadcast the batch dimensions
+	batch, err := BroadcastShapes(a.shape[:len(a.shape)-2], b.shape[:len(b.shape)-2])
+	if err != nil {
+		return nil, err
+	}
+	aB, err := a.BroadcastTo(append(append([]int{}, batch...), n, k)...)
+	if err != nil {
+		return nil, err
+	}
+	bB, err := b.BroadcastTo(append(append([]int{}, batch...), k, m)...)
+	if err != nil {
+		return nil, err
+	}
+	batches, _ := shapeSize(batch)
+	x, err := aB.Reshape(batches, n, k)
+	if err != nil {
+		return nil, err
+	}
+	y, err := bB.Reshape(batches, k, m)
+	if err != nil {
+		return nil, err
+	}
+	xd, yd := x.Data(), y.Data()
+
+	// Multiply each pair of matrices with an i-k-j loop so the innermost loop runs over contiguous memory
+	out := make([]float64, batches*n*m)
+	for p := 0; p < batches; p++ {
+		xm, ym, om := xd[p*n*k:(p+1)*n*k], yd[p*k*m:(p+1)*k*m], out[p*n*m:(p+1)*n*m]
+		for i := 0; i < n; i++ {
+			row := om[i*m : (i+1)*m]
+			for l := 0; l < k; l++ {
+				v := xm[i*k+l]
+				col := ym[l*m : (l+1)*m]
+				for j := range row {
+					row[j] += v * col[j]
+				}
+			}
+		}
+	}
+
+	shape := append([]int{}, batch...)
+	if !aVec {
+		shape = append(shape, n)
+	}
+	if !bVec {
+		shape = append(shape, m)
+	}
+	return newArray(out, shape), nil
+}
@@ -0,0 +1,462 @@
+package numpy
+
+import (
+	"fmt"
+	"math"
+)
+
+// None marks an omitted bound in a Slice, like leaving out start or stop in a Python slice expression.
+const None = math.MinInt
+
+// Slice selects a start:stop:step range along one axis when passed to View.
+//
+// Start and Stop follow Python semantics: negative values count from the end of the axis and out of range values are
+// clipped. Set a bound to None to omit it, for example Slice{Start: None, Stop: None, Step: -1} is the "::-1" slice.
+// A Step of zero is treated as one. The S helper builds slices without spelling out every field.
+type Slice struct {
+	Start int
+	Stop  int
+	Step  int
+}
+
+// S builds a Slice from up to three bounds, mirroring Python slice syntax.
+//
+// Example usage:
+//
+//	numpy.S()              // ":"
+//	numpy.S(2)             // "2:"
+//	numpy.S(1, 4)          // "1:4"
+//	numpy.S(None, None, -1) // "::-1"
+func S(bounds ...int) Slice {
+	s := Slice{Start: None, Stop: None, Step: 1}
+	if len(bounds) > 0 {
+		s.Start = bounds[0]
+	}
+	if len(bounds) > 1 {
+		s.Stop = bounds[1]
+	}
+	if len(bounds) > 2 {
+		s.Step = bounds[2]
+	}
+	return s
+}
+
+// indices resolves the slice against an axis of length n, returning the first index, the step and the number of
+// selected elements. The rules follow CPython's PySlice_AdjustIndices.
+func (s Slice) indices(n int) (int, int, int) {
+	step := s.Step
+	if step == 0 {
+		step = 1
+	}
+	lower, upper := 0, n
+	if step < 0 {
+		lower, upper = -1, n-1
+	}
+	clip := func(v, def int) int {
+		if v == None {
+			return def
+		}
+		if v < 0 {
+			v += n
+			if v < lower {
+				v = lower
+			}
+		} else if v > upper {
+			v = upper
+		}
+		return v
+	}
+	var start, stop int
+	if step > 0 {
+		start, stop = clip(s.Start, 0), clip(s.Stop, n)
+	} else {
+		start, stop = clip(s.Start, n-1), clip(s.Stop, -1)
+	}
+	length := 0
+	if step > 0 && start < stop {
+		length = (stop-start-1)/step + 1
+	} else if step < 0 && stop < start {
+		length = (start-stop-1)/(-step) + 1
+	}
+	return start, step, length
+}
+
+type newAxis struct{}
+
+type ellipsis struct{}
+
+// NewAxis inserts a new axis of length one when passed to View, like numpy.newaxis.
+var NewAxis = newAxis{}
+
+// Ellipsis expands to as many full slices as needed when passed to View, like "..." in numpy indexing.
+var Ellipsis = ellipsis{}
+
+// View returns a view of the array selected by basic numpy indexing. No data is copied.
+//
+// Each index can be an int, which selects a single position and removes the axis; a Slice, which keeps the axis and
+// selects a (possibly reversed or strided) range; NewAxis, which inserts an axis of length one; or Ellipsis, which
+// stands for as many full slices as needed. Axes without an index are kept in full.
+//
+// Example usage:
+//
+//	a := numpy.ZerosArray(4, 5)
+//	row, _ := a.View(1)                                // shape [5]
+//	sub, _ := a.View(numpy.S(1, 3), numpy.S(None, None, 2)) // shape [2 3]
+//	rev, _ := a.View(numpy.Ellipsis, numpy.S(None, None, -1)) // columns reversed
+//
+// Parameters:
+//
+//	index (...interface{}): The index expressions, one per axis, as described above.
+//
+// Returns:
+//
+//	(*NDArray, error): The view, or nil and an error if the index is invalid.
+//
+// Errors:
+//
+//	Returns an error if there are too many indices, an int index is out of bounds, more than one Ellipsis is given or
+//	an index has an unsupported type.
+func (a *NDArray) View(index ...interface{}) (*NDArray, error) {
+
+	// Count the indices that consume an axis so an Ellipsis can be expanded
+	consumed := 0
+	ellipses := 0
+	for _, idx := range index {
+		switch idx.(type) {
+		case int, Slice:
+			consumed++
+		case ellipsis:
+			ellipses++
+		case newAxis:
+		default:
+			return nil, fmt.Errorf("unsupported index type: %T", idx)
+		}
+	}
+	if ellipses > 1 {
+		return nil, fmt.Errorf("an index can only have a single ellipsis")
+	}
+	if consumed > len(a.shape) {
+		return nil, fmt.Errorf("too many indices for array: array is %v-dimensional, but %v were indexed", len(a.shape), consumed)
+	}
+
+	// Replace the ellipsis (or pad the end) with full slices
+	expanded := make([]interface{}, 0, len(index)+len(a.shape))
+	for _, idx := range index {
+		if _, ok := idx.(ellipsis); ok {
+			for i := 0; i < len(a.shape)-consumed; i++ {
+				expanded = append(expanded, S())
+			}
+			continue
+		}
+		expanded = append(expanded, idx)
+	}
+	if ellipses == 0 {
+		for i := consumed; i < len(a.shape); i++ {
+			expanded = append(expanded, S())
+		}
+	}
+
+	// Build the layout of the view axis by axis
+	shape := []int{}
+	strides := []int{}
+	offset := a.offset
+	axis := 0
+	for _, idx := range expanded {
+		switch v := idx.(type) {
+		case int:
+			n := a.shape[axis]
+			i := v
+			if i < 0 {
+				i += n
+			}
+			if i < 0 || i >= n {
+				return nil, fmt.Errorf("index %v is out of bounds for axis %v with size %v", v, axis, n)
+			}
+			offset += i * a.strides[axis]
+			axis++
+		case Slice:
+			start, step, length := v.indices(a.shape[axis])
+			if length > 0 {
+				offset += start * a.strides[axis]
+			}
+			shape = append(shape, length)
+			strides = append(strides, step*a.strides[axis])
+			axis++
+		case newAxis:
+			shape = append(shape, 1)
+			strides = append(strides, 0)
+		}
+	}
+	return a.newView(shape, strides, offset), nil
+}
+
+// normalizeAxis converts a possibly negative axis into the range [0, ndim).
+func normalizeAxis(axis, ndim int) (int, error) {
+	if axis < -ndim || axis >= ndim {
+		return 0, fmt.Errorf("axis %v is out of bounds for array of dimension %v", axis, ndim)
+	}
+	if axis < 0 {
+		axis += ndim
+	}
+	return axis, nil
+}
+
+// Transpose returns a view of the array with its axes permuted. No data is copied.
+//
+// With no arguments the order of the axes is reversed, like numpy.transpose. Otherwise axes must be a permutation of
+// the axis numbers; negative values count from the last axis.
+//
+// Errors:
+//
+//	Returns an error if axes is not a permutation of the dimensions of the array.
+func (a *NDArray) Transpose(axes ...int) (*NDArray, error) {
+	n := len(a.shape)
+	if len(axes) == 0 {
+		axes = make([]int, n)
+		for i := range axes {
+			axes[i] = n - 1 - i
+		}
+	}
+	if len(axes) != n {
+		return nil, fmt.Errorf("axes don't match array: expected %v axes, got %v", n, len(axes))
+	}
+	seen := make([]bool, n)
+	shape := make([]int, n)
+	strides := make([]int, n)
+	for i, ax := range axes {
+		ax, err := normalizeAxis(ax, n)
+		if err != nil {
+			return nil, err
+		}
+		if seen[ax] {
+			return nil, fmt.Errorf("repeated axis in transpose")
+		}
+		seen[ax] = true
+		shape[i] = a.shape[ax]
+		strides[i] = a.strides[ax]
+	}
+	return a.newView(shape, strides, a.offset), nil
+}
+
+// T returns the transposed view of the array, like the ndarray.T attribute.
+func (a *NDArray) T() *NDArray {
+	t, _ := a.Transpose()
+	return t
+}
+
+// SwapAxes returns a view of the array with two axes interchanged.
+func (a *NDArray) SwapAxes(axis1, axis2 int) (*NDArray, error) {
+	n := len(a.shape)
+	axis1, err := normalizeAxis(axis1, n)
+	if err != nil {
+		return nil, err
+	}
+	axis2, err = normalizeAxis(axis2, n)
+	if err != nil {
+		return nil, err
+	}
+	axes := make([]int, n)
+	for i := range axes {
+		axes[i] = i
+	}
+	axes[axis1], axes[axis2] = axes[axis2], axes[axis1]
+	return a.Transpose(axes...)
+}
+
+// Flip returns a view of the array with the order of the elements reversed along the given axis.
+func (a *NDArray) Flip(axis int) (*NDArray, error) {
+	axis, err := normalizeAxis(axis, len(a.shape))
+	if err != nil {
+		return nil, err
+	}
+	index := make([]interface{}, len(a.shape))
+	for i := range index {
+		index[i] = S()
+	}
+	index[axis] = S(None, None, -1)
+	return a.View(index...)
+}
+
+// ExpandDims returns a view of the array with a new axis of length one inserted at the given position.
+func (a *NDArray) ExpandDims(axis int) (*NDArray, error) {
+	axis, err := normalizeAxis(axis, len(a.shape)+1)
+	if err != nil {
+		return nil, err
+	}
+	shape := append(append(append([]int{}, a.shape[:axis]...), 1), a.shape[axis:]...)
+	strides := append(append(append([]int{}, a.strides[:axis]...), 0), a.strides[axis:]...)
+	return a.newView(shape, strides, a.offset), nil
+}
+
+// Squeeze returns a view of the array with axes of length one removed.
+//
+// With no arguments every axis of length one is removed. Otherwise only the given axes are removed.
+//
+// Errors:
+//
+//	Returns an error if one of the given axes does not have length one.
+func (a *NDArray) Squeeze(axes ...int) (*NDArray, error) {
+	remove := make([]bool, len(a.shape))
+	if len(axes) == 0 {
+		for i, n := range a.shape {
+			remove[i] = n == 1
+		}
+	}
+	for _, ax := range axes {
+		ax, err := normalizeAxis(ax, len(a.shape))
+		if err != nil {
+			return nil, err
+		}
+		if a.shape[ax] != 1 {
+			return nil, fmt.Errorf("cannot select an axis to squeeze out which has size not equal to one")
+		}
+		remove[ax] = true
+	}
+	shape := []int{}
+	strides := []int{}
+	for i := range a.shape {
+		if !remove[i] {
+			shape = append(shape, a.shape[i])
+			strides = append(strides, a.strides[i])
+		}
+	}
+	return a.newView(shape, strides, a.offset), nil
+}
+
+// Reshape gives the array a new shape without changing its data.
+//
+// One dimension may be -1, in which case it is inferred from the size of the array. When the array is C-contiguous
+// the result is a view; otherwise the elements are copied first, as numpy does when a view is impossible.
+//
+// Errors:
+//
+//	Returns an error if more than one dimension is -1 or the new shape does not have the same number of elements.
+func (a *NDArray) Reshape(shape ...int) (*NDArray, error) {
+	shape = append([]int{}, shape...)
+	size := a.Size()
+	unknown := -1
+	known := 1
+	for i, n := range shape {
+		if n == -1 {
+			if unknown >= 0 {
+				return nil, fmt.Errorf("can only specify one unknown dimension")
+			}
+			unknown = i
+			continue
+		}
+		if n < 0 {
+			return nil, fmt.Errorf("negative dimensions are not allowed: %v", shape)
+		}
+		known *= n
+	}
+	if unknown >= 0 {
+		if known == 0 || size%known != 0 {
+			return nil, fmt.Errorf("cannot reshape array of size %v into shape %v", size, shape)
+		}
+		shape[unknown] = size / known
+	} else if known != size {
+		return nil, fmt.Errorf("cannot reshape array of size %v into shape %v", size, shape)
+	}
+	src := a.AsContiguous()
+	if src == a {
+		return a.newView(shape, cStrides(shape), a.offset), nil
+	}
+	src.shape = shape
+	src.strides = cStrides(shape)
+	src.updateFlags()
+	return src, nil
+}
+
+// Ravel returns the array flattened to one dimension, as a view when the array is C-contiguous and a copy otherwise.
+func (a *NDArray) Ravel() *NDArray {
+	r, _ := a.Reshape(-1)
+	return r
+}
+
+// BroadcastShapes computes the shape that results from broadcasting the given shapes together using numpy's rules.
+//
+// Shapes are aligned on their last dimension; two dimensions are compatible when they are equal or one of them is 1.
+//
+// Errors:
+//
+//	Returns an error if the shapes cannot be broadcast together.
+func BroadcastShapes(shapes ...[]int) ([]int, error) {
+	ndim := 0
+	for _, s := range shapes {
+		if len(s) > ndim {
+			ndim = len(s)
+		}
+	}
+	out := make([]int, ndim)
+	for i := range out {
+		out[i] = 1
+	}
+	for _, s := range shapes {
+		for i, n := range s {
+			j := ndim - len(s) + i
+			switch {
+			case out[j] == n || n == 1:
+			case out[j] == 1:
+				out[j] = n
+			default:
+				return nil, fmt.Errorf("operands could not be broadcast together with shapes %v", shapes)
+			}
+		}
+	}
+	return out, nil
+}
+
+// BroadcastTo returns a read-only view of the array broadcast to a new shape, like numpy.broadcast_to.
+//
+// Broadcast dimensions get a stride of zero so that every position along them refers to the same element.
+//
+// Errors:
+//
+//	Returns an error if the array cannot be broadcast to the shape.
+func (a *NDArray) BroadcastTo(shape ...int) (*NDArray, error) {
+	if len(shape) < len(a.shape) {
+		return nil, fmt.Errorf("cannot broadcast array of shape %v to shape %v", a.shape, shape)
+	}
+	lead := len(shape) - len(a.shape)
+	strides := make([]int, len(shape))
+	for i := range shape {
+		if shape[i] < 0 {
+			return nil, fmt.Errorf("negative dimensions are not allowed: %v", shape)
+		}
+		if i < lead {
+			continue
+		}
+		n := a.shape[i-lead]
+		switch {
+		case n == shape[i]:
+			strides[i] = a.strides[i-lead]
+		case n == 1:
+			strides[i] = 0
+		default:
+			return nil, fmt.Errorf("cannot broadcast array of shape %v to shape %v", a.shape, shape)
+		}
+	}
+	v := a.newView(append([]int{}, shape...), strides, a.offset)
+	v.flags.Writeable = false
+	return v, nil
+}
+
+// BroadcastArrays broadcasts any number of arrays against each other and returns read-only views of the common shape.
+func BroadcastArrays(arrays ...*NDArray) ([]*NDArray, error) {
+	shapes := make([][]int, len(arrays))
+	for i, a := range arrays {
+		shapes[i] = a.shape
+	}
+	shape, err := BroadcastShapes(shapes...)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*NDArray, len(arrays))
+	for i, a := range arrays {
+		out[i], err = a.BroadcastTo(shape...)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
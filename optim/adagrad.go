@@ -0,0 +1,67 @@
+package optim
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/timotewb/gonn/nn"
+)
+
+// Adagrad implements the Adagrad algorithm (Duchi et al., 2011), following torch.optim.Adagrad.
+//
+// Adagrad accumulates the sum of the squared gradients of each element ("sum") and divides the learning rate by its
+// root, so frequently updated elements take smaller steps:
+//
+//	g = g + weightDecay * p
+//	s = s + g^2
+//	p = p - lr * g / (sqrt(s) + eps)
+type Adagrad struct {
+	base
+	Eps float64
+}
+
+// NewAdagrad creates an Adagrad optimizer.
+//
+// Parameters:
+//
+//	params ([]*nn.Parameter): The parameters to optimize, forming the first parameter group.
+//	lr (float64): The learning rate, typically 0.01.
+//	eps (float64): A term added to the denominator for numerical stability, typically 1e-10.
+//	weightDecay (float64): The L2 penalty added to the gradients.
+//
+// Returns:
+//
+//	(*Adagrad, error): The optimizer, or nil and an error if a hyperparameter is invalid.
+//
+// Errors:
+//
+//	Returns an error if a hyperparameter is negative or a parameter is not contiguous.
+func NewAdagrad(params []*nn.Parameter, lr, eps, weightDecay float64) (*Adagrad, error) {
+	if eps < 0 {
+		return nil, fmt.Errorf("invalid epsilon value: %v", eps)
+	}
+	b, err := newBase(params, lr, weightDecay, "sum")
+	if err != nil {
+		return nil, err
+	}
+	return &Adagrad{base: b, Eps: eps}, nil
+}
+
+// Step updates every parameter in place using its current gradient.
+func (o *Adagrad) Step() error {
+	for _, group := range o.groups {
+		for _, p := range group.Params {
+			value, grad := p.Value.Data(), p.Grad.Data()
+			s := o.buffer("sum", p)
+			for _, span := range p.RowSpans() {
+				for i := span[0]; i < span[1]; i++ {
+					g := grad[i] + group.WeightDecay*value[i]
+					s[i] += g * g
+					value[i] -= group.LR * g / (math.Sqrt(s[i]) + o.Eps)
+				}
+			}
+			o.steps[p]++
+		}
+	}
+	return nil
+}
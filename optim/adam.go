@@ -0,0 +1,102 @@
+package optim
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/timotewb/gonn/nn"
+)
+
+// Adam implements the Adam algorithm (Kingma and Ba, 2014), following torch.optim.Adam.
+//
+// Adam keeps exponential moving averages of each gradient ("exp_avg") and its square ("exp_avg_sq"), corrects their
+// bias towards zero in the first steps, and scales each update by the inverse root of the second moment:
+//
+//	g = g + weightDecay * p
+//	m = beta1 * m + (1 - beta1) * g
+//	v = beta2 * v + (1 - beta2) * g^2
+//	p = p - lr * (m / (1 - beta1^t)) / (sqrt(v / (1 - beta2^t)) + eps)
+//
+// When Decoupled is set the weight decay is applied directly to the parameters instead, as in AdamW.
+type Adam struct {
+	base
+	Beta1     float64
+	Beta2     float64
+	Eps       float64
+	Decoupled bool
+}
+
+// NewAdam creates an Adam optimizer with the weight decay added to the gradients as an L2 penalty.
+//
+// Parameters:
+//
+//	params ([]*nn.Parameter): The parameters to optimize, forming the first parameter group.
+//	lr (float64): The learning rate, typically 0.001.
+//	beta1, beta2 (float64): The decay rates of the moment estimates, in [0, 1), typically 0.9 and 0.999.
+//	eps (float64): A term added to the denominator for numerical stability, typically 1e-8.
+//	weightDecay (float64): The L2 penalty added to the gradients.
+//
+// Returns:
+//
+//	(*Adam, error): The optimizer, or nil and an error if a hyperparameter is invalid.
+//
+// Errors:
+//
+//	Returns an error if a beta is outside [0, 1), eps, lr or weightDecay is negative, or a parameter is not contiguous.
+func NewAdam(params []*nn.Parameter, lr, beta1, beta2, eps, weightDecay float64) (*Adam, error) {
+	return newAdam(params, lr, beta1, beta2, eps, weightDecay, false)
+}
+
+// NewAdamW creates an Adam optimizer with decoupled weight decay (Loshchilov and Hutter, 2017), following
+// torch.optim.AdamW. Each step first shrinks the parameters by lr * weightDecay and then applies the Adam update, so
+// the decay is not scaled by the adaptive learning rate. The parameters are as for NewAdam; weightDecay is typically
+// 0.01.
+func NewAdamW(params []*nn.Parameter, lr, beta1, beta2, eps, weightDecay float64) (*Adam, error) {
+	return newAdam(params, lr, beta1, beta2, eps, weightDecay, true)
+}
+
+// newAdam validates the hyperparameters shared by Adam and AdamW.
+func newAdam(params []*nn.Parameter, lr, beta1, beta2, eps, weightDecay float64, decoupled bool) (*Adam, error) {
+	if beta1 < 0 || beta1 >= 1 {
+		return nil, fmt.Errorf("invalid beta parameter at index 0: %v", beta1)
+	}
+	if beta2 < 0 || beta2 >= 1 {
+		return nil, fmt.Errorf("invalid beta parameter at index 1: %v", beta2)
+	}
+	if eps < 0 {
+		return nil, fmt.Errorf("invalid epsilon value: %v", eps)
+	}
+	b, err := newBase(params, lr, weightDecay, "exp_avg", "exp_avg_sq")
+	if err != nil {
+		return nil, err
+	}
+	return &Adam{base: b, Beta1: beta1, Beta2: beta2, Eps: eps, Decoupled: decoupled}, nil
+}
+
+// Step updates every parameter in place using its current gradient.
+func (o *Adam) Step() error {
+	for _, group := range o.groups {
+		for _, p := range group.Params {
+			value, grad := p.Value.Data(), p.Grad.Data()
+			m, v := o.buffer("exp_avg", p), o.buffer("exp_avg_sq", p)
+			o.steps[p]++
+			t := float64(o.steps[p])
+			correction1 := 1 - math.Pow(o.Beta1, t)
+			correction2 := 1 - math.Pow(o.Beta2, t)
+			for _, span := range p.RowSpans() {
+				for i := span[0]; i < span[1]; i++ {
+					g := grad[i]
+					if o.Decoupled {
+						value[i] *= 1 - group.LR*group.WeightDecay
+					} else {
+						g += group.WeightDecay * value[i]
+					}
+					m[i] = o.Beta1*m[i] + (1-o.Beta1)*g
+					v[i] = o.Beta2*v[i] + (1-o.Beta2)*g*g
+					value[i] -= group.LR * (m[i] / correction1) / (math.Sqrt(v[i]/correction2) + o.Eps)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package optim
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/timotewb/gonn/nn"
+)
+
+// ClipGradNorm rescales the gradients of the parameters in place so that their combined norm is at most maxNorm,
+// like torch.nn.utils.clip_grad_norm_.
+//
+// The norm is computed over all gradients together as if they were concatenated into a single vector.
+//
+// Parameters:
+//
+//	params ([]*nn.Parameter): The parameters whose gradients are clipped.
+//	maxNorm (float64): The maximum allowed norm.
+//	normType (float64): The order of the norm, for example 2 for the Euclidean norm or math.Inf(1) for the maximum
+//	                    absolute value.
+//
+// Returns:
+//
+//	(float64, error): The total norm of the gradients before clipping.
+//
+// Errors:
+//
+//	Returns an error if maxNorm is negative or normType is not positive.
+func ClipGradNorm(params []*nn.Parameter, maxNorm, normType float64) (float64, error) {
+	if maxNorm < 0 {
+		return 0, fmt.Errorf("maxNorm must not be negative, got %v", maxNorm)
+	}
+	if normType <= 0 {
+		return 0, fmt.Errorf("normType must be positive, got %v", normType)
+	}
+	total := 0.
+	for _, p := range params {
+		for _, g := range p.Grad.Data() {
+			if math.IsInf(normType, 1) {
+				total = math.Max(total, math.Abs(g))
+			} else {
+				total += math.Pow(math.Abs(g), normType)
+			}
+		}
+	}
+	if !math.IsInf(normType, 1) {
+		total = math.Pow(total, 1/normType)
+	}
+	coef := maxNorm / (total + 1e-6)
+	if coef < 1 {
+		for _, p := range params {
+			grad := p.Grad.Data()
+			for i := range grad {
+				grad[i] *= coef
+			}
+		}
+	}
+	return total, nil
+}
+
+// ClipGradValue clips every gradient element of the parameters in place to the range [-clipValue, clipValue], like
+// torch.nn.utils.clip_grad_value_.
+//
+// Errors:
+//
+//	Returns an error if clipValue is negative.
+func ClipGradValue(params []*nn.Parameter, clipValue float64) error {
+	if clipValue < 0 {
+		return fmt.Errorf("clipValue must not be negative, got %v", clipValue)
+	}
+	for _, p := range params {
+		grad := p.Grad.Data()
+		for i, g := range grad {
+			grad[i] = math.Max(-clipValue, math.Min(clipValue, g))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package optim
+
+import (
+	"fmt"
+
+	"github.com/timotewb/gonn/nn"
+	"github.com/timotewb/gonn/numpy"
+)
+
+// Optimizer updates the values of a set of parameters in place from their accumulated gradients.
+//
+// Step applies one update using the current gradients, and ZeroGrad clears them before the next backward pass.
+// ParamGroups exposes the groups of parameters and their hyperparameters, so learning rates can be changed between
+// steps. StateDict and LoadStateDict save and restore the internal state (such as moment estimates) so that training
+// can be resumed exactly.
+//
+// Parameters with a sparse gradient (see nn.Parameter.Rows) are updated lazily: only the rows seen since the last
+// ZeroGrad are touched, including their weight decay and moment estimates, like TensorFlow's LazyAdam. This keeps
+// the cost of a step proportional to the rows used by a batch rather than to the size of an embedding table.
+type Optimizer interface {
+	Step() error
+	ZeroGrad()
+	ParamGroups() []*ParamGroup
+	StateDict() *State
+	LoadStateDict(state *State) error
+}
+
+// ParamGroup is a set of parameters sharing a learning rate and weight decay.
+//
+// Fields:
+//
+//	Params ([]*nn.Parameter): The parameters updated by the optimizer.
+//	LR (float64): The learning rate used for these parameters.
+//	WeightDecay (float64): The weight decay coefficient used for these parameters.
+type ParamGroup struct {
+	Params      []*nn.Parameter
+	LR          float64
+	WeightDecay float64
+}
+
+// State is the internal state of an optimizer, as returned by StateDict.
+//
+// Fields:
+//
+//	Steps ([]int): The number of updates applied to each parameter, in the order of the parameter groups.
+//	Buffers (map[string][]*numpy.NDArray): The per-parameter buffers of the optimizer by name, for example "exp_avg"
+//	                                      for Adam's first moment. Entries are nil for parameters that have not been
+//	                                      updated yet.
+//	LRs ([]float64): The learning rate of each parameter group.
+//	WeightDecays ([]float64): The weight decay of each parameter group.
+type State struct {
+	Steps        []int
+	Buffers      map[string][]*numpy.NDArray
+	LRs          []float64
+	WeightDecays []float64
+}
+
+// base holds the parameter groups and per-parameter state shared by every optimizer.
+type base struct {
+	groups  []*ParamGroup
+	steps   map[*nn.Parameter]int
+	buffers map[string]map[*nn.Parameter]*numpy.NDArray
+	names   []string
+}
+
+// newBase creates the shared state with one parameter group and the given buffer names.
+func newBase(params []*nn.Parameter, lr, weightDecay float64, names ...string) (base, error) {
+	b := base{
+		steps:   map[*nn.Parameter]int{},
+		buffers: map[string]map[*nn.Parameter]*numpy.NDArray{},
+		names:   names,
+	}
+	for _, name := range names {
+		b.buffers[name] = map[*nn.Parameter]*numpy.NDArray{}
+	}
+	if err := b.AddParamGroup(params, lr, weightDecay); err != nil {
+		return base{}, err
+	}
+	return b, nil
+}
+
+// AddParamGroup adds a group of parameters with its own learning rate and weight decay, for example to fine-tune
+// pretrained layers more slowly than new ones.
+//
+// Errors:
+//
+//	Returns an error if the learning rate or weight decay is negative, a parameter is not contiguous, or a parameter
+//	already belongs to another group.
+func (b *base) AddParamGroup(params []*nn.Parameter, lr, weightDecay float64) error {
+	if lr < 0 {
+		return fmt.Errorf("invalid learning rate: %v", lr)
+	}
+	if weightDecay < 0 {
+		return fmt.Errorf("invalid weight decay value: %v", weightDecay)
+	}
+	for _, p := range params {
+		if !p.Value.IsContiguous() || !p.Grad.IsContiguous() {
+			return fmt.Errorf("parameter %q must be contiguous to be updated in place", p.Name)
+		}
+		for _, g := range b.groups {
+			for _, q := range g.Params {
+				if p == q {
+					return fmt.Errorf("some parameters appear in more than one parameter group")
+				}
+			}
+		}
+	}
+	b.groups = append(b.groups, &ParamGroup{Params: params, LR: lr, WeightDecay: weightDecay})
+	return nil
+}
+
+// ParamGroups returns the parameter groups. Changing LR or WeightDecay affects the following steps.
+func (b *base) ParamGroups() []*ParamGroup {
+	return b.groups
+}
+
+// ZeroGrad resets the gradients of every parameter to zero.
+func (b *base) ZeroGrad() {
+	for _, g := range b.groups {
+		for _, p := range g.Params {
+			p.ZeroGrad()
+		}
+	}
+}
+
+// buffer returns the named buffer of a parameter, creating it filled with zeros on first use.
+func (b *base) buffer(name string, p *nn.Parameter) []float64 {
+	buf, ok := b.buffers[name][p]
+	if !ok {
+		buf = numpy.ZerosArray(p.Value.Shape()...)
+		b.buffers[name][p] = buf
+	}
+	return buf.Data()
+}
+
+// hasBuffer reports whether the named buffer of a parameter has been created.
+func (b *base) hasBuffer(name string, p *nn.Parameter) bool {
+	_, ok := b.buffers[name][p]
+	return ok
+}
+
+// params returns every parameter in group order.
+func (b *base) params() []*nn.Parameter {
+	out := []*nn.Parameter{}
+	for _, g := range b.groups {
+		out = append(out, g.Params...)
+	}
+	return out
+}
+
+// StateDict returns a copy of the optimizer state that can be restored with LoadStateDict.
+func (b *base) StateDict() *State {
+	params := b.params()
+	s := &State{
+		Steps:   make([]int, len(params)),
+		Buffers: map[string][]*numpy.NDArray{},
+	}
+	for i, p := range params {
+		s.Steps[i] = b.steps[p]
+	}
+	for _, name := range b.names {
+		bufs := make([]*numpy.NDArray, len(params))
+		for i, p := range params {
+			if buf, ok := b.buffers[name][p]; ok {
+				bufs[i] = buf.Copy()
+			}
+		}
+		s.Buffers[name] = bufs
+	}
+	for _, g := range b.groups {
+		s.LRs = append(s.LRs, g.LR)
+		s.WeightDecays = append(s.WeightDecays, g.WeightDecay)
+	}
+	return s
+}
+
+// LoadStateDict restores a state returned by StateDict for the same parameters, in the same group order.
+//
+// Errors:
+//
+//	Returns an error if the state does not match the parameter groups or buffers of the optimizer. The optimizer is
+//	left unchanged in that case.
+func (b *base) LoadStateDict(state *State) error {
+	params := b.params()
+	if len(state.Steps) != len(params) {
+		return fmt.Errorf("loaded state contains %v parameters, optimizer has %v", len(state.Steps), len(params))
+	}
+	if len(state.LRs) != len(b.groups) || len(state.WeightDecays) != len(b.groups) {
+		return fmt.Errorf("loaded state contains %v parameter groups, optimizer has %v", len(state.LRs), len(b.groups))
+	}
+	for _, name := range b.names {
+		bufs, ok := state.Buffers[name]
+		if !ok || len(bufs) != len(params) {
+			return fmt.Errorf("loaded state is missing buffer %q for every parameter", name)
+		}
+		for i, buf := range bufs {
+			if buf != nil && buf.Size() != params[i].Value.Size() {
+				return fmt.Errorf("buffer %q for parameter %v has %v elements, expected %v", name, i, buf.Size(), params[i].Value.Size())
+			}
+		}
+	}
+
+	// Everything matches, so copy the state in
+	for i, p := range params {
+		b.steps[p] = state.Steps[i]
+	}
+	for _, name := range b.names {
+		b.buffers[name] = map[*nn.Parameter]*numpy.NDArray{}
+		for i, buf := range state.Buffers[name] {
+			if buf != nil {
+				c, _ := buf.Copy().Reshape(params[i].Value.Shape()...)
+				b.buffers[name][params[i]] = c
+			}
+		}
+	}
+	for i, g := range b.groups {
+		g.LR = state.LRs[i]
+		g.WeightDecay = state.WeightDecays[i]
+	}
+	return nil
+}
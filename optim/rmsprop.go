@@ -0,0 +1,87 @@
+package optim
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/timotewb/gonn/nn"
+)
+
+// RMSProp implements the RMSProp algorithm, following torch.optim.RMSprop.
+//
+// RMSProp divides each gradient by a moving root mean square of its recent values ("square_avg"), optionally
+// followed by momentum ("momentum_buffer"):
+//
+//	g = g + weightDecay * p
+//	s = alpha * s + (1 - alpha) * g^2
+//	buf = momentum * buf + g / (sqrt(s) + eps)
+//	p = p - lr * buf
+type RMSProp struct {
+	base
+	Alpha    float64
+	Eps      float64
+	Momentum float64
+}
+
+// NewRMSProp creates an RMSProp optimizer.
+//
+// Parameters:
+//
+//	params ([]*nn.Parameter): The parameters to optimize, forming the first parameter group.
+//	lr (float64): The learning rate, typically 0.01.
+//	alpha (float64): The smoothing constant of the squared gradient average, typically 0.99.
+//	eps (float64): A term added to the denominator for numerical stability, typically 1e-8.
+//	momentum (float64): The momentum factor, 0 to disable momentum.
+//	weightDecay (float64): The L2 penalty added to the gradients.
+//
+// Returns:
+//
+//	(*RMSProp, error): The optimizer, or nil and an error if a hyperparameter is invalid.
+//
+// Errors:
+//
+//	Returns an error if a hyperparameter is negative, alpha is greater than one, or a parameter is not contiguous.
+func NewRMSProp(params []*nn.Parameter, lr, alpha, eps, momentum, weightDecay float64) (*RMSProp, error) {
+	if alpha < 0 || alpha > 1 {
+		return nil, fmt.Errorf("invalid alpha value: %v", alpha)
+	}
+	if eps < 0 {
+		return nil, fmt.Errorf("invalid epsilon value: %v", eps)
+	}
+	if momentum < 0 {
+		return nil, fmt.Errorf("invalid momentum value: %v", momentum)
+	}
+	b, err := newBase(params, lr, weightDecay, "square_avg", "momentum_buffer")
+	if err != nil {
+		return nil, err
+	}
+	return &RMSProp{base: b, Alpha: alpha, Eps: eps, Momentum: momentum}, nil
+}
+
+// Step updates every parameter in place using its current gradient.
+func (o *RMSProp) Step() error {
+	for _, group := range o.groups {
+		for _, p := range group.Params {
+			value, grad := p.Value.Data(), p.Grad.Data()
+			s := o.buffer("square_avg", p)
+			var buf []float64
+			if o.Momentum > 0 {
+				buf = o.buffer("momentum_buffer", p)
+			}
+			for _, span := range p.RowSpans() {
+				for i := span[0]; i < span[1]; i++ {
+					g := grad[i] + group.WeightDecay*value[i]
+					s[i] = o.Alpha*s[i] + (1-o.Alpha)*g*g
+					update := g / (math.Sqrt(s[i]) + o.Eps)
+					if buf != nil {
+						buf[i] = o.Momentum*buf[i] + update
+						update = buf[i]
+					}
+					value[i] -= group.LR * update
+				}
+			}
+			o.steps[p]++
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package optim
+
+import (
+	"fmt"
+
+	"github.com/timotewb/gonn/nn"
+)
+
+// SGD implements stochastic gradient descent with optional momentum, Nesterov momentum and L2 weight decay, following
+// torch.optim.SGD.
+//
+// With momentum m the update for each parameter p with gradient g is
+//
+//	g = g + weightDecay * p
+//	buf = m * buf + g
+//	p = p - lr * buf              (or p - lr * (g + m * buf) with Nesterov momentum)
+//
+// Example usage:
+//
+//	opt, _ := optim.NewSGD(model.Parameters(), 0.01, 0.9, 0, false)
+//	for each batch {
+//	    opt.ZeroGrad()
+//	    ... forward and backward ...
+//	    opt.Step()
+//	}
+type SGD struct {
+	base
+	Momentum float64
+	Nesterov bool
+}
+
+// NewSGD creates a stochastic gradient descent optimizer.
+//
+// Parameters:
+//
+//	params ([]*nn.Parameter): The parameters to optimize, forming the first parameter group.
+//	lr (float64): The learning rate.
+//	momentum (float64): The momentum factor, 0 to disable momentum.
+//	weightDecay (float64): The L2 penalty added to the gradients.
+//	nesterov (bool): Whether to use Nesterov momentum. Requires a positive momentum.
+//
+// Returns:
+//
+//	(*SGD, error): The optimizer, or nil and an error if a hyperparameter is invalid.
+//
+// Errors:
+//
+//	Returns an error if a hyperparameter is negative, Nesterov momentum is requested without momentum, or a parameter
+//	is not contiguous.
+func NewSGD(params []*nn.Parameter, lr, momentum, weightDecay float64, nesterov bool) (*SGD, error) {
+	if momentum < 0 {
+		return nil, fmt.Errorf("invalid momentum value: %v", momentum)
+	}
+	if nesterov && momentum == 0 {
+		return nil, fmt.Errorf("nesterov momentum requires a momentum")
+	}
+	b, err := newBase(params, lr, weightDecay, "momentum_buffer")
+	if err != nil {
+		return nil, err
+	}
+	return &SGD{base: b, Momentum: momentum, Nesterov: nesterov}, nil
+}
+
+// Step updates every parameter in place using its current gradient.
+func (o *SGD) Step() error {
+	for _, group := range o.groups {
+		for _, p := range group.Params {
+			value, grad := p.Value.Data(), p.Grad.Data()
+			var buf []float64
+			first := false
+			if o.Momentum != 0 {
+				first = !o.hasBuffer("momentum_buffer", p)
+				buf = o.buffer("momentum_buffer", p)
+			}
+			for _, span := range p.RowSpans() {
+				for i := span[0]; i < span[1]; i++ {
+					g := grad[i] + group.WeightDecay*value[i]
+					if buf != nil {
+						if first {
+							buf[i] = g
+						} else {
+							buf[i] = o.Momentum*buf[i] + g
+						}
+						if o.Nesterov {
+							g += o.Momentum * buf[i]
+						} else {
+							g = buf[i]
+						}
+					}
+					value[i] -= group.LR * g
+				}
+			}
+			o.steps[p]++
+		}
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package regularizers
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/timotewb/gonn/numpy"
+)
+
+// normEpsilon keeps the rescaling finite for all-zero slices, as in Keras.
+const normEpsilon = 1e-7
+
+// writable returns the data of w for modification in place.
+func writable(w *numpy.NDArray) ([]float64, error) {
+	if w.AsContiguous() != w {
+		return nil, fmt.Errorf("constraints modify parameters in place and need a contiguous array")
+	}
+	return w.Data(), nil
+}
+
+// groups assigns every element of an array of the given shape to the slice it belongs to when the norm is taken
+// over axes, and returns the number of slices. Axes default to 0, the input axis of a Dense weight, so each output
+// unit is constrained separately.
+func groups(shape []int, axes []int) ([]int, int, error) {
+	if len(axes) == 0 {
+		axes = []int{0}
+	}
+	reduced := make([]bool, len(shape))
+	for _, a := range axes {
+		if a < 0 {
+			a += len(shape)
+		}
+		if a < 0 || a >= len(shape) {
+			return nil, 0, fmt.Errorf("axis %v is out of bounds for an array of %v dimensions", a, len(shape))
+		}
+		reduced[a] = true
+	}
+	size, count := 1, 1
+	for d, n := range shape {
+		size *= n
+		if !reduced[d] {
+			count *= n
+		}
+	}
+	group := make([]int, size)
+	index := make([]int, len(shape))
+	for i := range group {
+		g := 0
+		for d, n := range shape {
+			if !reduced[d] {
+				g = g*n + index[d]
+			}
+		}
+		group[i] = g
+		for d := len(shape) - 1; d >= 0; d-- {
+			index[d]++
+			if index[d] < shape[d] {
+				break
+			}
+			index[d] = 0
+		}
+	}
+	return group, count, nil
+}
+
+// rescale multiplies every slice of w by scale(norm) of its L2 norm over axes.
+func rescale(w *numpy.NDArray, axes []int, scale func(norm float64) float64) error {
+	data, err := writable(w)
+	if err != nil {
+		return err
+	}
+	group, count, err := groups(w.Shape(), axes)
+	if err != nil {
+		return err
+	}
+	norms := make([]float64, count)
+	for i, v := range data {
+		norms[group[i]] += v * v
+	}
+	for g := range norms {
+		norms[g] = scale(math.Sqrt(norms[g]))
+	}
+	for i := range data {
+		data[i] *= norms[group[i]]
+	}
+	return nil
+}
+
+// MaxNorm rescales the slices of a parameter whose L2 norm exceeds MaxValue back to that norm, like
+// tf.keras.constraints.MaxNorm. It is often combined with dropout (Srivastava et al., 2014).
+//
+// It implements nn.Constraint and is attached to a parameter through its Constraint field.
+//
+// Fields:
+//
+//	MaxValue (float64): The largest allowed norm.
+//	Axes ([]int): The axes the norm is taken over. Empty means axis 0, which constrains the incoming weights of each
+//	              unit of a Dense weight of shape (in, out). For a convolution weight of shape (out, in, kh, kw) use
+//	              axes 1, 2 and 3 to constrain each filter.
+type MaxNorm struct {
+	MaxValue float64
+	Axes     []int
+}
+
+// NewMaxNorm creates a max-norm constraint.
+//
+// Errors:
+//
+//	Returns an error if maxValue is not positive.
+func NewMaxNorm(maxValue float64, axes ...int) (*MaxNorm, error) {
+	if maxValue <= 0 {
+		return nil, fmt.Errorf("maxValue must be positive, got %v", maxValue)
+	}
+	return &MaxNorm{MaxValue: maxValue, Axes: axes}, nil
+}
+
+// Project rescales the slices of w whose norm exceeds MaxValue.
+//
+// Errors:
+//
+//	Returns an error if w is not contiguous or an axis is out of bounds.
+func (c *MaxNorm) Project(w *numpy.NDArray) error {
+	return rescale(w, c.Axes, func(norm float64) float64 {
+		return math.Min(norm, c.MaxValue) / (norm + normEpsilon)
+	})
+}
+
+// UnitNorm rescales every slice of a parameter to unit L2 norm, like tf.keras.constraints.UnitNorm. The axes are as
+// for MaxNorm.
+type UnitNorm struct {
+	Axes []int
+}
+
+// NewUnitNorm creates a unit-norm constraint over the given axes.
+func NewUnitNorm(axes ...int) *UnitNorm {
+	return &UnitNorm{Axes: axes}
+}
+
+// Project rescales every slice of w to unit norm.
+//
+// Errors:
+//
+//	Returns an error if w is not contiguous or an axis is out of bounds.
+func (c *UnitNorm) Project(w *numpy.NDArray) error {
+	return rescale(w, c.Axes, func(norm float64) float64 { return 1 / (norm + normEpsilon) })
+}
+
+// NonNeg sets the negative values of a parameter to zero, like tf.keras.constraints.NonNeg.
+type NonNeg struct{}
+
+// NewNonNeg creates a non-negativity constraint.
+func NewNonNeg() *NonNeg {
+	return &NonNeg{}
+}
+
+// Project sets the negative values of w to zero.
+//
+// Errors:
+//
+//	Returns an error if w is not contiguous.
+func (c *NonNeg) Project(w *numpy.NDArray) error {
+	data, err := writable(w)
+	if err != nil {
+		return err
+	}
+	for i, v := range data {
+		if v < 0 {
+			data[i] = 0
+		}
+	}
+	return nil
+}
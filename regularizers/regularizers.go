@@ -0,0 +1,79 @@
+package regularizers
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/timotewb/gonn/numpy"
+)
+
+// ElasticNet is the penalty L1 * sum(|w|) + L2 * sum(w^2), like tf.keras.regularizers.L1L2. With L2 zero it is the
+// lasso (L1) penalty, which drives weights to exactly zero, and with L1 zero it is the ridge (L2) penalty, which is
+// equivalent to weight decay for plain SGD.
+//
+// It implements nn.Regularizer and is attached to a parameter through its Regularizer field.
+//
+// Example usage:
+//
+//	dense.Weight.Regularizer, _ = regularizers.NewL2(1e-4)
+//
+// Fields:
+//
+//	L1 (float64): The coefficient of the sum of absolute values.
+//	L2 (float64): The coefficient of the sum of squares.
+type ElasticNet struct {
+	L1 float64
+	L2 float64
+}
+
+// NewElasticNet creates the penalty l1 * sum(|w|) + l2 * sum(w^2).
+//
+// Errors:
+//
+//	Returns an error if a coefficient is negative.
+func NewElasticNet(l1, l2 float64) (*ElasticNet, error) {
+	if l1 < 0 || l2 < 0 {
+		return nil, fmt.Errorf("regularization coefficients must not be negative, got %v and %v", l1, l2)
+	}
+	return &ElasticNet{L1: l1, L2: l2}, nil
+}
+
+// NewL1 creates the penalty l * sum(|w|).
+//
+// Errors:
+//
+//	Returns an error if l is negative.
+func NewL1(l float64) (*ElasticNet, error) {
+	return NewElasticNet(l, 0)
+}
+
+// NewL2 creates the penalty l * sum(w^2).
+//
+// Errors:
+//
+//	Returns an error if l is negative.
+func NewL2(l float64) (*ElasticNet, error) {
+	return NewElasticNet(0, l)
+}
+
+// Penalty returns the penalty of w and its gradient L1 * sign(w) + 2 * L2 * w. The subgradient of |w| at zero is
+// taken as zero.
+func (r *ElasticNet) Penalty(w *numpy.NDArray) (float64, *numpy.NDArray, error) {
+	values := w.Data()
+	grad := make([]float64, len(values))
+	penalty := 0.
+	for i, v := range values {
+		penalty += r.L1*math.Abs(v) + r.L2*v*v
+		grad[i] = 2 * r.L2 * v
+		if v > 0 {
+			grad[i] += r.L1
+		} else if v < 0 {
+			grad[i] -= r.L1
+		}
+	}
+	g, err := numpy.NewArray(grad, w.Shape()...)
+	if err != nil {
+		return 0, nil, err
+	}
+	return penalty, g, nil
+}
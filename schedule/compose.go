@@ -0,0 +1,163 @@
+package schedule
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Chain applies several schedules one after the other at every step, each to the learning rate returned by the
+// previous one, like torch.optim.lr_scheduler.ChainedScheduler. Chaining multiplicative schedules multiplies their
+// factors.
+type Chain struct {
+	Schedules []Schedule
+}
+
+// NewChain creates a chain of schedules.
+//
+// Errors:
+//
+//	Returns an error if no schedule is given or a schedule is nil.
+func NewChain(schedules ...Schedule) (*Chain, error) {
+	if err := checkSchedules(schedules); err != nil {
+		return nil, err
+	}
+	return &Chain{Schedules: schedules}, nil
+}
+
+// checkSchedules returns an error if schedules is empty or contains nil.
+func checkSchedules(schedules []Schedule) error {
+	if len(schedules) == 0 {
+		return fmt.Errorf("at least one schedule is required")
+	}
+	for i, s := range schedules {
+		if s == nil {
+			return fmt.Errorf("schedule %v is nil", i)
+		}
+	}
+	return nil
+}
+
+// LR returns the learning rate after applying every schedule in order.
+func (c *Chain) LR(base float64, step int) float64 {
+	lr := base
+	for _, s := range c.Schedules {
+		lr = s.LR(lr, step)
+	}
+	return lr
+}
+
+// Observe passes the value to every schedule that is an Observer.
+func (c *Chain) Observe(value float64) {
+	observe(c.Schedules, value)
+}
+
+// State returns the state of the stateful schedules, keyed by their position and name, for example "1.best".
+func (c *Chain) State() map[string]float64 {
+	return states(c.Schedules)
+}
+
+// LoadState restores a state returned by State.
+func (c *Chain) LoadState(state map[string]float64) error {
+	return loadStates(c.Schedules, state)
+}
+
+// Sequential switches between schedules at the given milestones, like torch.optim.lr_scheduler.SequentialLR. Each
+// schedule counts its steps from the milestone at which it starts, so a warmup can be followed by a decay that
+// starts from the initial learning rate.
+type Sequential struct {
+	Schedules  []Schedule
+	Milestones []int
+}
+
+// NewSequential creates a sequence of schedules.
+//
+// Parameters:
+//
+//	schedules ([]Schedule): The schedules to use in order.
+//	milestones ([]int): The steps at which each schedule after the first starts, one fewer than the schedules.
+//
+// Returns:
+//
+//	(*Sequential, error): The schedule, or nil and an error if the arguments are invalid.
+//
+// Errors:
+//
+//	Returns an error if no schedule is given, a schedule is nil, or the milestones are not increasing or do not
+//	number one fewer than the schedules.
+func NewSequential(schedules []Schedule, milestones []int) (*Sequential, error) {
+	if err := checkSchedules(schedules); err != nil {
+		return nil, err
+	}
+	if len(milestones) != len(schedules)-1 || !sort.IntsAreSorted(milestones) {
+		return nil, fmt.Errorf("expected %v increasing milestones, got %v", len(schedules)-1, milestones)
+	}
+	return &Sequential{Schedules: schedules, Milestones: append([]int{}, milestones...)}, nil
+}
+
+// LR returns the learning rate of the schedule active at step.
+func (s *Sequential) LR(base float64, step int) float64 {
+	i := sort.SearchInts(s.Milestones, step+1)
+	if i > 0 {
+		step -= s.Milestones[i-1]
+	}
+	return s.Schedules[i].LR(base, step)
+}
+
+// Observe passes the value to every schedule that is an Observer.
+func (s *Sequential) Observe(value float64) {
+	observe(s.Schedules, value)
+}
+
+// State returns the state of the stateful schedules, keyed by their position and name.
+func (s *Sequential) State() map[string]float64 {
+	return states(s.Schedules)
+}
+
+// LoadState restores a state returned by State.
+func (s *Sequential) LoadState(state map[string]float64) error {
+	return loadStates(s.Schedules, state)
+}
+
+// observe passes value to every Observer in schedules.
+func observe(schedules []Schedule, value float64) {
+	for _, s := range schedules {
+		if o, ok := s.(Observer); ok {
+			o.Observe(value)
+		}
+	}
+}
+
+// states merges the states of the stateful schedules, prefixing each key with the position of its schedule.
+func states(schedules []Schedule) map[string]float64 {
+	state := map[string]float64{}
+	for i, s := range schedules {
+		if st, ok := s.(Stateful); ok {
+			for k, v := range st.State() {
+				state[fmt.Sprintf("%v.%v", i, k)] = v
+			}
+		}
+	}
+	return state
+}
+
+// loadStates splits a state returned by states and loads each part into its schedule.
+func loadStates(schedules []Schedule, state map[string]float64) error {
+	for i, s := range schedules {
+		st, ok := s.(Stateful)
+		if !ok {
+			continue
+		}
+		prefix := fmt.Sprintf("%v.", i)
+		part := map[string]float64{}
+		for k, v := range state {
+			if strings.HasPrefix(k, prefix) {
+				part[strings.TrimPrefix(k, prefix)] = v
+			}
+		}
+		if err := st.LoadState(part); err != nil {
+			return fmt.Errorf("schedule %v: %v", i, err)
+		}
+	}
+	return nil
+}
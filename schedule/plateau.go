@@ -0,0 +1,123 @@
+package schedule
+
+import (
+	"fmt"
+	"math"
+)
+
+// ReduceOnPlateau multiplies the learning rate by Factor when a monitored quantity has stopped improving, like
+// torch.optim.lr_scheduler.ReduceLROnPlateau. Values of the quantity are passed to Observe, usually by a Scheduler
+// with Monitor set; the step count is ignored.
+type ReduceOnPlateau struct {
+	Factor    float64
+	Patience  int
+	Threshold float64
+	Cooldown  int
+	MinLR     float64
+	Maximize  bool
+
+	scale    float64
+	best     float64
+	wait     int
+	cooldown int
+}
+
+// NewReduceOnPlateau creates a schedule reducing the learning rate on plateaus.
+//
+// Parameters:
+//
+//	mode (string): "min" if the quantity should decrease, such as a loss, or "max" if it should increase.
+//	factor (float64): The factor in (0, 1) by which the learning rate is multiplied.
+//	patience (int): The number of observations without improvement after which the learning rate is reduced.
+//	threshold (float64): The minimum change counted as an improvement.
+//	cooldown (int): The number of observations to wait after a reduction before counting again.
+//	minLR (float64): The lower bound of the learning rate.
+//
+// Returns:
+//
+//	(*ReduceOnPlateau, error): The schedule, or nil and an error if an argument is invalid.
+//
+// Errors:
+//
+//	Returns an error if the mode is not recognised, factor is not in (0, 1), or another argument is negative.
+func NewReduceOnPlateau(mode string, factor float64, patience int, threshold float64, cooldown int, minLR float64) (*ReduceOnPlateau, error) {
+	if mode != "min" && mode != "max" {
+		return nil, fmt.Errorf("unknown mode %q, expected min or max", mode)
+	}
+	if factor <= 0 || factor >= 1 {
+		return nil, fmt.Errorf("factor must be in (0, 1), got %v", factor)
+	}
+	if patience < 0 || threshold < 0 || cooldown < 0 || minLR < 0 {
+		return nil, fmt.Errorf("patience, threshold, cooldown and minLR must be non-negative")
+	}
+	r := &ReduceOnPlateau{
+		Factor:    factor,
+		Patience:  patience,
+		Threshold: threshold,
+		Cooldown:  cooldown,
+		MinLR:     minLR,
+		Maximize:  mode == "max",
+	}
+	r.reset()
+	return r, nil
+}
+
+// reset restores the initial learning rate and forgets the best value.
+func (r *ReduceOnPlateau) reset() {
+	r.scale, r.wait, r.cooldown = 1, 0, 0
+	r.best = math.Inf(1)
+	if r.Maximize {
+		r.best = math.Inf(-1)
+	}
+}
+
+// Observe records a value of the monitored quantity and reduces the learning rate once the patience is exhausted.
+func (r *ReduceOnPlateau) Observe(value float64) {
+	if (r.Maximize && value > r.best+r.Threshold) || (!r.Maximize && value < r.best-r.Threshold) {
+		r.best, r.wait = value, 0
+	} else {
+		r.wait++
+	}
+	if r.cooldown > 0 {
+		r.cooldown--
+		r.wait = 0
+	}
+	if r.wait > r.Patience {
+		r.scale *= r.Factor
+		r.cooldown, r.wait = r.Cooldown, 0
+	}
+}
+
+// LR returns max(base * the accumulated reduction, MinLR).
+func (r *ReduceOnPlateau) LR(base float64, step int) float64 {
+	return math.Max(base*r.scale, r.MinLR)
+}
+
+// State returns the accumulated reduction, the counters and, once a value has been observed, the best value.
+func (r *ReduceOnPlateau) State() map[string]float64 {
+	state := map[string]float64{"scale": r.scale, "wait": float64(r.wait), "cooldown": float64(r.cooldown)}
+	// Infinities cannot be encoded as JSON, so the best value is left out until there is one
+	if !math.IsInf(r.best, 0) {
+		state["best"] = r.best
+	}
+	return state
+}
+
+// LoadState restores a state returned by State.
+//
+// Errors:
+//
+//	Returns an error if the scale or a counter is missing.
+func (r *ReduceOnPlateau) LoadState(state map[string]float64) error {
+	for _, k := range []string{"scale", "wait", "cooldown"} {
+		if _, ok := state[k]; !ok {
+			return fmt.Errorf("state is missing %q", k)
+		}
+	}
+	r.reset()
+	r.scale, r.wait, r.cooldown = state["scale"], int(state["wait"]), int(state["cooldown"])
+	if best, ok := state["best"]; ok {
+		r.best = best
+	}
+	return nil
+}
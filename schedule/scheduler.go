@@ -0,0 +1,176 @@
+package schedule
+
+import (
+	"fmt"
+
+	"github.com/timotewb/gonn/model"
+	"github.com/timotewb/gonn/optim"
+)
+
+// Schedule maps the initial learning rate of a parameter group and the number of steps taken to the learning rate
+// to use, like the schedulers of torch.optim.lr_scheduler written in closed form.
+//
+// Steps are usually epochs, or batches for schedules such as OneCycle. Schedules are combined with Chain and
+// Sequential.
+type Schedule interface {
+	LR(base float64, step int) float64
+}
+
+// Observer is implemented by schedules that react to a monitored quantity, such as ReduceOnPlateau.
+type Observer interface {
+	Observe(value float64)
+}
+
+// Stateful is implemented by schedules with internal state that must be checkpointed to resume training.
+type Stateful interface {
+	State() map[string]float64
+	LoadState(state map[string]float64) error
+}
+
+// State is the state of a Scheduler, as returned by StateDict. It can be encoded as JSON.
+//
+// Fields:
+//
+//	Step (int): The number of steps taken.
+//	BaseLRs ([]float64): The initial learning rate of each parameter group.
+//	Schedule (map[string]float64): The internal state of stateful schedules, keyed by name.
+type State struct {
+	Step     int
+	BaseLRs  []float64
+	Schedule map[string]float64
+}
+
+// Scheduler sets the learning rates of an optimizer according to a schedule.
+//
+// Scheduler is also a model.Callback, stepping after each epoch, or after each batch if PerBatch is set. If Monitor
+// names a log entry, its value at the end of each epoch is passed to the observers of the schedule before stepping.
+//
+// Example usage:
+//
+//	warmup, _ := schedule.NewLinearWarmup(5, 0.1)
+//	cosine, _ := schedule.NewCosineAnnealing(95, 0)
+//	seq, _ := schedule.NewSequential([]schedule.Schedule{warmup, cosine}, []int{5})
+//	s, _ := schedule.NewScheduler(opt, seq)
+//	m.Fit(x, y, 100, 32, 0.1, s)
+//
+// Fields:
+//
+//	Schedule (Schedule): The schedule applied to every parameter group.
+//	PerBatch (bool): Whether the callback steps after each batch instead of each epoch.
+//	Monitor (string): The log entry observed at the end of each epoch, for example "val_loss", or "" for none.
+type Scheduler struct {
+	model.BaseCallback
+	Schedule Schedule
+	PerBatch bool
+	Monitor  string
+
+	optimizer optim.Optimizer
+	baseLRs   []float64
+	step      int
+}
+
+// NewScheduler creates a scheduler and sets the learning rates of the optimizer to their values at step 0. The
+// current learning rates of the parameter groups are taken as their initial learning rates.
+//
+// Errors:
+//
+//	Returns an error if the optimizer or schedule is nil.
+func NewScheduler(optimizer optim.Optimizer, schedule Schedule) (*Scheduler, error) {
+	if optimizer == nil || schedule == nil {
+		return nil, fmt.Errorf("optimizer and schedule must not be nil")
+	}
+	s := &Scheduler{Schedule: schedule, optimizer: optimizer}
+	for _, g := range optimizer.ParamGroups() {
+		s.baseLRs = append(s.baseLRs, g.LR)
+	}
+	s.apply()
+	return s, nil
+}
+
+// apply sets the learning rate of every parameter group for the current step.
+func (s *Scheduler) apply() {
+	for i, g := range s.optimizer.ParamGroups() {
+		if i < len(s.baseLRs) {
+			g.LR = s.Schedule.LR(s.baseLRs[i], s.step)
+		}
+	}
+}
+
+// Step advances the schedule by one step and updates the learning rates.
+func (s *Scheduler) Step() {
+	s.step++
+	s.apply()
+}
+
+// Observe passes a value of the monitored quantity to the schedule if it is an Observer. Call it before Step.
+func (s *Scheduler) Observe(value float64) {
+	if o, ok := s.Schedule.(Observer); ok {
+		o.Observe(value)
+	}
+}
+
+// StepCount returns the number of steps taken.
+func (s *Scheduler) StepCount() int {
+	return s.step
+}
+
+// LastLR returns the current learning rate of each parameter group.
+func (s *Scheduler) LastLR() []float64 {
+	lrs := []float64{}
+	for _, g := range s.optimizer.ParamGroups() {
+		lrs = append(lrs, g.LR)
+	}
+	return lrs
+}
+
+// OnEpochEnd observes the monitored quantity, if any, and steps unless PerBatch is set.
+func (s *Scheduler) OnEpochEnd(m *model.Model, epoch int, logs model.Logs) error {
+	if s.Monitor != "" {
+		v, ok := logs[s.Monitor]
+		if !ok {
+			return fmt.Errorf("monitored quantity %q is not available, available keys are %v", s.Monitor, logs)
+		}
+		s.Observe(v)
+	}
+	if !s.PerBatch {
+		s.Step()
+	}
+	return nil
+}
+
+// OnBatchEnd steps if PerBatch is set.
+func (s *Scheduler) OnBatchEnd(m *model.Model, batch int, logs model.Logs) error {
+	if s.PerBatch {
+		s.Step()
+	}
+	return nil
+}
+
+// StateDict returns the state of the scheduler, including the state of stateful schedules, so training can be
+// resumed with LoadStateDict.
+func (s *Scheduler) StateDict() *State {
+	state := &State{Step: s.step, BaseLRs: append([]float64{}, s.baseLRs...)}
+	if st, ok := s.Schedule.(Stateful); ok {
+		state.Schedule = st.State()
+	}
+	return state
+}
+
+// LoadStateDict restores a state returned by StateDict and updates the learning rates.
+//
+// Errors:
+//
+//	Returns an error if the state does not match the parameter groups of the optimizer or the schedule.
+func (s *Scheduler) LoadStateDict(state *State) error {
+	if len(state.BaseLRs) != len(s.optimizer.ParamGroups()) {
+		return fmt.Errorf("state has %v parameter groups but the optimizer has %v", len(state.BaseLRs), len(s.optimizer.ParamGroups()))
+	}
+	if st, ok := s.Schedule.(Stateful); ok {
+		if err := st.LoadState(state.Schedule); err != nil {
+			return err
+		}
+	}
+	s.step, s.baseLRs = state.Step, append([]float64{}, state.BaseLRs...)
+	s.apply()
+	return nil
+}
@@ -0,0 +1,266 @@
+package schedule
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// StepLR decays the learning rate by Gamma every StepSize steps, like torch.optim.lr_scheduler.StepLR.
+type StepLR struct {
+	StepSize int
+	Gamma    float64
+}
+
+// NewStepLR creates a step decay schedule.
+//
+// Errors:
+//
+//	Returns an error if stepSize is not positive or gamma is negative.
+func NewStepLR(stepSize int, gamma float64) (*StepLR, error) {
+	if stepSize <= 0 || gamma < 0 {
+		return nil, fmt.Errorf("stepSize must be positive and gamma non-negative, got %v and %v", stepSize, gamma)
+	}
+	return &StepLR{StepSize: stepSize, Gamma: gamma}, nil
+}
+
+// LR returns base * Gamma^(step / StepSize).
+func (s *StepLR) LR(base float64, step int) float64 {
+	return base * math.Pow(s.Gamma, float64(step/s.StepSize))
+}
+
+// MultiStepLR decays the learning rate by Gamma at each milestone, like torch.optim.lr_scheduler.MultiStepLR.
+type MultiStepLR struct {
+	Milestones []int
+	Gamma      float64
+}
+
+// NewMultiStepLR creates a schedule decaying at the given steps.
+//
+// Errors:
+//
+//	Returns an error if the milestones are not increasing or gamma is negative.
+func NewMultiStepLR(milestones []int, gamma float64) (*MultiStepLR, error) {
+	if !sort.IntsAreSorted(milestones) || gamma < 0 {
+		return nil, fmt.Errorf("milestones must be increasing and gamma non-negative")
+	}
+	return &MultiStepLR{Milestones: append([]int{}, milestones...), Gamma: gamma}, nil
+}
+
+// LR returns base * Gamma^k where k is the number of milestones reached.
+func (s *MultiStepLR) LR(base float64, step int) float64 {
+	return base * math.Pow(s.Gamma, float64(sort.SearchInts(s.Milestones, step+1)))
+}
+
+// ExponentialLR decays the learning rate by Gamma every step, like torch.optim.lr_scheduler.ExponentialLR.
+type ExponentialLR struct {
+	Gamma float64
+}
+
+// NewExponentialLR creates an exponential decay schedule.
+//
+// Errors:
+//
+//	Returns an error if gamma is negative.
+func NewExponentialLR(gamma float64) (*ExponentialLR, error) {
+	if gamma < 0 {
+		return nil, fmt.Errorf("gamma must be non-negative, got %v", gamma)
+	}
+	return &ExponentialLR{Gamma: gamma}, nil
+}
+
+// LR returns base * Gamma^step.
+func (s *ExponentialLR) LR(base float64, step int) float64 {
+	return base * math.Pow(s.Gamma, float64(step))
+}
+
+// CosineAnnealing anneals the learning rate from its initial value to EtaMin along a half cosine over TMax steps
+// (Loshchilov and Hutter, 2016), like torch.optim.lr_scheduler.CosineAnnealingLR. The learning rate stays at EtaMin
+// after TMax steps.
+type CosineAnnealing struct {
+	TMax   int
+	EtaMin float64
+}
+
+// NewCosineAnnealing creates a cosine annealing schedule.
+//
+// Errors:
+//
+//	Returns an error if tMax is not positive.
+func NewCosineAnnealing(tMax int, etaMin float64) (*CosineAnnealing, error) {
+	if tMax <= 0 {
+		return nil, fmt.Errorf("tMax must be positive, got %v", tMax)
+	}
+	return &CosineAnnealing{TMax: tMax, EtaMin: etaMin}, nil
+}
+
+// LR returns EtaMin + (base - EtaMin) * (1 + cos(pi * step / TMax)) / 2.
+func (s *CosineAnnealing) LR(base float64, step int) float64 {
+	return cosine(base, s.EtaMin, math.Min(float64(step)/float64(s.TMax), 1))
+}
+
+// cosine interpolates from start to end along a half cosine as t goes from 0 to 1.
+func cosine(start, end, t float64) float64 {
+	return end + (start-end)*(1+math.Cos(math.Pi*t))/2
+}
+
+// CosineAnnealingWarmRestarts anneals the learning rate along a half cosine and restarts from the initial value
+// after each period, with periods of T0, T0 * TMult, T0 * TMult^2, ... steps (SGDR), like
+// torch.optim.lr_scheduler.CosineAnnealingWarmRestarts.
+type CosineAnnealingWarmRestarts struct {
+	T0     int
+	TMult  int
+	EtaMin float64
+}
+
+// NewCosineAnnealingWarmRestarts creates a cosine annealing schedule with warm restarts.
+//
+// Errors:
+//
+//	Returns an error if t0 or tMult is not positive.
+func NewCosineAnnealingWarmRestarts(t0, tMult int, etaMin float64) (*CosineAnnealingWarmRestarts, error) {
+	if t0 <= 0 || tMult <= 0 {
+		return nil, fmt.Errorf("t0 and tMult must be positive, got %v and %v", t0, tMult)
+	}
+	return &CosineAnnealingWarmRestarts{T0: t0, TMult: tMult, EtaMin: etaMin}, nil
+}
+
+// LR returns the annealed learning rate within the current period.
+func (s *CosineAnnealingWarmRestarts) LR(base float64, step int) float64 {
+	period, t := s.T0, step
+	for t >= period {
+		t -= period
+		period *= s.TMult
+	}
+	return cosine(base, s.EtaMin, float64(t)/float64(period))
+}
+
+// OneCycle implements the 1cycle policy (Smith and Topin, 2017), like torch.optim.lr_scheduler.OneCycleLR: the
+// learning rate rises from MaxLR / DivFactor to MaxLR over the first PctStart of TotalSteps, then anneals to
+// MaxLR / (DivFactor * FinalDivFactor) at the last step. It is stepped after every batch and ignores the initial
+// learning rate of the optimizer.
+type OneCycle struct {
+	MaxLR          float64
+	TotalSteps     int
+	PctStart       float64
+	DivFactor      float64
+	FinalDivFactor float64
+	Linear         bool
+}
+
+// NewOneCycle creates a 1cycle schedule.
+//
+// Parameters:
+//
+//	maxLR (float64): The peak learning rate.
+//	totalSteps (int): The number of steps in the cycle, usually epochs * batches per epoch.
+//	pctStart (float64): The fraction of the cycle spent increasing the learning rate, 0.3 in PyTorch.
+//	divFactor (float64): The initial learning rate is maxLR / divFactor, 25 in PyTorch.
+//	finalDivFactor (float64): The final learning rate is the initial one / finalDivFactor, 1e4 in PyTorch.
+//	annealStrategy (string): "cos" or "linear".
+//
+// Returns:
+//
+//	(*OneCycle, error): The schedule, or nil and an error if an argument is invalid.
+//
+// Errors:
+//
+//	Returns an error if totalSteps or a factor is not positive, pctStart is not in (0, 1), the warmup phase
+//	pctStart * totalSteps is not longer than one step, or the strategy is not recognised.
+func NewOneCycle(maxLR float64, totalSteps int, pctStart, divFactor, finalDivFactor float64, annealStrategy string) (*OneCycle, error) {
+	if totalSteps <= 0 || divFactor <= 0 || finalDivFactor <= 0 {
+		return nil, fmt.Errorf("totalSteps, divFactor and finalDivFactor must be positive")
+	}
+	if pctStart <= 0 || pctStart >= 1 {
+		return nil, fmt.Errorf("pctStart must be in (0, 1), got %v", pctStart)
+	}
+	if pctStart*float64(totalSteps) <= 1 {
+		return nil, fmt.Errorf("pctStart * totalSteps must be greater than 1, got %v", pctStart*float64(totalSteps))
+	}
+	if annealStrategy != "cos" && annealStrategy != "linear" {
+		return nil, fmt.Errorf("unknown anneal strategy %q, expected cos or linear", annealStrategy)
+	}
+	return &OneCycle{
+		MaxLR:          maxLR,
+		TotalSteps:     totalSteps,
+		PctStart:       pctStart,
+		DivFactor:      divFactor,
+		FinalDivFactor: finalDivFactor,
+		Linear:         annealStrategy == "linear",
+	}, nil
+}
+
+// LR returns the learning rate of the cycle at step, and the final learning rate after the cycle. A cycle without a
+// warmup phase starts at MaxLR.
+func (s *OneCycle) LR(base float64, step int) float64 {
+	initial := s.MaxLR / s.DivFactor
+	final := initial / s.FinalDivFactor
+	peak := s.PctStart*float64(s.TotalSteps) - 1
+	last := float64(s.TotalSteps - 1)
+	t := math.Min(float64(step), last)
+	anneal := cosine
+	if s.Linear {
+		anneal = func(start, end, t float64) float64 { return start + (end-start)*t }
+	}
+	if peak <= 0 {
+		peak = 0
+	} else if t <= peak {
+		return anneal(initial, s.MaxLR, t/peak)
+	}
+	if last <= peak {
+		return s.MaxLR
+	}
+	return anneal(s.MaxLR, final, (t-peak)/(last-peak))
+}
+
+// LinearWarmup scales the learning rate linearly from StartFactor times its initial value to the initial value over
+// WarmupSteps steps, like torch.optim.lr_scheduler.LinearLR. It is usually followed by a decay with Sequential.
+type LinearWarmup struct {
+	WarmupSteps int
+	StartFactor float64
+}
+
+// NewLinearWarmup creates a linear warmup schedule.
+//
+// Errors:
+//
+//	Returns an error if warmupSteps is not positive or startFactor is not in (0, 1].
+func NewLinearWarmup(warmupSteps int, startFactor float64) (*LinearWarmup, error) {
+	if warmupSteps <= 0 || startFactor <= 0 || startFactor > 1 {
+		return nil, fmt.Errorf("warmupSteps must be positive and startFactor in (0, 1], got %v and %v", warmupSteps, startFactor)
+	}
+	return &LinearWarmup{WarmupSteps: warmupSteps, StartFactor: startFactor}, nil
+}
+
+// LR returns base * (StartFactor + (1 - StartFactor) * min(step, WarmupSteps) / WarmupSteps).
+func (s *LinearWarmup) LR(base float64, step int) float64 {
+	t := math.Min(float64(step)/float64(s.WarmupSteps), 1)
+	return base * (s.StartFactor + (1-s.StartFactor)*t)
+}
+
+// PolynomialDecay decays the learning rate from its initial value to EndLR over TotalSteps steps following a
+// polynomial of the given power, like tf.keras.optimizers.schedules.PolynomialDecay and, with an EndLR of 0,
+// torch.optim.lr_scheduler.PolynomialLR.
+type PolynomialDecay struct {
+	TotalSteps int
+	Power      float64
+	EndLR      float64
+}
+
+// NewPolynomialDecay creates a polynomial decay schedule.
+//
+// Errors:
+//
+//	Returns an error if totalSteps or power is not positive.
+func NewPolynomialDecay(totalSteps int, power, endLR float64) (*PolynomialDecay, error) {
+	if totalSteps <= 0 || power <= 0 {
+		return nil, fmt.Errorf("totalSteps and power must be positive, got %v and %v", totalSteps, power)
+	}
+	return &PolynomialDecay{TotalSteps: totalSteps, Power: power, EndLR: endLR}, nil
+}
+
+// LR returns (base - EndLR) * (1 - min(step, TotalSteps) / TotalSteps)^Power + EndLR.
+func (s *PolynomialDecay) LR(base float64, step int) float64 {
+	t := math.Min(float64(step)/float64(s.TotalSteps), 1)
+	return (base-s.EndLR)*math.Pow(1-t, s.Power) + s.EndLR
+}
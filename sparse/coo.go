@@ -0,0 +1,105 @@
+package sparse
+
+import (
+	"fmt"
+
+	"github.com/timotewb/gonn/numpy"
+)
+
+// COO is a matrix in coordinate format, like scipy.sparse.coo_matrix: value Data[k] is at row Row[k] and column
+// Col[k]. Entries may be in any order and repeated, in which case they are summed. COO is the convenient format for
+// building a matrix incrementally; convert it to CSR for arithmetic.
+//
+// Fields:
+//
+//	Row ([]int): The row index of every entry.
+//	Col ([]int): The column index of every entry.
+//	Data ([]float64): The value of every entry.
+//	Shape ([2]int): The number of rows and columns.
+type COO struct {
+	Row   []int
+	Col   []int
+	Data  []float64
+	Shape [2]int
+}
+
+// NewCOO creates a COO matrix from its arrays, which are used without copying.
+//
+// Errors:
+//
+//	Returns an error if a dimension is negative, the arrays differ in length or an index is out of range.
+//
+// Example usage:
+//
+//	m, _ := sparse.NewCOO([]int{0, 1, 1}, []int{2, 0, 2}, []float64{1, 2, 3}, 2, 3)
+//	csr := m.ToCSR()
+func NewCOO(row, col []int, data []float64, rows, cols int) (*COO, error) {
+	if rows < 0 || cols < 0 {
+		return nil, fmt.Errorf("dimensions must not be negative, got (%v, %v)", rows, cols)
+	}
+	if len(row) != len(data) || len(col) != len(data) {
+		return nil, fmt.Errorf("row, col and data must have the same length, got %v, %v and %v", len(row), len(col), len(data))
+	}
+	for k := range data {
+		if row[k] < 0 || row[k] >= rows || col[k] < 0 || col[k] >= cols {
+			return nil, fmt.Errorf("index (%v, %v) is out of range for shape (%v, %v)", row[k], col[k], rows, cols)
+		}
+	}
+	return &COO{Row: row, Col: col, Data: data, Shape: [2]int{rows, cols}}, nil
+}
+
+// NNZ returns the number of stored entries, counting repeated positions separately.
+func (m *COO) NNZ() int {
+	return len(m.Data)
+}
+
+// Append adds an entry, which is summed with any other entry at the same position.
+//
+// Errors:
+//
+//	Returns an error if the index is out of range.
+func (m *COO) Append(i, j int, v float64) error {
+	if i < 0 || i >= m.Shape[0] || j < 0 || j >= m.Shape[1] {
+		return fmt.Errorf("index (%v, %v) is out of range for shape %v", i, j, m.Shape)
+	}
+	m.Row, m.Col, m.Data = append(m.Row, i), append(m.Col, j), append(m.Data, v)
+	return nil
+}
+
+// ToCSR converts the matrix to canonical CSR format, summing repeated entries.
+func (m *COO) ToCSR() *CSR {
+	out := &CSR{Indptr: make([]int, m.Shape[0]+1), Indices: make([]int, m.NNZ()), Data: make([]float64, m.NNZ()), Shape: m.Shape}
+	for _, i := range m.Row {
+		out.Indptr[i+1]++
+	}
+	for i := 0; i < m.Shape[0]; i++ {
+		out.Indptr[i+1] += out.Indptr[i]
+	}
+	next := append([]int{}, out.Indptr[:m.Shape[0]]...)
+	for k, i := range m.Row {
+		out.Indices[next[i]], out.Data[next[i]] = m.Col[k], m.Data[k]
+		next[i]++
+	}
+	out.canonicalize()
+	return out
+}
+
+// ToCSC converts the matrix to canonical CSC format, summing repeated entries.
+func (m *COO) ToCSC() *CSC {
+	return m.Transpose().ToCSR().Transpose()
+}
+
+// ToDense returns the matrix as a 2-D array.
+func (m *COO) ToDense() *numpy.NDArray {
+	out := numpy.ZerosArray(m.Shape[0], m.Shape[1])
+	d := out.Data()
+	for k, v := range m.Data {
+		d[m.Row[k]*m.Shape[1]+m.Col[k]] += v
+	}
+	return out
+}
+
+// Transpose returns the transpose, sharing the arrays of m with the roles of Row and Col exchanged.
+func (m *COO) Transpose() *COO {
+	return &COO{Row: m.Col, Col: m.Row, Data: m.Data, Shape: [2]int{m.Shape[1], m.Shape[0]}}
+}
@@ -0,0 +1,195 @@
+package sparse
+
+import (
+	"fmt"
+
+	"github.com/timotewb/gonn/numpy"
+)
+
+// CSC is a matrix in compressed sparse column format, like scipy.sparse.csc_matrix. The row indices and values of
+// column j are Indices[Indptr[j]:Indptr[j+1]] and Data[Indptr[j]:Indptr[j+1]].
+//
+// The arrays of a CSC matrix are those of the CSR matrix of its transpose, so transposing between the formats is
+// free and most operations are implemented by the CSR code. CSC is efficient for column access and for computing
+// X^T @ Y, as in the gradient of a linear layer with sparse inputs.
+//
+// Fields:
+//
+//	Indptr ([]int): The offsets of the columns in Indices and Data, of length cols + 1.
+//	Indices ([]int): The row index of every stored value.
+//	Data ([]float64): The stored values.
+//	Shape ([2]int): The number of rows and columns.
+type CSC struct {
+	Indptr  []int
+	Indices []int
+	Data    []float64
+	Shape   [2]int
+}
+
+// NewCSC creates a CSC matrix from its arrays, which are used without copying. Unsorted row indices are sorted and
+// duplicates summed.
+//
+// Errors:
+//
+//	Returns an error if a dimension is negative, Indptr does not have cols + 1 non-decreasing offsets ending at
+//	len(Indices), Indices and Data differ in length, or a row index is out of range.
+func NewCSC(indptr, indices []int, data []float64, rows, cols int) (*CSC, error) {
+	t, err := NewCSR(indptr, indices, data, cols, rows)
+	if err != nil {
+		return nil, err
+	}
+	return t.Transpose(), nil
+}
+
+// Transpose returns the transpose in CSR format without copying.
+func (m *CSC) Transpose() *CSR {
+	return &CSR{Indptr: m.Indptr, Indices: m.Indices, Data: m.Data, Shape: [2]int{m.Shape[1], m.Shape[0]}}
+}
+
+// T returns the transpose as a new CSC matrix.
+func (m *CSC) T() *CSC {
+	return m.ToCSR().Transpose()
+}
+
+// NNZ returns the number of stored values.
+func (m *CSC) NNZ() int {
+	return len(m.Data)
+}
+
+// Copy returns a deep copy of the matrix.
+func (m *CSC) Copy() *CSC {
+	return m.Transpose().Copy().Transpose()
+}
+
+// At returns the element at row i and column j.
+//
+// Errors:
+//
+//	Returns an error if the index is out of range.
+func (m *CSC) At(i, j int) (float64, error) {
+	if i < 0 || i >= m.Shape[0] || j < 0 || j >= m.Shape[1] {
+		return 0, fmt.Errorf("index (%v, %v) is out of range for shape %v", i, j, m.Shape)
+	}
+	return m.Transpose().At(j, i)
+}
+
+// Col returns the row indices and values stored in column j, sharing memory with the matrix.
+//
+// Errors:
+//
+//	Returns an error if j is out of range.
+func (m *CSC) Col(j int) ([]int, []float64, error) {
+	if j < 0 || j >= m.Shape[1] {
+		return nil, nil, fmt.Errorf("column %v is out of range for %v columns", j, m.Shape[1])
+	}
+	return m.Transpose().Row(j)
+}
+
+// ToDense returns the matrix as a 2-D array.
+func (m *CSC) ToDense() *numpy.NDArray {
+	return m.ToCSR().ToDense()
+}
+
+// ToCSR returns the matrix in compressed sparse row format.
+func (m *CSC) ToCSR() *CSR {
+	return m.Transpose().T()
+}
+
+// ToCOO returns the matrix in coordinate format.
+func (m *CSC) ToCOO() *COO {
+	return m.Transpose().ToCOO().Transpose()
+}
+
+// MatMul multiplies the matrix by a dense vector of shape (cols,) or matrix of shape (cols, n), scattering every
+// column into the result.
+//
+// Errors:
+//
+//	Returns an error if b is not 1-D or 2-D or its first dimension does not match the columns of m.
+func (m *CSC) MatMul(b *numpy.NDArray) (*numpy.NDArray, error) {
+	shape := b.Shape()
+	if len(shape) < 1 || len(shape) > 2 || shape[0] != m.Shape[1] {
+		return nil, fmt.Errorf("cannot multiply a %v sparse matrix by an array of shape %v", m.Shape, shape)
+	}
+	n := 1
+	if len(shape) == 2 {
+		n = shape[1]
+	}
+	bd := b.AsContiguous().Data()
+	out := make([]float64, m.Shape[0]*n)
+	for j := 0; j < m.Shape[1]; j++ {
+		bj := bd[j*n : (j+1)*n]
+		for k := m.Indptr[j]; k < m.Indptr[j+1]; k++ {
+			o, v := out[m.Indices[k]*n:(m.Indices[k]+1)*n], m.Data[k]
+			for c := range bj {
+				o[c] += v * bj[c]
+			}
+		}
+	}
+	if len(shape) == 1 {
+		return numpy.NewArray(out, m.Shape[0])
+	}
+	return numpy.NewArray(out, m.Shape[0], n)
+}
+
+// Add returns the element-wise sum m + b.
+//
+// Errors:
+//
+//	Returns an error if the shapes differ.
+func (m *CSC) Add(b *CSC) (*CSC, error) {
+	out, err := m.Transpose().Add(b.Transpose())
+	if err != nil {
+		return nil, err
+	}
+	return out.Transpose(), nil
+}
+
+// Sub returns the element-wise difference m - b.
+//
+// Errors:
+//
+//	Returns an error if the shapes differ.
+func (m *CSC) Sub(b *CSC) (*CSC, error) {
+	out, err := m.Transpose().Sub(b.Transpose())
+	if err != nil {
+		return nil, err
+	}
+	return out.Transpose(), nil
+}
+
+// Multiply returns the element-wise product m * b.
+//
+// Errors:
+//
+//	Returns an error if the shapes differ.
+func (m *CSC) Multiply(b *CSC) (*CSC, error) {
+	out, err := m.Transpose().Multiply(b.Transpose())
+	if err != nil {
+		return nil, err
+	}
+	return out.Transpose(), nil
+}
+
+// Scale returns the matrix multiplied by s.
+func (m *CSC) Scale(s float64) *CSC {
+	return m.Transpose().Scale(s).Transpose()
+}
+
+// Apply returns a matrix with fn applied to every stored value. Zeros that are not stored are left unchanged, so
+// fn should map zero to zero.
+func (m *CSC) Apply(fn func(float64) float64) *CSC {
+	return m.Transpose().Apply(fn).Transpose()
+}
+
+// Sum returns the column sums of shape (cols,) for axis 0 or the row sums of shape (rows,) for axis 1.
+//
+// Errors:
+//
+//	Returns an error if axis is not 0 or 1.
+func (m *CSC) Sum(axis int) (*numpy.NDArray, error) {
+	if axis != 0 && axis != 1 {
+		return nil, fmt.Errorf("axis must be 0 or 1, got %v", axis)
+	}
+	return m.Transpose().Sum(1 - axis)
+}
@@ -0,0 +1,507 @@
+package sparse
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/timotewb/gonn/numpy"
+)
+
+// CSR is a matrix in compressed sparse row format, like scipy.sparse.csr_matrix. The column indices and values of
+// row i are Indices[Indptr[i]:Indptr[i+1]] and Data[Indptr[i]:Indptr[i+1]].
+//
+// Matrices built by this package are canonical: the column indices of each row are sorted and unique. CSR is the
+// format for arithmetic and row slicing, as used for bag-of-words features where each row is a document.
+//
+// Fields:
+//
+//	Indptr ([]int): The offsets of the rows in Indices and Data, of length rows + 1.
+//	Indices ([]int): The column index of every stored value.
+//	Data ([]float64): The stored values.
+//	Shape ([2]int): The number of rows and columns.
+type CSR struct {
+	Indptr  []int
+	Indices []int
+	Data    []float64
+	Shape   [2]int
+}
+
+// NewCSR creates a CSR matrix from its arrays, which are used without copying. Unsorted column indices are sorted and
+// duplicates summed, so the result is canonical.
+//
+// Errors:
+//
+//	Returns an error if a dimension is negative, Indptr does not have rows + 1 non-decreasing offsets ending at
+//	len(Indices), Indices and Data differ in length, or a column index is out of range.
+func NewCSR(indptr, indices []int, data []float64, rows, cols int) (*CSR, error) {
+	if rows < 0 || cols < 0 {
+		return nil, fmt.Errorf("dimensions must not be negative, got (%v, %v)", rows, cols)
+	}
+	if len(indptr) != rows+1 || indptr[0] != 0 || indptr[rows] != len(indices) {
+		return nil, fmt.Errorf("indptr must have %v offsets from 0 to %v", rows+1, len(indices))
+	}
+	if len(indices) != len(data) {
+		return nil, fmt.Errorf("indices and data must have the same length, got %v and %v", len(indices), len(data))
+	}
+	for i := 0; i < rows; i++ {
+		if indptr[i] > indptr[i+1] {
+			return nil, fmt.Errorf("indptr must not decrease, got %v before %v", indptr[i], indptr[i+1])
+		}
+	}
+	for _, j := range indices {
+		if j < 0 || j >= cols {
+			return nil, fmt.Errorf("column index %v is out of range for %v columns", j, cols)
+		}
+	}
+	m := &CSR{Indptr: indptr, Indices: indices, Data: data, Shape: [2]int{rows, cols}}
+	m.canonicalize()
+	return m, nil
+}
+
+// Zeros returns an empty CSR matrix of the given shape.
+func Zeros(rows, cols int) *CSR {
+	return &CSR{Indptr: make([]int, rows+1), Shape: [2]int{rows, cols}}
+}
+
+// Identity returns the n x n identity matrix in CSR format.
+func Identity(n int) *CSR {
+	m := &CSR{Indptr: make([]int, n+1), Indices: make([]int, n), Data: make([]float64, n), Shape: [2]int{n, n}}
+	for i := 0; i < n; i++ {
+		m.Indptr[i+1], m.Indices[i], m.Data[i] = i+1, i, 1
+	}
+	return m
+}
+
+// FromDense converts a 2-D array to CSR format, storing its nonzero elements.
+//
+// Errors:
+//
+//	Returns an error if a is not 2-D.
+func FromDense(a *numpy.NDArray) (*CSR, error) {
+	s := a.Shape()
+	if len(s) != 2 {
+		return nil, fmt.Errorf("expected a 2-D array, got shape %v", s)
+	}
+	values := a.Data()
+	m := &CSR{Indptr: make([]int, s[0]+1), Shape: [2]int{s[0], s[1]}}
+	for i := 0; i < s[0]; i++ {
+		for j, v := range values[i*s[1] : (i+1)*s[1]] {
+			if v != 0 {
+				m.Indices = append(m.Indices, j)
+				m.Data = append(m.Data, v)
+			}
+		}
+		m.Indptr[i+1] = len(m.Indices)
+	}
+	return m, nil
+}
+
+// canonicalize sorts the column indices of every row and sums duplicates, compacting the arrays in place.
+func (m *CSR) canonicalize() {
+	out := 0
+	start := 0
+	for i := 0; i < m.Shape[0]; i++ {
+		end := m.Indptr[i+1]
+		row := entries{m.Indices[start:end], m.Data[start:end]}
+		if !sort.IsSorted(row) {
+			sort.Stable(row)
+		}
+		rowStart := out
+		for k := start; k < end; k++ {
+			if out > rowStart && m.Indices[out-1] == m.Indices[k] {
+				m.Data[out-1] += m.Data[k]
+				continue
+			}
+			m.Indices[out], m.Data[out] = m.Indices[k], m.Data[k]
+			out++
+		}
+		start = end
+		m.Indptr[i+1] = out
+	}
+	m.Indices, m.Data = m.Indices[:out], m.Data[:out]
+}
+
+// entries sorts parallel index and value slices by index.
+type entries struct {
+	indices []int
+	data    []float64
+}
+
+func (e entries) Len() int           { return len(e.indices) }
+func (e entries) Less(a, b int) bool { return e.indices[a] < e.indices[b] }
+func (e entries) Swap(a, b int) {
+	e.indices[a], e.indices[b] = e.indices[b], e.indices[a]
+	e.data[a], e.data[b] = e.data[b], e.data[a]
+}
+
+// NNZ returns the number of stored values.
+func (m *CSR) NNZ() int {
+	return len(m.Data)
+}
+
+// Copy returns a deep copy of the matrix.
+func (m *CSR) Copy() *CSR {
+	return &CSR{
+		Indptr:  append([]int{}, m.Indptr...),
+		Indices: append([]int{}, m.Indices...),
+		Data:    append([]float64{}, m.Data...),
+		Shape:   m.Shape,
+	}
+}
+
+// At returns the element at row i and column j, found by binary search within the row.
+//
+// Errors:
+//
+//	Returns an error if the index is out of range.
+func (m *CSR) At(i, j int) (float64, error) {
+	if i < 0 || i >= m.Shape[0] || j < 0 || j >= m.Shape[1] {
+		return 0, fmt.Errorf("index (%v, %v) is out of range for shape %v", i, j, m.Shape)
+	}
+	cols := m.Indices[m.Indptr[i]:m.Indptr[i+1]]
+	k := sort.SearchInts(cols, j)
+	if k < len(cols) && cols[k] == j {
+		return m.Data[m.Indptr[i]+k], nil
+	}
+	return 0, nil
+}
+
+// Row returns the column indices and values stored in row i, sharing memory with the matrix.
+//
+// Errors:
+//
+//	Returns an error if i is out of range.
+func (m *CSR) Row(i int) ([]int, []float64, error) {
+	if i < 0 || i >= m.Shape[0] {
+		return nil, nil, fmt.Errorf("row %v is out of range for %v rows", i, m.Shape[0])
+	}
+	return m.Indices[m.Indptr[i]:m.Indptr[i+1]], m.Data[m.Indptr[i]:m.Indptr[i+1]], nil
+}
+
+// RowSlice returns rows [start, end) as a new matrix, like m[start:end] in SciPy.
+//
+// Errors:
+//
+//	Returns an error if the range is not within the rows.
+func (m *CSR) RowSlice(start, end int) (*CSR, error) {
+	if start < 0 || end > m.Shape[0] || start > end {
+		return nil, fmt.Errorf("row range [%v, %v) is out of range for %v rows", start, end, m.Shape[0])
+	}
+	lo, hi := m.Indptr[start], m.Indptr[end]
+	out := &CSR{
+		Indptr:  make([]int, end-start+1),
+		Indices: append([]int{}, m.Indices[lo:hi]...),
+		Data:    append([]float64{}, m.Data[lo:hi]...),
+		Shape:   [2]int{end - start, m.Shape[1]},
+	}
+	for i := range out.Indptr {
+		out.Indptr[i] = m.Indptr[start+i] - lo
+	}
+	return out, nil
+}
+
+// SelectRows returns the given rows, in order and possibly repeated, as a new matrix, like m[rows] in SciPy. It is
+// used to gather the samples of a shuffled batch.
+//
+// Errors:
+//
+//	Returns an error if a row is out of range.
+func (m *CSR) SelectRows(rows []int) (*CSR, error) {
+	out := &CSR{Indptr: make([]int, len(rows)+1), Shape: [2]int{len(rows), m.Shape[1]}}
+	for k, i := range rows {
+		cols, values, err := m.Row(i)
+		if err != nil {
+			return nil, err
+		}
+		out.Indices = append(out.Indices, cols...)
+		out.Data = append(out.Data, values...)
+		out.Indptr[k+1] = len(out.Indices)
+	}
+	return out, nil
+}
+
+// ToDense returns the matrix as a 2-D array.
+func (m *CSR) ToDense() *numpy.NDArray {
+	out := numpy.ZerosArray(m.Shape[0], m.Shape[1])
+	d := out.Data()
+	for i := 0; i < m.Shape[0]; i++ {
+		for k := m.Indptr[i]; k < m.Indptr[i+1]; k++ {
+			d[i*m.Shape[1]+m.Indices[k]] += m.Data[k]
+		}
+	}
+	return out
+}
+
+// ToCOO returns the matrix in coordinate format.
+func (m *CSR) ToCOO() *COO {
+	out := &COO{Row: make([]int, m.NNZ()), Col: append([]int{}, m.Indices...), Data: append([]float64{}, m.Data...), Shape: m.Shape}
+	for i := 0; i < m.Shape[0]; i++ {
+		for k := m.Indptr[i]; k < m.Indptr[i+1]; k++ {
+			out.Row[k] = i
+		}
+	}
+	return out
+}
+
+// transposed returns the CSR matrix of the transpose, with sorted indices, by counting the values of each column.
+func (m *CSR) transposed() *CSR {
+	rows, cols := m.Shape[0], m.Shape[1]
+	out := &CSR{Indptr: make([]int, cols+1), Indices: make([]int, m.NNZ()), Data: make([]float64, m.NNZ()), Shape: [2]int{cols, rows}}
+	for _, j := range m.Indices {
+		out.Indptr[j+1]++
+	}
+	for j := 0; j < cols; j++ {
+		out.Indptr[j+1] += out.Indptr[j]
+	}
+	next := append([]int{}, out.Indptr[:cols]...)
+	for i := 0; i < rows; i++ {
+		for k := m.Indptr[i]; k < m.Indptr[i+1]; k++ {
+			j := m.Indices[k]
+			out.Indices[next[j]], out.Data[next[j]] = i, m.Data[k]
+			next[j]++
+		}
+	}
+	return out
+}
+
+// ToCSC returns the matrix in compressed sparse column format.
+func (m *CSR) ToCSC() *CSC {
+	t := m.transposed()
+	return &CSC{Indptr: t.Indptr, Indices: t.Indices, Data: t.Data, Shape: m.Shape}
+}
+
+// Transpose returns the transpose in CSC format without copying, as the CSR arrays of a matrix are the CSC arrays
+// of its transpose. Use T for a CSR result.
+func (m *CSR) Transpose() *CSC {
+	return &CSC{Indptr: m.Indptr, Indices: m.Indices, Data: m.Data, Shape: [2]int{m.Shape[1], m.Shape[0]}}
+}
+
+// T returns the transpose as a new CSR matrix.
+func (m *CSR) T() *CSR {
+	return m.transposed()
+}
+
+// MatMul multiplies the matrix by a dense matrix of shape (cols, n) or a vector of shape (cols,), returning a dense
+// result of shape (rows, n) or (rows,). The cost is proportional to NNZ() * n.
+//
+// Errors:
+//
+//	Returns an error if b is not 1-D or 2-D or its first dimension is not the number of columns.
+func (m *CSR) MatMul(b *numpy.NDArray) (*numpy.NDArray, error) {
+	s := b.Shape()
+	if (len(s) != 1 && len(s) != 2) || s[0] != m.Shape[1] {
+		return nil, fmt.Errorf("cannot multiply a matrix of shape %v by an array of shape %v", m.Shape, s)
+	}
+	n := 1
+	outShape := []int{m.Shape[0]}
+	if len(s) == 2 {
+		n = s[1]
+		outShape = append(outShape, n)
+	}
+	bd := b.Data()
+	out := make([]float64, m.Shape[0]*n)
+	for i := 0; i < m.Shape[0]; i++ {
+		row := out[i*n : (i+1)*n]
+		for k := m.Indptr[i]; k < m.Indptr[i+1]; k++ {
+			v, src := m.Data[k], bd[m.Indices[k]*n:(m.Indices[k]+1)*n]
+			for c := range row {
+				row[c] += v * src[c]
+			}
+		}
+	}
+	return numpy.NewArray(out, outShape...)
+}
+
+// MatMulSparse multiplies two sparse matrices with Gustavson's row-by-row algorithm, returning a canonical CSR
+// matrix. Products that cancel to zero are kept as explicit zeros; call Prune to drop them.
+//
+// Errors:
+//
+//	Returns an error if the inner dimensions differ.
+func (m *CSR) MatMulSparse(b *CSR) (*CSR, error) {
+	if m.Shape[1] != b.Shape[0] {
+		return nil, fmt.Errorf("cannot multiply matrices of shapes %v and %v", m.Shape, b.Shape)
+	}
+	cols := b.Shape[1]
+	out := &CSR{Indptr: make([]int, m.Shape[0]+1), Shape: [2]int{m.Shape[0], cols}}
+	acc := make([]float64, cols)
+	seen := make([]bool, cols)
+	var used []int
+	for i := 0; i < m.Shape[0]; i++ {
+		used = used[:0]
+		for k := m.Indptr[i]; k < m.Indptr[i+1]; k++ {
+			v, r := m.Data[k], m.Indices[k]
+			for kk := b.Indptr[r]; kk < b.Indptr[r+1]; kk++ {
+				j := b.Indices[kk]
+				if !seen[j] {
+					seen[j] = true
+					used = append(used, j)
+				}
+				acc[j] += v * b.Data[kk]
+			}
+		}
+		sort.Ints(used)
+		for _, j := range used {
+			out.Indices = append(out.Indices, j)
+			out.Data = append(out.Data, acc[j])
+			acc[j], seen[j] = 0, false
+		}
+		out.Indptr[i+1] = len(out.Indices)
+	}
+	return out, nil
+}
+
+// merge combines two canonical matrices of the same shape element by element. Where only one matrix stores a value
+// the other is taken as zero; with union unset, such positions are skipped, which is correct when fn(x, 0) and
+// fn(0, y) are zero.
+func (m *CSR) merge(b *CSR, union bool, fn func(x, y float64) float64) (*CSR, error) {
+	if m.Shape != b.Shape {
+		return nil, fmt.Errorf("matrices of shapes %v and %v do not match", m.Shape, b.Shape)
+	}
+	out := &CSR{Indptr: make([]int, m.Shape[0]+1), Shape: m.Shape}
+	emit := func(j int, v float64) {
+		out.Indices = append(out.Indices, j)
+		out.Data = append(out.Data, v)
+	}
+	for i := 0; i < m.Shape[0]; i++ {
+		p, pe := m.Indptr[i], m.Indptr[i+1]
+		q, qe := b.Indptr[i], b.Indptr[i+1]
+		for p < pe || q < qe {
+			switch {
+			case q == qe || (p < pe && m.Indices[p] < b.Indices[q]):
+				if union {
+					emit(m.Indices[p], fn(m.Data[p], 0))
+				}
+				p++
+			case p == pe || b.Indices[q] < m.Indices[p]:
+				if union {
+					emit(b.Indices[q], fn(0, b.Data[q]))
+				}
+				q++
+			default:
+				emit(m.Indices[p], fn(m.Data[p], b.Data[q]))
+				p, q = p+1, q+1
+			}
+		}
+		out.Indptr[i+1] = len(out.Indices)
+	}
+	return out, nil
+}
+
+// Add returns the element-wise sum m + b.
+//
+// Errors:
+//
+//	Returns an error if the shapes differ.
+func (m *CSR) Add(b *CSR) (*CSR, error) {
+	return m.merge(b, true, func(x, y float64) float64 { return x + y })
+}
+
+// Sub returns the element-wise difference m - b.
+//
+// Errors:
+//
+//	Returns an error if the shapes differ.
+func (m *CSR) Sub(b *CSR) (*CSR, error) {
+	return m.merge(b, true, func(x, y float64) float64 { return x - y })
+}
+
+// Multiply returns the element-wise (Hadamard) product of m and b, which stores only the positions stored in both.
+//
+// Errors:
+//
+//	Returns an error if the shapes differ.
+func (m *CSR) Multiply(b *CSR) (*CSR, error) {
+	return m.merge(b, false, func(x, y float64) float64 { return x * y })
+}
+
+// Maximum returns the element-wise maximum of m and b.
+//
+// Errors:
+//
+//	Returns an error if the shapes differ.
+func (m *CSR) Maximum(b *CSR) (*CSR, error) {
+	return m.merge(b, true, func(x, y float64) float64 {
+		if x > y {
+			return x
+		}
+		return y
+	})
+}
+
+// MultiplyDense returns the element-wise product of m and a dense array of the same shape, as a sparse matrix with
+// the positions of m.
+//
+// Errors:
+//
+//	Returns an error if the shapes differ.
+func (m *CSR) MultiplyDense(b *numpy.NDArray) (*CSR, error) {
+	s := b.Shape()
+	if len(s) != 2 || s[0] != m.Shape[0] || s[1] != m.Shape[1] {
+		return nil, fmt.Errorf("matrix of shape %v does not match array of shape %v", m.Shape, s)
+	}
+	bd := b.Data()
+	out := m.Copy()
+	for i := 0; i < m.Shape[0]; i++ {
+		for k := m.Indptr[i]; k < m.Indptr[i+1]; k++ {
+			out.Data[k] *= bd[i*m.Shape[1]+m.Indices[k]]
+		}
+	}
+	return out, nil
+}
+
+// Scale returns the matrix multiplied by s.
+func (m *CSR) Scale(s float64) *CSR {
+	return m.Apply(func(v float64) float64 { return v * s })
+}
+
+// Apply returns a matrix with fn applied to every stored value, like operations on the data attribute in SciPy.
+// Positions that are not stored stay zero, so fn should map zero to zero, as math.Sqrt or math.Abs do.
+func (m *CSR) Apply(fn func(float64) float64) *CSR {
+	out := m.Copy()
+	for k, v := range out.Data {
+		out.Data[k] = fn(v)
+	}
+	return out
+}
+
+// Prune returns a matrix without the explicitly stored zeros.
+func (m *CSR) Prune() *CSR {
+	out := &CSR{Indptr: make([]int, m.Shape[0]+1), Shape: m.Shape}
+	for i := 0; i < m.Shape[0]; i++ {
+		for k := m.Indptr[i]; k < m.Indptr[i+1]; k++ {
+			if m.Data[k] != 0 {
+				out.Indices = append(out.Indices, m.Indices[k])
+				out.Data = append(out.Data, m.Data[k])
+			}
+		}
+		out.Indptr[i+1] = len(out.Indices)
+	}
+	return out
+}
+
+// Sum returns the sums along an axis: the column sums, of shape (cols,), for axis 0 and the row sums, of shape
+// (rows,), for axis 1.
+//
+// Errors:
+//
+//	Returns an error if axis is not 0 or 1.
+func (m *CSR) Sum(axis int) (*numpy.NDArray, error) {
+	switch axis {
+	case 0:
+		out := make([]float64, m.Shape[1])
+		for k, j := range m.Indices {
+			out[j] += m.Data[k]
+		}
+		return numpy.NewArray(out, m.Shape[1])
+	case 1:
+		out := make([]float64, m.Shape[0])
+		for i := range out {
+			for k := m.Indptr[i]; k < m.Indptr[i+1]; k++ {
+				out[i] += m.Data[k]
+			}
+		}
+		return numpy.NewArray(out, m.Shape[0])
+	}
+	return nil, fmt.Errorf("axis must be 0 or 1, got %v", axis)
+}